@@ -0,0 +1,215 @@
+// Package bucket 提供保存单个值的分布式对象
+package bucket
+
+import (
+	"context"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+
+	"github.com/MaricoHan/redisson/mutex"
+	"github.com/MaricoHan/redisson/pkg/backend"
+	"github.com/MaricoHan/redisson/pkg/object"
+)
+
+var bucketScript = struct {
+	getScript           *backend.Script
+	setScript           *backend.Script
+	trySetScript        *backend.Script
+	compareAndSetScript *backend.Script
+	getAndSetScript     *backend.Script
+	getAndDeleteScript  *backend.Script
+}{}
+
+// Bucket 保存单个值，值通过对象的编解码器编码后存入 redis 的 string
+type Bucket struct {
+	*object.Object
+}
+
+func NewBucket(root *mutex.Root, name string, opts ...object.Option) *Bucket {
+	root.Logger.Debugf("创建 Bucket 实例: %s", name)
+	return &Bucket{Object: object.New(root, name).With(opts...)}
+}
+
+// Get 将值解码到 v 中，v 必须为指针；值不存在时返回 false
+func (b *Bucket) Get(ctx context.Context, v interface{}) (bool, error) {
+	data, ok, err := b.CachedLoad("", func() (string, bool, error) {
+		return b.text(b.Root().Eval(ctx, bucketScript.getScript, []string{b.Key()}), "查询")
+	})
+	if err != nil || !ok {
+		return false, err
+	}
+	return true, b.Decode([]byte(data), v)
+}
+
+// Set 设置值，同时清除过期时间
+func (b *Bucket) Set(ctx context.Context, v interface{}) error {
+	return b.SetWithTTL(ctx, v, 0)
+}
+
+// SetWithTTL 设置值与过期时间，ttl 为 0 时不过期
+func (b *Bucket) SetWithTTL(ctx context.Context, v interface{}, ttl time.Duration) error {
+	defer b.InvalidateCache()
+
+	data, err := b.Encode(v)
+	if err != nil {
+		return err
+	}
+	err = b.Root().Eval(ctx, bucketScript.setScript, []string{b.Key()}, data, int64(ttl/time.Millisecond)).Err()
+	if err != nil {
+		b.Root().Logger.Errorf("设置 Bucket 失败: %s, 错误: %v", b.Name(), err)
+		return err
+	}
+	return nil
+}
+
+// TrySet 值不存在时设置值与过期时间，ttl 为 0 时不过期；值已存在时返回 false
+func (b *Bucket) TrySet(ctx context.Context, v interface{}, ttl time.Duration) (bool, error) {
+	defer b.InvalidateCache()
+
+	data, err := b.Encode(v)
+	if err != nil {
+		return false, err
+	}
+	res, err := b.Root().Eval(ctx, bucketScript.trySetScript, []string{b.Key()}, data, int64(ttl/time.Millisecond)).Int64()
+	if err != nil {
+		b.Root().Logger.Errorf("设置 Bucket 失败: %s, 错误: %v", b.Name(), err)
+		return false, err
+	}
+	return res == 1, nil
+}
+
+// CompareAndSet 当前值等于 expect 时设置为 update，返回是否设置成功。
+// 设置成功时清除过期时间，与 GetAndSet 相同。
+// expect 为 nil 表示期望值不存在，update 为 nil 表示删除；值按编码后的字节比较，
+// 只适用于编码结果确定的值：MsgPack、Gob、Protobuf 编码 map 时键的顺序不固定，
+// 相等的值可能编码出不同的字节导致比较失败，这类值应使用 JSON 编解码器（按键排序）或改用结构体
+func (b *Bucket) CompareAndSet(ctx context.Context, expect, update interface{}) (bool, error) {
+	defer b.InvalidateCache()
+
+	args := make([]interface{}, 0, 4)
+	for _, v := range []interface{}{expect, update} {
+		if v == nil {
+			args = append(args, 0, "")
+			continue
+		}
+		data, err := b.Encode(v)
+		if err != nil {
+			return false, err
+		}
+		args = append(args, 1, data)
+	}
+
+	res, err := b.Root().Eval(ctx, bucketScript.compareAndSetScript, []string{b.Key()}, args...).Int64()
+	if err != nil {
+		b.Root().Logger.Errorf("比较并设置 Bucket 失败: %s, 错误: %v", b.Name(), err)
+		return false, err
+	}
+	return res == 1, nil
+}
+
+// GetAndSet 设置新值并清除过期时间，将旧值解码到 old 中；旧值不存在时返回 false
+func (b *Bucket) GetAndSet(ctx context.Context, v interface{}, old interface{}) (bool, error) {
+	defer b.InvalidateCache()
+
+	data, err := b.Encode(v)
+	if err != nil {
+		return false, err
+	}
+	return b.decode(b.Root().Eval(ctx, bucketScript.getAndSetScript, []string{b.Key()}, data), old, "设置")
+}
+
+// GetAndDelete 删除值，将删除前的值解码到 old 中；值不存在时返回 false
+func (b *Bucket) GetAndDelete(ctx context.Context, old interface{}) (bool, error) {
+	defer b.InvalidateCache()
+
+	return b.decode(b.Root().Eval(ctx, bucketScript.getAndDeleteScript, []string{b.Key()}), old, "删除")
+}
+
+// decode 解码脚本返回的值，nil 表示值不存在
+func (b *Bucket) decode(cmd *redis.Cmd, v interface{}, desc string) (bool, error) {
+	res, ok, err := b.text(cmd, desc)
+	if err != nil || !ok || v == nil {
+		return ok, err
+	}
+	return true, b.Decode([]byte(res), v)
+}
+
+// text 返回脚本返回的编码值，nil 表示值不存在
+func (b *Bucket) text(cmd *redis.Cmd, desc string) (string, bool, error) {
+	res, err := cmd.Text()
+	if err == redis.Nil {
+		return "", false, nil
+	}
+	if err != nil {
+		b.Root().Logger.Errorf("%s Bucket 失败: %s, 错误: %v", desc, b.Name(), err)
+		return "", false, err
+	}
+	return res, true, nil
+}
+
+func init() {
+	bucketScript.getScript = backend.NewScript("bucket.get", `
+	-- KEYS[1] Bucket
+	return redis.call('get',KEYS[1])
+`)
+
+	bucketScript.setScript = backend.NewScript("bucket.set", `
+	-- KEYS[1] Bucket
+	-- ARGV[1] 值
+	-- ARGV[2] 过期时间，单位：ms，为 0 时不过期
+	if tonumber(ARGV[2]) > 0 then
+		return redis.call('set',KEYS[1],ARGV[1],'px',ARGV[2])
+	end
+	return redis.call('set',KEYS[1],ARGV[1])
+`)
+
+	bucketScript.trySetScript = backend.NewScript("bucket.trySet", `
+	-- KEYS[1] Bucket
+	-- ARGV[1] 值
+	-- ARGV[2] 过期时间，单位：ms，为 0 时不过期
+	if redis.call('exists',KEYS[1]) == 1 then
+		return 0
+	end
+	redis.call('set',KEYS[1],ARGV[1])
+	if tonumber(ARGV[2]) > 0 then
+		redis.call('pexpire',KEYS[1],ARGV[2])
+	end
+	return 1
+`)
+
+	bucketScript.compareAndSetScript = backend.NewScript("bucket.compareAndSet", `
+	-- KEYS[1] Bucket
+	-- ARGV[1] 期望值是否存在：1-是 0-否
+	-- ARGV[2] 期望值
+	-- ARGV[3] 新值是否存在：1-是 0-否，为 0 时删除
+	-- ARGV[4] 新值
+	local value = redis.call('get',KEYS[1])
+	if ARGV[1] == '0' then
+		if value ~= false then
+			return 0
+		end
+	elseif value ~= ARGV[2] then
+		return 0
+	end
+	if ARGV[3] == '0' then
+		redis.call('del',KEYS[1])
+	else
+		redis.call('set',KEYS[1],ARGV[4])
+	end
+	return 1
+`)
+
+	bucketScript.getAndSetScript = backend.NewScript("bucket.getAndSet", `
+	-- KEYS[1] Bucket
+	-- ARGV[1] 新值
+	return redis.call('getset',KEYS[1],ARGV[1])
+`)
+
+	bucketScript.getAndDeleteScript = backend.NewScript("bucket.getAndDelete", `
+	-- KEYS[1] Bucket
+	local value = redis.call('get',KEYS[1])
+	redis.call('del',KEYS[1])
+	return value
+`)
+}
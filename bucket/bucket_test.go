@@ -0,0 +1,100 @@
+package bucket_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+
+	"github.com/MaricoHan/redisson"
+	"github.com/MaricoHan/redisson/pkg/codec"
+	"github.com/MaricoHan/redisson/pkg/object"
+)
+
+type featureFlag struct {
+	Enabled bool
+	Percent int
+}
+
+func TestBucket(t *testing.T) {
+	client := redis.NewClient(&redis.Options{Addr: ":6379"})
+	client.Del(context.Background(), "bucketKey")
+	r := redisson.New(context.Background(), client)
+
+	b := r.NewBucket("bucketKey")
+	var flag featureFlag
+	if ok, err := b.Get(context.Background(), &flag); err != nil || ok {
+		t.Errorf("bucket should be empty: %v", err)
+		return
+	}
+
+	// 测试：值不存在时才设置
+	if ok, err := b.TrySet(context.Background(), featureFlag{Enabled: true, Percent: 10}, time.Minute); err != nil || !ok {
+		t.Errorf("try set failed: %v", err)
+		return
+	}
+	if ok, _ := b.TrySet(context.Background(), featureFlag{}, 0); ok {
+		t.Error("value should not be overwritten")
+		return
+	}
+	if ttl, _ := b.RemainTTL(context.Background()); ttl <= 0 || ttl > time.Minute {
+		t.Errorf("unexpected ttl: %v", ttl)
+		return
+	}
+	if ok, err := b.Get(context.Background(), &flag); err != nil || !ok || !flag.Enabled || flag.Percent != 10 {
+		t.Errorf("unexpected value: %+v, %v", flag, err)
+		return
+	}
+
+	// 测试：比较并设置
+	if ok, _ := b.CompareAndSet(context.Background(), featureFlag{Enabled: true, Percent: 20}, featureFlag{}); ok {
+		t.Error("compare and set should fail")
+		return
+	}
+	if ok, err := b.CompareAndSet(context.Background(), featureFlag{Enabled: true, Percent: 10}, featureFlag{Enabled: true, Percent: 50}); err != nil || !ok {
+		t.Errorf("compare and set failed: %v", err)
+		return
+	}
+
+	var old featureFlag
+	if ok, err := b.GetAndSet(context.Background(), featureFlag{Percent: 100}, &old); err != nil || !ok || old.Percent != 50 {
+		t.Errorf("unexpected old value: %+v, %v", old, err)
+		return
+	}
+	if ok, err := b.GetAndDelete(context.Background(), &old); err != nil || !ok || old.Percent != 100 {
+		t.Errorf("unexpected old value: %+v, %v", old, err)
+		return
+	}
+
+	// 测试：期望值为 nil 表示不存在
+	if ok, err := b.CompareAndSet(context.Background(), nil, featureFlag{Enabled: true}); err != nil || !ok {
+		t.Errorf("compare and set failed: %v", err)
+		return
+	}
+	if ok, err := b.CompareAndSet(context.Background(), featureFlag{Enabled: true}, nil); err != nil || !ok {
+		t.Errorf("compare and delete failed: %v", err)
+		return
+	}
+	if exists, _ := b.IsExists(context.Background()); exists {
+		t.Error("value should be deleted")
+	}
+}
+
+func TestBucket_Codec(t *testing.T) {
+	client := redis.NewClient(&redis.Options{Addr: ":6379"})
+	client.Del(context.Background(), "bucketCodecKey")
+	r := redisson.New(context.Background(), client)
+
+	// 测试：单独指定编解码器
+	b := r.NewBucket("bucketCodecKey", object.WithCodec(codec.String()))
+	if err := b.SetWithTTL(context.Background(), "on", time.Minute); err != nil {
+		t.Error(err)
+		return
+	}
+	if v := client.Get(context.Background(), "bucketCodecKey").Val(); v != "on" {
+		t.Errorf("value should be stored as raw string, got: %s", v)
+	}
+
+	client.Del(context.Background(), "bucketCodecKey")
+}
@@ -0,0 +1,88 @@
+package counter
+
+import (
+	"context"
+	"strconv"
+
+	"github.com/go-redis/redis/v8"
+
+	"github.com/MaricoHan/redisson/mutex"
+	"github.com/MaricoHan/redisson/pkg/object"
+)
+
+// AtomicDouble 是分布式的 float64 计数器，不存在时视为 0
+type AtomicDouble struct {
+	*object.Object
+}
+
+func NewAtomicDouble(root *mutex.Root, name string) *AtomicDouble {
+	root.Logger.Debugf("创建 AtomicDouble 实例: %s", name)
+	return &AtomicDouble{Object: object.New(root, name)}
+}
+
+// Get 返回当前值
+func (a *AtomicDouble) Get(ctx context.Context) (float64, error) {
+	return a.result(a.Root().Eval(ctx, atomicScript.getScript, []string{a.Key()}), "查询")
+}
+
+// Set 设置新值，同时清除过期时间
+func (a *AtomicDouble) Set(ctx context.Context, value float64) error {
+	err := a.Root().Eval(ctx, atomicScript.setScript, []string{a.Key()}, formatFloat(value)).Err()
+	if err != nil {
+		a.Root().Logger.Errorf("设置 AtomicDouble 失败: %s, 错误: %v", a.Name(), err)
+		return err
+	}
+	return nil
+}
+
+// GetAndSet 设置新值并清除过期时间，返回旧值
+func (a *AtomicDouble) GetAndSet(ctx context.Context, value float64) (float64, error) {
+	return a.result(a.Root().Eval(ctx, atomicScript.getAndSetScript, []string{a.Key()}, formatFloat(value)), "设置")
+}
+
+// IncrementAndGet 加 1，返回新值
+func (a *AtomicDouble) IncrementAndGet(ctx context.Context) (float64, error) {
+	return a.AddAndGet(ctx, 1)
+}
+
+// DecrementAndGet 减 1，返回新值
+func (a *AtomicDouble) DecrementAndGet(ctx context.Context) (float64, error) {
+	return a.AddAndGet(ctx, -1)
+}
+
+// AddAndGet 加 delta，返回新值
+func (a *AtomicDouble) AddAndGet(ctx context.Context, delta float64) (float64, error) {
+	return a.result(a.Root().Eval(ctx, atomicScript.incrByFloatScript, []string{a.Key()}, formatFloat(delta)), "累加")
+}
+
+// CompareAndSet 当前值等于 expect 时设置为 update，返回是否设置成功；设置成功时清除过期时间
+func (a *AtomicDouble) CompareAndSet(ctx context.Context, expect, update float64) (bool, error) {
+	res, err := a.Root().Eval(ctx, atomicScript.compareAndSetFScript, []string{a.Key()}, formatFloat(expect), formatFloat(update)).Int64()
+	if err != nil {
+		a.Root().Logger.Errorf("比较并设置 AtomicDouble 失败: %s, 错误: %v", a.Name(), err)
+		return false, err
+	}
+	return res == 1, nil
+}
+
+// GetAndDelete 删除计数器，返回删除前的值
+func (a *AtomicDouble) GetAndDelete(ctx context.Context) (float64, error) {
+	return a.result(a.Root().Eval(ctx, atomicScript.getAndDeleteScript, []string{a.Key()}), "删除")
+}
+
+// result 解析脚本返回的值，nil 视为 0
+func (a *AtomicDouble) result(cmd *redis.Cmd, desc string) (float64, error) {
+	res, err := cmd.Text()
+	if err == redis.Nil {
+		return 0, nil
+	}
+	if err != nil {
+		a.Root().Logger.Errorf("%s AtomicDouble 失败: %s, 错误: %v", desc, a.Name(), err)
+		return 0, err
+	}
+	return strconv.ParseFloat(res, 64)
+}
+
+func formatFloat(f float64) string {
+	return strconv.FormatFloat(f, 'f', -1, 64)
+}
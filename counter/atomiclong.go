@@ -0,0 +1,176 @@
+// Package counter 提供分布式计数器
+package counter
+
+import (
+	"context"
+	"strconv"
+
+	"github.com/go-redis/redis/v8"
+
+	"github.com/MaricoHan/redisson/mutex"
+	"github.com/MaricoHan/redisson/pkg/backend"
+	"github.com/MaricoHan/redisson/pkg/object"
+)
+
+var atomicScript = struct {
+	getScript            *backend.Script
+	setScript            *backend.Script
+	getAndSetScript      *backend.Script
+	getAndDeleteScript   *backend.Script
+	incrByScript         *backend.Script
+	incrByFloatScript    *backend.Script
+	compareAndSetScript  *backend.Script
+	compareAndSetFScript *backend.Script
+}{}
+
+// AtomicLong 是分布式的 int64 计数器，不存在时视为 0
+type AtomicLong struct {
+	*object.Object
+}
+
+func NewAtomicLong(root *mutex.Root, name string) *AtomicLong {
+	root.Logger.Debugf("创建 AtomicLong 实例: %s", name)
+	return &AtomicLong{Object: object.New(root, name)}
+}
+
+// Get 返回当前值
+func (a *AtomicLong) Get(ctx context.Context) (int64, error) {
+	return a.result(a.Root().Eval(ctx, atomicScript.getScript, []string{a.Key()}), "查询")
+}
+
+// Set 设置新值，同时清除过期时间
+func (a *AtomicLong) Set(ctx context.Context, value int64) error {
+	err := a.Root().Eval(ctx, atomicScript.setScript, []string{a.Key()}, value).Err()
+	if err != nil {
+		a.Root().Logger.Errorf("设置 AtomicLong 失败: %s, 错误: %v", a.Name(), err)
+		return err
+	}
+	return nil
+}
+
+// GetAndSet 设置新值并清除过期时间，返回旧值
+func (a *AtomicLong) GetAndSet(ctx context.Context, value int64) (int64, error) {
+	return a.result(a.Root().Eval(ctx, atomicScript.getAndSetScript, []string{a.Key()}, value), "设置")
+}
+
+// IncrementAndGet 加 1，返回新值
+func (a *AtomicLong) IncrementAndGet(ctx context.Context) (int64, error) {
+	return a.AddAndGet(ctx, 1)
+}
+
+// DecrementAndGet 减 1，返回新值
+func (a *AtomicLong) DecrementAndGet(ctx context.Context) (int64, error) {
+	return a.AddAndGet(ctx, -1)
+}
+
+// AddAndGet 加 delta，返回新值
+func (a *AtomicLong) AddAndGet(ctx context.Context, delta int64) (int64, error) {
+	return a.result(a.Root().Eval(ctx, atomicScript.incrByScript, []string{a.Key()}, delta), "累加")
+}
+
+// GetAndAdd 加 delta，返回旧值
+func (a *AtomicLong) GetAndAdd(ctx context.Context, delta int64) (int64, error) {
+	value, err := a.AddAndGet(ctx, delta)
+	if err != nil {
+		return 0, err
+	}
+	return value - delta, nil
+}
+
+// CompareAndSet 当前值等于 expect 时设置为 update，返回是否设置成功；设置成功时清除过期时间
+func (a *AtomicLong) CompareAndSet(ctx context.Context, expect, update int64) (bool, error) {
+	res, err := a.Root().Eval(ctx, atomicScript.compareAndSetScript, []string{a.Key()}, expect, update).Int64()
+	if err != nil {
+		a.Root().Logger.Errorf("比较并设置 AtomicLong 失败: %s, 错误: %v", a.Name(), err)
+		return false, err
+	}
+	return res == 1, nil
+}
+
+// GetAndDelete 删除计数器，返回删除前的值
+func (a *AtomicLong) GetAndDelete(ctx context.Context) (int64, error) {
+	return a.result(a.Root().Eval(ctx, atomicScript.getAndDeleteScript, []string{a.Key()}), "删除")
+}
+
+// result 解析脚本返回的值，nil 视为 0
+func (a *AtomicLong) result(cmd *redis.Cmd, desc string) (int64, error) {
+	res, err := cmd.Text()
+	if err == redis.Nil {
+		return 0, nil
+	}
+	if err != nil {
+		a.Root().Logger.Errorf("%s AtomicLong 失败: %s, 错误: %v", desc, a.Name(), err)
+		return 0, err
+	}
+	return strconv.ParseInt(res, 10, 64)
+}
+
+func init() {
+	atomicScript.getScript = backend.NewScript("atomic.get", `
+	-- KEYS[1] 计数器
+	return redis.call('get',KEYS[1])
+`)
+
+	atomicScript.setScript = backend.NewScript("atomic.set", `
+	-- KEYS[1] 计数器
+	-- ARGV[1] 新值
+	return redis.call('set',KEYS[1],ARGV[1])
+`)
+
+	atomicScript.getAndSetScript = backend.NewScript("atomic.getAndSet", `
+	-- KEYS[1] 计数器
+	-- ARGV[1] 新值
+	return redis.call('getset',KEYS[1],ARGV[1])
+`)
+
+	atomicScript.getAndDeleteScript = backend.NewScript("atomic.getAndDelete", `
+	-- KEYS[1] 计数器
+	local value = redis.call('get',KEYS[1])
+	redis.call('del',KEYS[1])
+	return value
+`)
+
+	atomicScript.incrByScript = backend.NewScript("atomic.incrBy", `
+	-- KEYS[1] 计数器
+	-- ARGV[1] 增量
+	-- lua 中的数字为 double，以字符串返回新值，避免大整数的精度损失
+	redis.call('incrby',KEYS[1],ARGV[1])
+	return redis.call('get',KEYS[1])
+`)
+
+	atomicScript.incrByFloatScript = backend.NewScript("atomic.incrByFloat", `
+	-- KEYS[1] 计数器
+	-- ARGV[1] 增量
+	return redis.call('incrbyfloat',KEYS[1],ARGV[1])
+`)
+
+	atomicScript.compareAndSetScript = backend.NewScript("atomic.compareAndSet", `
+	-- KEYS[1] 计数器
+	-- ARGV[1] 期望值，整数按字符串比较，避免 lua 中 double 的精度损失
+	-- ARGV[2] 新值
+	local value = redis.call('get',KEYS[1])
+	if value == false then
+		value = '0'
+	end
+	if value == ARGV[1] then
+		redis.call('set',KEYS[1],ARGV[2])
+		return 1
+	end
+	return 0
+`)
+
+	atomicScript.compareAndSetFScript = backend.NewScript("atomic.compareAndSetFloat", `
+	-- KEYS[1] 计数器
+	-- ARGV[1] 期望值
+	-- ARGV[2] 新值
+	local value = redis.call('get',KEYS[1])
+	if value == false then
+		value = '0'
+	end
+	if tonumber(value) == tonumber(ARGV[1]) then
+		redis.call('set',KEYS[1],ARGV[2])
+		return 1
+	end
+	return 0
+`)
+}
@@ -0,0 +1,97 @@
+package counter_test
+
+import (
+	"context"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+
+	"github.com/MaricoHan/redisson"
+)
+
+func newClient() *redis.Client {
+	return redis.NewClient(&redis.Options{Addr: ":6379"})
+}
+
+func TestAtomicLong(t *testing.T) {
+	client := newClient()
+	client.Del(context.Background(), "atomicLongKey")
+	r := redisson.New(context.Background(), client)
+
+	a := r.NewAtomicLong("atomicLongKey")
+	// 测试：不存在时视为 0
+	if v, err := a.Get(context.Background()); err != nil || v != 0 {
+		t.Errorf("unexpected value: %d, %v", v, err)
+		return
+	}
+	if v, err := a.IncrementAndGet(context.Background()); err != nil || v != 1 {
+		t.Errorf("unexpected value: %d, %v", v, err)
+		return
+	}
+	if v, err := a.AddAndGet(context.Background(), 10); err != nil || v != 11 {
+		t.Errorf("unexpected value: %d, %v", v, err)
+		return
+	}
+
+	// 测试：比较并设置
+	if ok, _ := a.CompareAndSet(context.Background(), 10, 20); ok {
+		t.Error("compare and set should fail")
+		return
+	}
+	if ok, err := a.CompareAndSet(context.Background(), 11, math.MaxInt64-1); err != nil || !ok {
+		t.Errorf("compare and set failed: %v", err)
+		return
+	}
+	// 测试：大整数不丢失精度
+	if v, err := a.IncrementAndGet(context.Background()); err != nil || v != math.MaxInt64 {
+		t.Errorf("unexpected value: %d, %v", v, err)
+		return
+	}
+
+	// 测试：过期时间
+	if ok, err := a.Expire(context.Background(), time.Minute); err != nil || !ok {
+		t.Errorf("expire failed: %v", err)
+		return
+	}
+	if ttl, _ := a.RemainTTL(context.Background()); ttl <= 0 || ttl > time.Minute {
+		t.Errorf("unexpected ttl: %v", ttl)
+		return
+	}
+	if ok, _ := a.ClearExpire(context.Background()); !ok {
+		t.Error("clear expire failed")
+		return
+	}
+
+	if v, err := a.GetAndDelete(context.Background()); err != nil || v != math.MaxInt64 {
+		t.Errorf("unexpected value: %d, %v", v, err)
+		return
+	}
+	if exists, _ := a.IsExists(context.Background()); exists {
+		t.Error("counter should be deleted")
+	}
+}
+
+func TestAtomicDouble(t *testing.T) {
+	client := newClient()
+	client.Del(context.Background(), "atomicDoubleKey")
+	r := redisson.New(context.Background(), client)
+
+	a := r.NewAtomicDouble("atomicDoubleKey")
+	if v, err := a.AddAndGet(context.Background(), 1.5); err != nil || v != 1.5 {
+		t.Errorf("unexpected value: %v, %v", v, err)
+		return
+	}
+	if ok, err := a.CompareAndSet(context.Background(), 1.5, 0.25); err != nil || !ok {
+		t.Errorf("compare and set failed: %v", err)
+		return
+	}
+	if v, err := a.GetAndSet(context.Background(), 3); err != nil || v != 0.25 {
+		t.Errorf("unexpected value: %v, %v", v, err)
+		return
+	}
+	if v, err := a.GetAndDelete(context.Background()); err != nil || v != 3 {
+		t.Errorf("unexpected value: %v, %v", v, err)
+	}
+}
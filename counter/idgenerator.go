@@ -0,0 +1,136 @@
+package counter
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"sync"
+
+	"github.com/MaricoHan/redisson/mutex"
+	"github.com/MaricoHan/redisson/pkg/backend"
+	"github.com/MaricoHan/redisson/pkg/object"
+)
+
+var idGeneratorScript = struct {
+	tryInitScript  *backend.Script
+	allocateScript *backend.Script
+}{}
+
+const (
+	// DefaultIDStart 未初始化时的起始 ID
+	DefaultIDStart int64 = 1
+	// DefaultIDAllocationSize 未初始化时每次从 redis 分配的 ID 数量
+	DefaultIDAllocationSize int64 = 5000
+)
+
+// IDGenerator 是分布式的 ID 生成器：每次从 redis 原子地分配一段 ID，再在本地逐个发放，无需每次往返 redis。
+// 同一实例发放的 ID 单调递增；实例崩溃时尚未发放的 ID 被丢弃，不会被重复分配
+type IDGenerator struct {
+	*object.Object
+
+	allocationKey string // 每次分配的 ID 数量，与主 key 位于同一个 hash slot
+
+	mu   sync.Mutex
+	next int64 // 下一个发放的 ID
+	end  int64 // 当前分段的结束 ID(不含)
+}
+
+func NewIDGenerator(root *mutex.Root, name string) *IDGenerator {
+	root.Logger.Debugf("创建 ID 生成器实例: %s", name)
+
+	g := &IDGenerator{Object: object.New(root, name, "allocation")}
+	g.allocationKey = g.CompanionKey("allocation")
+	return g
+}
+
+// TryInit 设置起始 ID 与每次分配的 ID 数量，已初始化时不会覆盖，返回 false
+func (g *IDGenerator) TryInit(ctx context.Context, start, allocationSize int64) (bool, error) {
+	if allocationSize <= 0 {
+		return false, fmt.Errorf("invalid allocation size: %d", allocationSize)
+	}
+
+	res, err := g.Root().Eval(ctx, idGeneratorScript.tryInitScript, []string{g.Key(), g.allocationKey}, start, allocationSize).Int64()
+	if err != nil {
+		g.Root().Logger.Errorf("初始化 ID 生成器失败: %s, 错误: %v", g.Name(), err)
+		return false, err
+	}
+	if res == 0 {
+		g.Root().Logger.Debugf("ID 生成器已初始化，不覆盖: %s", g.Name())
+		return false, nil
+	}
+
+	g.Root().Logger.Infof("初始化 ID 生成器成功: %s, 起始 ID: %d, 分配数量: %d", g.Name(), start, allocationSize)
+	return true, nil
+}
+
+// NextID 返回下一个 ID，本地分段用尽时从 redis 分配新的分段
+func (g *IDGenerator) NextID(ctx context.Context) (int64, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if g.next >= g.end {
+		if err := g.allocate(ctx); err != nil {
+			return 0, err
+		}
+	}
+
+	id := g.next
+	g.next++
+	return id, nil
+}
+
+// allocate 从 redis 分配新的分段
+func (g *IDGenerator) allocate(ctx context.Context) error {
+	res, err := g.Root().Eval(ctx, idGeneratorScript.allocateScript, []string{g.Key(), g.allocationKey},
+		DefaultIDStart, DefaultIDAllocationSize).StringSlice()
+	if err != nil {
+		g.Root().Logger.Errorf("分配 ID 失败: %s, 错误: %v", g.Name(), err)
+		return err
+	}
+
+	end, err := strconv.ParseInt(res[0], 10, 64)
+	if err != nil {
+		return err
+	}
+	size, err := strconv.ParseInt(res[1], 10, 64)
+	if err != nil {
+		return err
+	}
+
+	g.next, g.end = end-size, end
+	g.Root().Logger.Debugf("分配 ID 成功: %s, 分段: [%d, %d)", g.Name(), g.next, g.end)
+	return nil
+}
+
+func init() {
+	idGeneratorScript.tryInitScript = backend.NewScript("idGenerator.tryInit", `
+	-- KEYS[1] 下一个待分配的 ID
+	-- KEYS[2] 每次分配的 ID 数量
+	-- ARGV[1] 起始 ID
+	-- ARGV[2] 每次分配的 ID 数量
+	if redis.call('exists',KEYS[1]) == 1 then
+		return 0
+	end
+	redis.call('set',KEYS[1],ARGV[1])
+	redis.call('set',KEYS[2],ARGV[2])
+	return 1
+`)
+
+	idGeneratorScript.allocateScript = backend.NewScript("idGenerator.allocate", `
+	-- KEYS[1] 下一个待分配的 ID
+	-- KEYS[2] 每次分配的 ID 数量
+	-- ARGV[1] 未初始化时的起始 ID
+	-- ARGV[2] 未初始化时每次分配的 ID 数量
+	-- 返回值：{分段的结束 ID(不含), 分段大小}，以字符串返回，避免 lua 中 double 的精度损失
+	local size = redis.call('get',KEYS[2])
+	if size == false then
+		size = ARGV[2]
+		redis.call('set',KEYS[2],size)
+	end
+	if redis.call('exists',KEYS[1]) == 0 then
+		redis.call('set',KEYS[1],ARGV[1])
+	end
+	redis.call('incrby',KEYS[1],size)
+	return {redis.call('get',KEYS[1]), size}
+`)
+}
@@ -0,0 +1,67 @@
+package counter_test
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	"github.com/MaricoHan/redisson"
+	"github.com/MaricoHan/redisson/counter"
+)
+
+func TestIDGenerator(t *testing.T) {
+	client := newClient()
+	client.Del(context.Background(), "idGeneratorKey", "{idGeneratorKey}:allocation")
+
+	r := redisson.New(context.Background(), client)
+	g1 := r.NewIDGenerator("idGeneratorKey")
+	if ok, err := g1.TryInit(context.Background(), 100, 10); err != nil || !ok {
+		t.Errorf("init failed: %v", err)
+		return
+	}
+	// 测试：已初始化时不覆盖
+	if ok, _ := g1.TryInit(context.Background(), 0, 10); ok {
+		t.Error("generator should not be reinitialized")
+		return
+	}
+
+	// 测试：同一实例发放的 ID 单调递增
+	if id, err := g1.NextID(context.Background()); err != nil || id != 100 {
+		t.Errorf("unexpected id: %d, %v", id, err)
+		return
+	}
+
+	// 测试：并发获取，多个实例之间不重复
+	g2 := redisson.New(context.Background(), client).NewIDGenerator("idGeneratorKey")
+	var (
+		mu  sync.Mutex
+		ids = map[int64]struct{}{}
+		wg  sync.WaitGroup
+	)
+	for i := 0; i < 50; i++ {
+		for _, g := range []*counter.IDGenerator{g1, g2} {
+			wg.Add(1)
+			go func(g *counter.IDGenerator) {
+				defer wg.Done()
+				id, err := g.NextID(context.Background())
+				if err != nil {
+					t.Error(err)
+					return
+				}
+				mu.Lock()
+				defer mu.Unlock()
+				if _, ok := ids[id]; ok {
+					t.Errorf("duplicate id: %d", id)
+				}
+				ids[id] = struct{}{}
+			}(g)
+		}
+	}
+	wg.Wait()
+
+	if len(ids) != 100 {
+		t.Errorf("unexpected id count: %d", len(ids))
+	}
+
+	client.Del(context.Background(), "idGeneratorKey", "{idGeneratorKey}:allocation")
+}
@@ -0,0 +1,442 @@
+package counter
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/google/uuid"
+
+	"github.com/MaricoHan/redisson/mutex"
+	"github.com/MaricoHan/redisson/pkg/backend"
+	"github.com/MaricoHan/redisson/pkg/clock"
+	"github.com/MaricoHan/redisson/pkg/object"
+	"github.com/MaricoHan/redisson/pkg/types"
+	"github.com/MaricoHan/redisson/pkg/utils"
+	"github.com/MaricoHan/redisson/pkg/utils/pubsub"
+)
+
+var longAdderScript = struct {
+	flushScript      *backend.Script
+	requestScript    *backend.Script
+	beginResetScript *backend.Script
+	collectScript    *backend.Script
+}{}
+
+const (
+	longAdderActionSum   = "sum."
+	longAdderActionReset = "reset."
+	longAdderActionAck   = "ack"
+
+	// longAdderHandledSize 记录最近处理过的请求数量
+	longAdderHandledSize = 16
+)
+
+// LongAdder 是分片累加的分布式计数器：累加只发生在本地，由后台协程定期合并到 redis，避免热点 key。
+// Sum 与 Reset 通过实例的 pubsub 频道通知所有实例上的同名 LongAdder 立即合并或清空本地的累加值，
+// 并等待所有存活的 LongAdder 确认。Reset 期间已确认的 LongAdder 合并的累加值单独记录，
+// 全部确认后作为新的总和，确认之后的累加不会被清空
+type LongAdder struct {
+	*object.Object
+	options *longAdderOptions
+
+	id          string // 当前 LongAdder 的唯一标识
+	registryKey string // 记录所有存活的 LongAdder（zset，score 为租约的过期时间戳）
+	resetKey    string // 进行中的 Reset（hash：请求标识、已确认的 LongAdder、确认之后合并的累加值）
+	pending     int64  // 本地尚未合并的累加值
+
+	mu       sync.Mutex
+	handled  []string // 最近处理过的请求，同一请求可能被重复通知
+	handledN int
+
+	pubSub    *pubsub.PubSub
+	release   chan struct{}
+	closeOnce sync.Once
+}
+
+// longAdderOptions 定义 LongAdder 的配置选项
+type longAdderOptions struct {
+	flushInterval time.Duration
+	sumTimeout    time.Duration
+}
+
+// LongAdderOption 设置 LongAdder 的可选项
+type LongAdderOption func(opt *longAdderOptions)
+
+// WithFlushInterval 设置本地累加值合并到 redis 的间隔，默认 1s
+func WithFlushInterval(interval time.Duration) LongAdderOption {
+	return func(opt *longAdderOptions) {
+		opt.flushInterval = interval
+	}
+}
+
+// WithSumTimeout 设置 Sum 与 Reset 等待其他实例确认的最长时间，默认 5s
+func WithSumTimeout(timeout time.Duration) LongAdderOption {
+	return func(opt *longAdderOptions) {
+		opt.sumTimeout = timeout
+	}
+}
+
+func (o *longAdderOptions) checkAndInit() {
+	if o.flushInterval <= 0 {
+		o.flushInterval = time.Second
+	}
+	if o.sumTimeout <= 0 {
+		o.sumTimeout = 5 * time.Second
+	}
+}
+
+// NewLongAdder 创建 LongAdder 并启动后台合并协程，不再使用时需要调用 Close
+func NewLongAdder(root *mutex.Root, name string, opts ...LongAdderOption) *LongAdder {
+	a := &LongAdder{
+		Object:  object.New(root, name, "adders", "reset"),
+		options: &longAdderOptions{},
+		id:      uuid.New().String(),
+		release: make(chan struct{}),
+	}
+	for i := range opts {
+		opts[i](a.options)
+	}
+	a.options.checkAndInit()
+	a.registryKey = a.CompanionKey("adders")
+	a.resetKey = a.CompanionKey("reset")
+
+	root.Logger.Debugf("创建 LongAdder 实例: %s, 合并间隔: %v", name, a.options.flushInterval)
+
+	// 先订阅，再登记，避免错过登记之后发出的通知
+	a.pubSub = pubsub.Subscribe(utils.ChannelName(a.Key()), pubsub.WithClock(root.Clock))
+	notices := a.pubSub.Channel()
+	if err := a.flush(context.Background(), ""); err != nil {
+		root.Logger.Errorf("登记 LongAdder 失败: %s, 错误: %v", name, err)
+	}
+
+	wg := sync.WaitGroup{}
+	wg.Add(1)
+	go func() {
+		wg.Done()
+
+		ticker := root.NewTicker(a.options.flushInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-a.release:
+				root.Logger.Debugf("LongAdder 合并协程收到退出信号: %s", name)
+				return
+			case <-ticker.C():
+				if err := a.flush(context.Background(), ""); err != nil {
+					root.Logger.Errorf("合并 LongAdder 失败: %s, 错误: %v", name, err)
+				}
+			case msg, ok := <-notices:
+				if !ok {
+					return
+				}
+				a.handle(msg)
+			}
+		}
+	}()
+	wg.Wait() // 等待协程启动成功
+
+	return a
+}
+
+// Add 在本地累加 delta
+func (a *LongAdder) Add(delta int64) {
+	atomic.AddInt64(&a.pending, delta)
+}
+
+// Increment 在本地加 1
+func (a *LongAdder) Increment() {
+	a.Add(1)
+}
+
+// Decrement 在本地减 1
+func (a *LongAdder) Decrement() {
+	a.Add(-1)
+}
+
+// Sum 通知所有实例合并本地的累加值，等待全部确认后返回总和
+func (a *LongAdder) Sum(ctx context.Context) (int64, error) {
+	return a.broadcast(ctx, longAdderActionSum)
+}
+
+// Reset 通知所有实例清空本地的累加值，等待全部确认后将总和置为 0
+func (a *LongAdder) Reset(ctx context.Context) error {
+	_, err := a.broadcast(ctx, longAdderActionReset)
+	return err
+}
+
+// Close 停止后台合并协程，合并剩余的累加值并注销当前 LongAdder
+func (a *LongAdder) Close(ctx context.Context) error {
+	var err error
+	a.closeOnce.Do(func() {
+		close(a.release)
+		a.pubSub.Close()
+
+		delta := atomic.SwapInt64(&a.pending, 0)
+		err = a.Root().Eval(ctx, longAdderScript.flushScript, []string{a.Key(), a.registryKey, a.resetKey}, delta, a.id, 0).Err()
+		if err != nil {
+			a.Root().Logger.Errorf("注销 LongAdder 失败: %s, 错误: %v", a.Name(), err)
+			return
+		}
+		a.Root().Logger.Debugf("注销 LongAdder 成功: %s", a.Name())
+	})
+	return err
+}
+
+// handle 处理其他实例发出的 Sum 与 Reset 通知
+func (a *LongAdder) handle(msg string) {
+	var err error
+	switch {
+	case strings.HasPrefix(msg, longAdderActionSum):
+		if requestID := strings.TrimPrefix(msg, longAdderActionSum); a.firstSeen(requestID) {
+			err = a.flush(context.Background(), requestID)
+		}
+	case strings.HasPrefix(msg, longAdderActionReset):
+		if requestID := strings.TrimPrefix(msg, longAdderActionReset); a.firstSeen(requestID) {
+			atomic.StoreInt64(&a.pending, 0)
+			err = a.ack(context.Background(), requestID, true)
+		}
+	default:
+		return
+	}
+	if err != nil {
+		a.Root().Logger.Errorf("响应 LongAdder 通知失败: %s, 通知: %s, 错误: %v", a.Name(), msg, err)
+	}
+}
+
+// firstSeen 记录请求，返回是否为首次处理
+func (a *LongAdder) firstSeen(requestID string) bool {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	for _, id := range a.handled {
+		if id == requestID {
+			return false
+		}
+	}
+	if len(a.handled) < longAdderHandledSize {
+		a.handled = append(a.handled, requestID)
+	} else {
+		a.handled[a.handledN%longAdderHandledSize] = requestID
+	}
+	a.handledN++
+	return true
+}
+
+// flush 把本地的累加值合并到 redis 并续约；requestID 不为空时同时确认对应的 Sum 请求
+func (a *LongAdder) flush(ctx context.Context, requestID string) error {
+	return a.ack(ctx, requestID, false)
+}
+
+// ack 合并本地的累加值并续约，requestID 不为空时同时确认对应的请求；
+// reset 为 true 时登记当前 LongAdder 已确认该 Reset，之后合并的累加值计入 Reset 之后的总和
+func (a *LongAdder) ack(ctx context.Context, requestID string, reset bool) error {
+	delta := atomic.SwapInt64(&a.pending, 0)
+	lease := int64(3 * a.options.flushInterval / time.Millisecond)
+
+	keys := []string{a.Key(), a.registryKey, a.resetKey}
+	if requestID != "" {
+		keys = append(keys, a.ackKey(requestID), a.Root().RedisChannelName)
+	}
+	var resetID string
+	if reset {
+		resetID = requestID
+	}
+	err := a.Root().Eval(ctx, longAdderScript.flushScript, keys, delta, a.id, lease, a.Key()+":"+longAdderActionAck, resetID).Err()
+	if err != nil {
+		// 合并失败，归还累加值，下次再合并
+		atomic.AddInt64(&a.pending, delta)
+		return err
+	}
+	return nil
+}
+
+// broadcast 发出 Sum 或 Reset 请求，等待所有存活的 LongAdder 确认
+func (a *LongAdder) broadcast(ctx context.Context, action string) (int64, error) {
+	requestID := uuid.New().String()
+	ackKey := a.ackKey(requestID)
+
+	// 先订阅确认通知，再发出请求
+	acks := pubsub.Subscribe(utils.ChannelName(a.Key()), pubsub.WithClock(a.Root().Clock))
+	notices := acks.Channel()
+	defer acks.Close()
+
+	var (
+		request  = a.Key() + ":" + action + requestID
+		reset    = action == longAdderActionReset
+		interval = a.options.flushInterval
+		deadline = a.Root().NewTimer(a.options.sumTimeout)
+		poll     clock.Timer
+	)
+	if interval > a.options.sumTimeout/10 {
+		interval = a.options.sumTimeout / 10
+	}
+	defer deadline.Stop()
+	defer func() {
+		if poll != nil {
+			poll.Stop()
+		}
+	}()
+
+	if reset {
+		// 先登记 Reset，再发出请求，确认之后合并的累加值才能被单独记录
+		if err := a.Root().Eval(ctx, longAdderScript.beginResetScript, []string{a.resetKey}, requestID).Err(); err != nil {
+			a.Root().Logger.Errorf("发出 LongAdder 请求失败: %s, 错误: %v", a.Name(), err)
+			return 0, err
+		}
+	}
+
+	for resend := true; ; {
+		// 定期重新发出请求，避免实例错过通知；每个实例对同一请求只处理一次
+		if resend {
+			err := a.Root().Eval(ctx, longAdderScript.requestScript, []string{a.Root().RedisChannelName}, request).Err()
+			if err != nil {
+				a.Root().Logger.Errorf("发出 LongAdder 请求失败: %s, 错误: %v", a.Name(), err)
+				return 0, err
+			}
+			resend = false
+		}
+
+		res, err := a.Root().Eval(ctx, longAdderScript.collectScript, []string{a.Key(), a.registryKey, ackKey, a.resetKey}, boolToInt(reset), requestID).Slice()
+		if err != nil {
+			a.Root().Logger.Errorf("收集 LongAdder 确认失败: %s, 错误: %v", a.Name(), err)
+			return 0, err
+		}
+		if pending := res[0].(int64); pending == 0 {
+			return parseSum(res[1])
+		}
+
+		// 除确认通知外，定期检查，以便重新发出请求，并及时排除租约已过期的实例
+		if poll == nil {
+			poll = a.Root().NewTimer(interval)
+		} else {
+			poll.Reset(interval)
+		}
+
+		select {
+		case <-ctx.Done():
+			a.Root().Logger.Warnf("等待 LongAdder 确认被调用方取消: %s, 原因: %v", a.Name(), ctx.Err())
+			return 0, types.Wrap(types.ErrWaitCanceled, ctx.Err())
+		case <-deadline.C():
+			a.Root().Logger.Warnf("等待 LongAdder 确认超时: %s", a.Name())
+			return 0, types.Wrap(types.ErrWaitTimeout, context.DeadlineExceeded)
+		case <-poll.C():
+			resend = true
+			continue
+		case <-notices:
+		}
+
+		if !poll.Stop() {
+			select {
+			case <-poll.C():
+			default:
+			}
+		}
+	}
+}
+
+// ackKey 返回记录请求确认的集合
+func (a *LongAdder) ackKey(requestID string) string {
+	return a.CompanionKey("ack:" + requestID)
+}
+
+func parseSum(v interface{}) (int64, error) {
+	return redis.NewCmdResult(v, nil).Int64()
+}
+
+func boolToInt(b bool) int {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+func init() {
+	longAdderScript.flushScript = backend.NewScript("longAdder.flush", backend.NowMillisLua+`
+	-- KEYS[1] 总和
+	-- KEYS[2] 存活的 LongAdder 集合（zset，score 为租约的过期时间戳）
+	-- KEYS[3] 进行中的 Reset（hash）
+	-- KEYS[4] 可选，请求的确认集合
+	-- KEYS[5] 可选，发布订阅的channel
+	-- ARGV[1] 本地累加值
+	-- ARGV[2] LongAdder 唯一标识
+	-- ARGV[3] 租约时长，单位：ms，为 0 时注销
+	-- ARGV[4] 确认时发布的消息
+	-- ARGV[5] 可选，确认的 Reset 请求标识
+	if ARGV[5] and ARGV[5] ~= '' and redis.call('hget',KEYS[3],'id') == ARGV[5] then
+		redis.call('hset',KEYS[3],'acked:' .. ARGV[2],1)
+	end
+	if tonumber(ARGV[1]) ~= 0 then
+		-- 已确认进行中的 Reset 时，累加值发生在 Reset 之后，单独记录，避免被清空
+		if redis.call('hexists',KEYS[3],'acked:' .. ARGV[2]) == 1 then
+			redis.call('hincrby',KEYS[3],'carry',ARGV[1])
+		else
+			redis.call('incrby',KEYS[1],ARGV[1])
+		end
+	end
+	if tonumber(ARGV[3]) == 0 then
+		redis.call('zrem',KEYS[2],ARGV[2])
+		return 1
+	end
+	redis.call('zadd',KEYS[2],nowMillis() + tonumber(ARGV[3]),ARGV[2])
+	-- 各 LongAdder 的租约时长可能不同，只延长不缩短
+	if redis.call('pttl',KEYS[2]) < tonumber(ARGV[3]) then
+		redis.call('pexpire',KEYS[2],ARGV[3])
+	end
+	if #KEYS >= 5 then
+		redis.call('sadd',KEYS[4],ARGV[2])
+		redis.call('pexpire',KEYS[4],60000)
+		redis.call('publish',KEYS[5],ARGV[4])
+	end
+	return 1
+`)
+
+	longAdderScript.requestScript = backend.NewScript("longAdder.request", `
+	-- KEYS[1] 发布订阅的channel
+	-- ARGV[1] 请求消息
+	return redis.call('publish',KEYS[1],ARGV[1])
+`)
+
+	longAdderScript.beginResetScript = backend.NewScript("longAdder.beginReset", `
+	-- KEYS[1] 进行中的 Reset（hash）
+	-- ARGV[1] 请求标识
+	-- 新的 Reset 取代进行中的 Reset
+	redis.call('del',KEYS[1])
+	redis.call('hset',KEYS[1],'id',ARGV[1])
+	redis.call('pexpire',KEYS[1],60000)
+	return 1
+`)
+
+	longAdderScript.collectScript = backend.NewScript("longAdder.collect", backend.NowMillisLua+`
+	-- KEYS[1] 总和
+	-- KEYS[2] 存活的 LongAdder 集合（zset，score 为租约的过期时间戳）
+	-- KEYS[3] 请求的确认集合
+	-- KEYS[4] 进行中的 Reset（hash）
+	-- ARGV[1] 是否为 Reset 请求：1-是 0-否
+	-- ARGV[2] 请求标识
+	-- 返回值：{尚未确认的 LongAdder 数量, 总和}
+	redis.call('zremrangebyscore',KEYS[2],'-inf',nowMillis())
+	local pending = 0
+	local adders = redis.call('zrange',KEYS[2],0,-1)
+	for i = 1, #adders do
+		if redis.call('sismember',KEYS[3],adders[i]) == 0 then
+			pending = pending + 1
+		end
+	end
+	if pending > 0 then
+		return {pending, '0'}
+	end
+
+	redis.call('del',KEYS[3])
+	-- 以确认之后合并的累加值作为新的总和；Reset 已被更新的 Reset 取代时由后者清空
+	if ARGV[1] == '1' and redis.call('hget',KEYS[4],'id') == ARGV[2] then
+		redis.call('set',KEYS[1],redis.call('hget',KEYS[4],'carry') or 0)
+		redis.call('del',KEYS[4])
+	end
+	return {0, redis.call('get',KEYS[1]) or '0'}
+`)
+}
@@ -0,0 +1,139 @@
+package counter_test
+
+import (
+	"context"
+	"runtime"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+
+	"github.com/MaricoHan/redisson"
+	"github.com/MaricoHan/redisson/counter"
+)
+
+func TestLongAdder(t *testing.T) {
+	client := newClient()
+	client.Del(context.Background(), "longAdderKey", "{longAdderKey}:adders")
+
+	r1 := redisson.New(context.Background(), client)
+	r2 := redisson.New(context.Background(), client)
+	adder1 := r1.NewLongAdder("longAdderKey", counter.WithFlushInterval(time.Minute))
+	adder2 := r2.NewLongAdder("longAdderKey", counter.WithFlushInterval(time.Minute))
+	defer adder1.Close(context.Background())
+
+	wg := sync.WaitGroup{}
+	for i := 0; i < 100; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			adder1.Increment()
+		}()
+		go func() {
+			defer wg.Done()
+			adder2.Add(2)
+		}()
+	}
+	wg.Wait()
+
+	// 测试：累加只发生在本地，Sum 时通知所有实例合并
+	if v := client.Get(context.Background(), "longAdderKey").Val(); v != "" {
+		t.Errorf("increments should be accumulated locally, got: %s", v)
+		return
+	}
+	sum, err := adder1.Sum(context.Background())
+	if err != nil || sum != 300 {
+		t.Errorf("unexpected sum: %d, %v", sum, err)
+		return
+	}
+
+	// 测试：Reset 清空所有实例的累加值
+	adder2.Add(5)
+	if err = adder1.Reset(context.Background()); err != nil {
+		t.Error(err)
+		return
+	}
+	adder2.Add(1)
+	if err = adder2.Close(context.Background()); err != nil {
+		t.Error(err)
+		return
+	}
+	// 测试：注销后的实例不再参与确认
+	if sum, err = adder1.Sum(context.Background()); err != nil || sum != 1 {
+		t.Errorf("unexpected sum: %d, %v", sum, err)
+	}
+
+	client.Del(context.Background(), "longAdderKey", "{longAdderKey}:adders")
+	// 保持实例存活，实例被回收后其 pubsub 监听协程会退出
+	runtime.KeepAlive(r1)
+	runtime.KeepAlive(r2)
+}
+
+func TestLongAdder_Flush(t *testing.T) {
+	client := newClient()
+	client.Del(context.Background(), "longAdderFlushKey", "{longAdderFlushKey}:adders")
+
+	adder := redisson.New(context.Background(), client).NewLongAdder("longAdderFlushKey", counter.WithFlushInterval(100*time.Millisecond))
+	defer adder.Close(context.Background())
+
+	// 测试：定期合并到 redis
+	adder.Add(3)
+	<-time.After(300 * time.Millisecond)
+	if v := client.Get(context.Background(), "longAdderFlushKey").Val(); v != "3" {
+		t.Errorf("increments should be flushed, got: %s", v)
+	}
+
+	client.Del(context.Background(), "longAdderFlushKey", "{longAdderFlushKey}:adders")
+}
+
+// TestLongAdder_ResetConcurrent
+// @Description: 测试：Reset 等待其他实例确认期间，已确认的实例继续累加并合并，这部分累加值不会被清空
+// @param t
+func TestLongAdder_ResetConcurrent(t *testing.T) {
+	client := newClient()
+	client.Del(context.Background(), "longAdderResetKey", "{longAdderResetKey}:adders", "{longAdderResetKey}:reset")
+
+	r1 := redisson.New(context.Background(), client)
+	r2 := redisson.New(context.Background(), client)
+	adder1 := r1.NewLongAdder("longAdderResetKey", counter.WithFlushInterval(20*time.Millisecond))
+	adder2 := r2.NewLongAdder("longAdderResetKey", counter.WithFlushInterval(20*time.Millisecond))
+	defer adder1.Close(context.Background())
+	defer adder2.Close(context.Background())
+
+	adder2.Add(3)
+	// 登记一个不会确认的 LongAdder，租约到期前 Reset 一直等待
+	now, err := client.Time(context.Background()).Result()
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	client.ZAdd(context.Background(), "{longAdderResetKey}:adders", &redis.Z{
+		Score:  float64(now.Add(500*time.Millisecond).UnixNano() / int64(time.Millisecond)),
+		Member: "ghost",
+	})
+
+	wg := sync.WaitGroup{}
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		// 测试：adder2 确认 Reset 之后的累加在 Reset 完成前已合并到 redis
+		<-time.After(200 * time.Millisecond)
+		adder2.Add(7)
+	}()
+
+	if err = adder1.Reset(context.Background()); err != nil {
+		t.Error(err)
+		return
+	}
+	wg.Wait()
+
+	sum, err := adder2.Sum(context.Background())
+	if err != nil || sum != 7 {
+		t.Errorf("unexpected sum: %d, %v", sum, err)
+	}
+
+	client.Del(context.Background(), "longAdderResetKey", "{longAdderResetKey}:adders", "{longAdderResetKey}:reset")
+	runtime.KeepAlive(r1)
+	runtime.KeepAlive(r2)
+}
@@ -0,0 +1,133 @@
+package hashmap
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/MaricoHan/redisson/mutex"
+)
+
+const (
+	// evictionBatchSize 每次最多清理的元素数量
+	evictionBatchSize = 100
+
+	evictionMinDelay     = time.Second
+	evictionInitialDelay = 5 * time.Second
+	evictionMaxDelay     = 30 * time.Minute
+)
+
+// EvictionScheduler 由 Redisson 实例持有，在后台定期清理所有 MapCache 中已过期的元素。
+// 清理间隔随清理量自适应：某次清理满一批时缩短间隔，没有可清理的元素时延长间隔。
+// 同名的 MapCache 共用一个清理计划，全部关闭后才停止清理
+type EvictionScheduler struct {
+	root *mutex.Root
+
+	mu     sync.Mutex
+	caches map[string]*cacheEviction // key 为 MapCache 的 key
+}
+
+type cacheEviction struct {
+	cache *MapCache // 执行清理的 MapCache，同名的 MapCache 清理的是相同的 key
+	refs  int       // 未关闭的同名 MapCache 数量
+	delay time.Duration
+	next  time.Time
+}
+
+func NewEvictionScheduler(root *mutex.Root) *EvictionScheduler {
+	return &EvictionScheduler{
+		root:   root,
+		caches: make(map[string]*cacheEviction),
+	}
+}
+
+// Run 执行清理，直到 ctx 被取消
+func (s *EvictionScheduler) Run(ctx context.Context) {
+	s.root.Logger.Info("启动 MapCache 过期清理协程")
+
+	timer := s.root.NewTimer(evictionMinDelay)
+	defer timer.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			s.root.Logger.Info("MapCache 过期清理协程收到退出信号")
+			return
+		case <-timer.C():
+		}
+
+		timer.Reset(s.runDue(ctx))
+	}
+}
+
+// runDue 清理到期的 MapCache，返回距离下一次清理的时间
+func (s *EvictionScheduler) runDue(ctx context.Context) time.Duration {
+	now := s.root.Now()
+
+	s.mu.Lock()
+	var due []*cacheEviction
+	for _, e := range s.caches {
+		if !e.next.After(now) {
+			due = append(due, e)
+		}
+	}
+	s.mu.Unlock()
+
+	for _, e := range due {
+		n, err := e.cache.evict(ctx, evictionBatchSize)
+		if err != nil {
+			s.root.Logger.Errorf("清理 MapCache 过期元素失败: %s, 错误: %v", e.cache.Name(), err)
+		} else if n > 0 {
+			s.root.Logger.Debugf("清理 MapCache 过期元素: %s, 数量: %d", e.cache.Name(), n)
+		}
+
+		s.mu.Lock()
+		switch {
+		case n >= evictionBatchSize:
+			e.delay /= 2
+			if e.delay < evictionMinDelay {
+				e.delay = evictionMinDelay
+			}
+		case n == 0:
+			e.delay *= 2
+			if e.delay > evictionMaxDelay {
+				e.delay = evictionMaxDelay
+			}
+		}
+		e.next = now.Add(e.delay)
+		s.mu.Unlock()
+	}
+
+	// 按最短间隔检查，使新注册的 MapCache 与缩短的间隔及时生效
+	return evictionMinDelay
+}
+
+func (s *EvictionScheduler) register(m *MapCache) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if e, ok := s.caches[m.Key()]; ok {
+		e.refs++
+		return
+	}
+	s.caches[m.Key()] = &cacheEviction{
+		cache: m,
+		refs:  1,
+		delay: evictionInitialDelay,
+		next:  s.root.Now().Add(evictionInitialDelay),
+	}
+}
+
+func (s *EvictionScheduler) unregister(m *MapCache) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	e, ok := s.caches[m.Key()]
+	if !ok {
+		return
+	}
+	e.refs--
+	if e.refs <= 0 {
+		delete(s.caches, m.Key())
+	}
+}
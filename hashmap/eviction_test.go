@@ -0,0 +1,45 @@
+package hashmap
+
+import (
+	"testing"
+
+	"github.com/go-redis/redis/v8"
+
+	"github.com/MaricoHan/redisson/mutex"
+	"github.com/MaricoHan/redisson/pkg/loggers"
+)
+
+// TestEvictionScheduler_Refs
+// @Description: 测试：同名的 MapCache 共用一个清理计划，全部关闭后才移除
+// @param t
+func TestEvictionScheduler_Refs(t *testing.T) {
+	root := &mutex.Root{
+		Client: redis.NewClient(&redis.Options{Addr: ":6379"}),
+		UUID:   "uuid",
+		Logger: loggers.Logger(),
+	}
+	s := NewEvictionScheduler(root)
+
+	var caches []*MapCache
+	for i := 0; i < 3; i++ {
+		caches = append(caches, NewMapCache(root, s, "evictionRefsKey"))
+	}
+	other := NewMapCache(root, s, "evictionRefsKey2")
+	if n := len(s.caches); n != 2 {
+		t.Errorf("unexpected schedules: %d", n)
+		return
+	}
+
+	caches[0].Close()
+	caches[0].Close() // 重复关闭不会多次减少引用
+	caches[1].Close()
+	if _, ok := s.caches[caches[2].Key()]; !ok {
+		t.Error("schedule should be kept while a MapCache is open")
+		return
+	}
+	caches[2].Close()
+	other.Close()
+	if n := len(s.caches); n != 0 {
+		t.Errorf("schedules should be removed after all closed: %d", n)
+	}
+}
@@ -0,0 +1,138 @@
+package hashmap
+
+import (
+	"context"
+
+	"github.com/MaricoHan/redisson/pkg/object"
+)
+
+// Entry 是 Map 中的一个键值对，值在调用 Value 时才解码
+type Entry struct {
+	Key string
+
+	raw    []byte
+	object *object.Object
+}
+
+// Value 将值解码到 v 中，v 必须为指针
+func (e Entry) Value(v interface{}) error {
+	return e.object.Decode(e.raw, v)
+}
+
+// scanner 基于 HSCAN 分批遍历 Map，遍历期间被修改的元素可能被遗漏或重复返回
+type scanner struct {
+	m     *Map
+	count int64
+
+	cursor  string
+	started bool
+	batch   []Entry
+	current Entry
+	err     error
+}
+
+func (s *scanner) next(ctx context.Context) bool {
+	for len(s.batch) == 0 {
+		if s.err != nil || (s.started && s.cursor == "0") {
+			return false
+		}
+		s.started = true
+
+		res, err := s.m.Root().Eval(ctx, mapScript.scanScript, []string{s.m.Key()}, s.cursor, s.count).Slice()
+		if err != nil {
+			s.m.Root().Logger.Errorf("遍历 Map 失败: %s, 错误: %v", s.m.Name(), err)
+			s.err = err
+			return false
+		}
+		s.cursor = res[0].(string)
+		fields := res[1].([]interface{})
+		for i := 0; i+1 < len(fields); i += 2 {
+			s.batch = append(s.batch, s.m.entry(fields[i].(string), fields[i+1].(string)))
+		}
+	}
+
+	s.current, s.batch = s.batch[0], s.batch[1:]
+	return true
+}
+
+// KeyIterator 遍历 Map 的 key
+type KeyIterator struct {
+	s *scanner
+}
+
+// Next 移动到下一个元素，遍历结束或出错时返回 false
+func (it *KeyIterator) Next(ctx context.Context) bool {
+	return it.s.next(ctx)
+}
+
+// Key 返回当前元素的 key
+func (it *KeyIterator) Key() string {
+	return it.s.current.Key
+}
+
+// Err 返回遍历过程中的错误
+func (it *KeyIterator) Err() error {
+	return it.s.err
+}
+
+// ValueIterator 遍历 Map 的值
+type ValueIterator struct {
+	s *scanner
+}
+
+// Next 移动到下一个元素，遍历结束或出错时返回 false
+func (it *ValueIterator) Next(ctx context.Context) bool {
+	return it.s.next(ctx)
+}
+
+// Value 将当前元素的值解码到 v 中，v 必须为指针
+func (it *ValueIterator) Value(v interface{}) error {
+	return it.s.current.Value(v)
+}
+
+// Err 返回遍历过程中的错误
+func (it *ValueIterator) Err() error {
+	return it.s.err
+}
+
+// EntryIterator 遍历 Map 的键值对
+type EntryIterator struct {
+	s *scanner
+}
+
+// Next 移动到下一个元素，遍历结束或出错时返回 false
+func (it *EntryIterator) Next(ctx context.Context) bool {
+	return it.s.next(ctx)
+}
+
+// Entry 返回当前的键值对
+func (it *EntryIterator) Entry() Entry {
+	return it.s.current
+}
+
+// Err 返回遍历过程中的错误
+func (it *EntryIterator) Err() error {
+	return it.s.err
+}
+
+// Keys 基于 HSCAN 遍历 key，count 为每次扫描的数量提示
+func (m *Map) Keys(count int64) *KeyIterator {
+	return &KeyIterator{s: m.scanner(count)}
+}
+
+// Values 基于 HSCAN 遍历值，count 为每次扫描的数量提示
+func (m *Map) Values(count int64) *ValueIterator {
+	return &ValueIterator{s: m.scanner(count)}
+}
+
+// Entries 基于 HSCAN 遍历键值对，count 为每次扫描的数量提示
+func (m *Map) Entries(count int64) *EntryIterator {
+	return &EntryIterator{s: m.scanner(count)}
+}
+
+func (m *Map) scanner(count int64) *scanner {
+	if count <= 0 {
+		count = 10
+	}
+	return &scanner{m: m, count: count, cursor: "0"}
+}
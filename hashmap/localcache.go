@@ -0,0 +1,193 @@
+package hashmap
+
+import "container/list"
+
+// EvictionPolicy 是本地缓存已满时的淘汰策略
+type EvictionPolicy int
+
+const (
+	// EvictLRU 淘汰最久未访问的元素
+	EvictLRU EvictionPolicy = iota
+	// EvictLFU 淘汰访问次数最少的元素，次数相同时淘汰最久未访问的
+	EvictLFU
+)
+
+// localCache 是进程内缓存，保存编码后的值，由调用方加锁
+type localCache interface {
+	get(key string) (string, bool)
+	put(key, value string)
+	remove(key string)
+	clear()
+	len() int
+}
+
+func newLocalCache(policy EvictionPolicy, size int) localCache {
+	if policy == EvictLFU {
+		return newLFUCache(size)
+	}
+	return newLRUCache(size)
+}
+
+type cacheEntry struct {
+	key   string
+	value string
+}
+
+// lruCache 容量小于等于 0 时不限制大小
+type lruCache struct {
+	size  int
+	order *list.List // 表头为最近访问的元素
+	items map[string]*list.Element
+}
+
+func newLRUCache(size int) *lruCache {
+	return &lruCache{
+		size:  size,
+		order: list.New(),
+		items: make(map[string]*list.Element),
+	}
+}
+
+func (c *lruCache) get(key string) (string, bool) {
+	e, ok := c.items[key]
+	if !ok {
+		return "", false
+	}
+	c.order.MoveToFront(e)
+	return e.Value.(*cacheEntry).value, true
+}
+
+func (c *lruCache) put(key, value string) {
+	if e, ok := c.items[key]; ok {
+		e.Value.(*cacheEntry).value = value
+		c.order.MoveToFront(e)
+		return
+	}
+	if c.size > 0 && c.order.Len() >= c.size {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.items, oldest.Value.(*cacheEntry).key)
+	}
+	c.items[key] = c.order.PushFront(&cacheEntry{key: key, value: value})
+}
+
+func (c *lruCache) remove(key string) {
+	if e, ok := c.items[key]; ok {
+		c.order.Remove(e)
+		delete(c.items, key)
+	}
+}
+
+func (c *lruCache) clear() {
+	c.order.Init()
+	c.items = make(map[string]*list.Element)
+}
+
+func (c *lruCache) len() int {
+	return c.order.Len()
+}
+
+// lfuCache 按访问次数分组，每组内按最近访问排序，读写均为 O(1)；容量小于等于 0 时不限制大小
+type lfuCache struct {
+	size    int
+	minFreq int
+	items   map[string]*list.Element
+	freqs   map[int]*list.List // 访问次数 -> 元素，表头为最近访问的元素
+}
+
+type lfuEntry struct {
+	cacheEntry
+	freq int
+}
+
+func newLFUCache(size int) *lfuCache {
+	return &lfuCache{
+		size:  size,
+		items: make(map[string]*list.Element),
+		freqs: make(map[int]*list.List),
+	}
+}
+
+func (c *lfuCache) get(key string) (string, bool) {
+	e, ok := c.items[key]
+	if !ok {
+		return "", false
+	}
+	c.touch(e)
+	return e.Value.(*lfuEntry).value, true
+}
+
+func (c *lfuCache) put(key, value string) {
+	if e, ok := c.items[key]; ok {
+		e.Value.(*lfuEntry).value = value
+		c.touch(e)
+		return
+	}
+	if c.size > 0 && len(c.items) >= c.size {
+		c.evict()
+	}
+	c.minFreq = 1
+	c.items[key] = c.bucket(1).PushFront(&lfuEntry{cacheEntry: cacheEntry{key: key, value: value}, freq: 1})
+}
+
+func (c *lfuCache) remove(key string) {
+	if e, ok := c.items[key]; ok {
+		c.unlink(e)
+	}
+}
+
+func (c *lfuCache) clear() {
+	c.minFreq = 0
+	c.items = make(map[string]*list.Element)
+	c.freqs = make(map[int]*list.List)
+}
+
+func (c *lfuCache) len() int {
+	return len(c.items)
+}
+
+// evict 淘汰访问次数最少的组中最久未访问的元素
+func (c *lfuCache) evict() {
+	victims, ok := c.freqs[c.minFreq]
+	if !ok {
+		// 删除元素后 minFreq 可能已经失效，重新查找
+		c.minFreq = 0
+		for freq := range c.freqs {
+			if c.minFreq == 0 || freq < c.minFreq {
+				c.minFreq = freq
+			}
+		}
+		victims = c.freqs[c.minFreq]
+	}
+	c.unlink(victims.Back())
+}
+
+// touch 访问次数加一，移动到下一组
+func (c *lfuCache) touch(e *list.Element) {
+	entry := e.Value.(*lfuEntry)
+	c.unlink(e)
+	if c.freqs[entry.freq] == nil && c.minFreq == entry.freq {
+		c.minFreq++
+	}
+	entry.freq++
+	c.items[entry.key] = c.bucket(entry.freq).PushFront(entry)
+}
+
+func (c *lfuCache) unlink(e *list.Element) {
+	entry := e.Value.(*lfuEntry)
+	l := c.freqs[entry.freq]
+	l.Remove(e)
+	if l.Len() == 0 {
+		delete(c.freqs, entry.freq)
+	}
+	delete(c.items, entry.key)
+}
+
+func (c *lfuCache) bucket(freq int) *list.List {
+	l, ok := c.freqs[freq]
+	if !ok {
+		l = list.New()
+		c.freqs[freq] = l
+	}
+	return l
+}
@@ -0,0 +1,54 @@
+package hashmap
+
+import "testing"
+
+func TestLRUCache(t *testing.T) {
+	c := newLocalCache(EvictLRU, 2)
+	c.put("a", "1")
+	c.put("b", "2")
+	c.get("a")
+	c.put("c", "3") // 淘汰最久未访问的 b
+
+	if _, ok := c.get("b"); ok {
+		t.Error("b should be evicted")
+	}
+	if v, ok := c.get("a"); !ok || v != "1" {
+		t.Errorf("unexpected a: %s", v)
+	}
+	if c.len() != 2 {
+		t.Errorf("unexpected len: %d", c.len())
+	}
+}
+
+func TestLFUCache(t *testing.T) {
+	c := newLocalCache(EvictLFU, 2)
+	c.put("a", "1")
+	c.put("b", "2")
+	c.get("a")
+	c.get("a")
+	c.get("b")
+	c.put("c", "3") // 淘汰访问次数最少的 b
+
+	if _, ok := c.get("b"); ok {
+		t.Error("b should be evicted")
+	}
+	if v, ok := c.get("a"); !ok || v != "1" {
+		t.Errorf("unexpected a: %s", v)
+	}
+
+	// 删除访问次数最少的元素后仍能正确淘汰
+	c.remove("c")
+	c.put("d", "4")
+	c.put("e", "5") // 淘汰 d
+	if _, ok := c.get("d"); ok {
+		t.Error("d should be evicted")
+	}
+	if _, ok := c.get("a"); !ok {
+		t.Error("a should be kept")
+	}
+
+	c.clear()
+	if c.len() != 0 {
+		t.Errorf("unexpected len: %d", c.len())
+	}
+}
@@ -0,0 +1,671 @@
+package hashmap
+
+import (
+	"context"
+	"encoding/base64"
+	"strings"
+	"sync"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/google/uuid"
+
+	"github.com/MaricoHan/redisson/mutex"
+	"github.com/MaricoHan/redisson/pkg/backend"
+	"github.com/MaricoHan/redisson/pkg/object"
+	"github.com/MaricoHan/redisson/pkg/utils"
+	"github.com/MaricoHan/redisson/pkg/utils/pubsub"
+)
+
+var localCachedMapScript = struct {
+	putScript         *backend.Script
+	fastPutScript     *backend.Script
+	putIfAbsentScript *backend.Script
+	replaceScript     *backend.Script
+	removeScript      *backend.Script
+	fastRemoveScript  *backend.Script
+	putAllScript      *backend.Script
+	deleteScript      *backend.Script
+}{}
+
+// SyncStrategy 是写入后同步其他实例本地缓存的方式
+type SyncStrategy int
+
+const (
+	// SyncInvalidate 通知其他实例删除本地缓存中对应的元素，下次读取时从 redis 加载
+	SyncInvalidate SyncStrategy = iota
+	// SyncUpdate 把新值发送给其他实例，直接更新其本地缓存
+	SyncUpdate
+	// SyncNone 不通知其他实例，其本地缓存可能一直保留旧值
+	SyncNone
+)
+
+// ReconnectionStrategy 是实例的 redis 订阅断线重连后处理本地缓存的方式，断线期间的同步消息可能已经丢失
+type ReconnectionStrategy int
+
+const (
+	// ReconnectClear 清空本地缓存
+	ReconnectClear ReconnectionStrategy = iota
+	// ReconnectReload 清空本地缓存，并从 redis 重新加载，直到本地缓存已满
+	ReconnectReload
+	// ReconnectNone 不做处理
+	ReconnectNone
+)
+
+const (
+	localCachedMapActionInvalidate = "invalidate"
+	localCachedMapActionUpdate     = "update"
+	localCachedMapActionClear      = "clear"
+
+	// reloadBatchSize 重新加载时每次扫描的数量
+	reloadBatchSize = 100
+)
+
+// localCachedMapOptions 定义 LocalCachedMap 的配置选项
+type localCachedMapOptions struct {
+	cacheSize      int
+	evictionPolicy EvictionPolicy
+	syncStrategy   SyncStrategy
+	reconnection   ReconnectionStrategy
+	objectOptions  []object.Option
+}
+
+// LocalCachedMapOption 设置 LocalCachedMap 的可选项
+type LocalCachedMapOption func(opt *localCachedMapOptions)
+
+// WithCacheSize 设置本地缓存的最大元素数量，默认为 0，表示不限制
+func WithCacheSize(size int) LocalCachedMapOption {
+	return func(opt *localCachedMapOptions) {
+		opt.cacheSize = size
+	}
+}
+
+// WithEvictionPolicy 设置本地缓存已满时的淘汰策略，默认为 EvictLRU
+func WithEvictionPolicy(policy EvictionPolicy) LocalCachedMapOption {
+	return func(opt *localCachedMapOptions) {
+		opt.evictionPolicy = policy
+	}
+}
+
+// WithSyncStrategy 设置写入后同步其他实例本地缓存的方式，默认为 SyncInvalidate
+func WithSyncStrategy(strategy SyncStrategy) LocalCachedMapOption {
+	return func(opt *localCachedMapOptions) {
+		opt.syncStrategy = strategy
+	}
+}
+
+// WithReconnectionStrategy 设置 redis 订阅断线重连后处理本地缓存的方式，默认为 ReconnectClear
+func WithReconnectionStrategy(strategy ReconnectionStrategy) LocalCachedMapOption {
+	return func(opt *localCachedMapOptions) {
+		opt.reconnection = strategy
+	}
+}
+
+// WithObjectOptions 设置分布式对象的通用可选项，例如 object.WithCodec
+func WithObjectOptions(opts ...object.Option) LocalCachedMapOption {
+	return func(opt *localCachedMapOptions) {
+		opt.objectOptions = append(opt.objectOptions, opts...)
+	}
+}
+
+// LocalCachedMap 是带进程内缓存的 Map：读取优先命中本地缓存，写入 redis 的同时更新本地缓存，
+// 并通过实例的 pubsub 频道通知其他实例上的同名 LocalCachedMap 删除或更新对应的元素。
+// 不再使用时需要调用 Close 取消订阅
+type LocalCachedMap struct {
+	*object.Object
+	m       *Map
+	options *localCachedMapOptions
+
+	id string // 当前 LocalCachedMap 的唯一标识，用于忽略自己发出的通知
+
+	mu    sync.Mutex
+	cache localCache
+	// generation 每次修改本地缓存中的元素时递增，从 redis 加载期间发生变化时，加载的值可能已经过时，不写入本地缓存
+	generation uint64
+
+	pubSub    *pubsub.PubSub
+	reconnect *pubsub.PubSub
+	closeOnce sync.Once
+}
+
+func NewLocalCachedMap(root *mutex.Root, name string, opts ...LocalCachedMapOption) *LocalCachedMap {
+	options := &localCachedMapOptions{}
+	for i := range opts {
+		opts[i](options)
+	}
+
+	root.Logger.Debugf("创建 LocalCachedMap 实例: %s, 本地缓存大小: %d", name, options.cacheSize)
+
+	m := NewMap(root, name, options.objectOptions...)
+	l := &LocalCachedMap{
+		Object:  m.Object,
+		m:       m,
+		options: options,
+		id:      uuid.New().String(),
+		cache:   newLocalCache(options.evictionPolicy, options.cacheSize),
+	}
+
+	l.pubSub = pubsub.Subscribe(utils.ChannelName(l.Key()), pubsub.WithClock(root.Clock))
+	l.reconnect = pubsub.Subscribe(root.ReconnectChannelName(), pubsub.WithClock(root.Clock))
+	go l.listen(l.pubSub.Channel(), l.reconnect.Channel())
+
+	return l
+}
+
+// Get 将 key 对应的值解码到 v 中，v 必须为指针；key 不存在时返回 false
+func (l *LocalCachedMap) Get(ctx context.Context, key string, v interface{}) (bool, error) {
+	data, ok, err := l.load(ctx, key)
+	if err != nil || !ok {
+		return false, err
+	}
+	return true, l.Decode([]byte(data), v)
+}
+
+// GetAll 批量查询，返回存在的 key 及其值，本地缓存未命中的 key 从 redis 批量加载
+func (l *LocalCachedMap) GetAll(ctx context.Context, keys ...string) (map[string]Entry, error) {
+	entries := make(map[string]Entry, len(keys))
+
+	var missing []string
+	l.mu.Lock()
+	for _, key := range keys {
+		if data, ok := l.cache.get(key); ok {
+			entries[key] = l.m.entry(key, data)
+		} else {
+			missing = append(missing, key)
+		}
+	}
+	generation := l.generation
+	l.mu.Unlock()
+
+	if len(missing) == 0 {
+		return entries, nil
+	}
+	loaded, err := l.m.GetAll(ctx, missing...)
+	if err != nil {
+		return nil, err
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	for key, e := range loaded {
+		entries[key] = e
+		if l.generation == generation {
+			l.cache.put(key, string(e.raw))
+		}
+	}
+	return entries, nil
+}
+
+// ContainsKey 查询 key 是否存在
+func (l *LocalCachedMap) ContainsKey(ctx context.Context, key string) (bool, error) {
+	_, ok, err := l.load(ctx, key)
+	return ok, err
+}
+
+// Size 返回 redis 中的元素数量
+func (l *LocalCachedMap) Size(ctx context.Context) (int64, error) {
+	return l.m.Size(ctx)
+}
+
+// CachedSize 返回本地缓存中的元素数量
+func (l *LocalCachedMap) CachedSize() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.cache.len()
+}
+
+// ClearLocalCache 清空当前实例的本地缓存
+func (l *LocalCachedMap) ClearLocalCache() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.clearLocked()
+}
+
+// Put 设置 key 对应的值，将旧值解码到 old 中(old 为 nil 时忽略)；旧值不存在时返回 false
+func (l *LocalCachedMap) Put(ctx context.Context, key string, v interface{}, old interface{}) (bool, error) {
+	data, err := l.Encode(v)
+	if err != nil {
+		return false, err
+	}
+	cmd := l.Root().Eval(ctx, localCachedMapScript.putScript, l.keys(), key, data, l.message(map[string][]byte{key: data}))
+	ok, err := l.m.decode(cmd, old, "设置")
+	if err == nil {
+		l.invalidate(key)
+	}
+	return ok, err
+}
+
+// FastPut 设置 key 对应的值，不返回旧值；key 为新增时返回 true
+func (l *LocalCachedMap) FastPut(ctx context.Context, key string, v interface{}) (bool, error) {
+	data, err := l.Encode(v)
+	if err != nil {
+		return false, err
+	}
+	cmd := l.Root().Eval(ctx, localCachedMapScript.fastPutScript, l.keys(), key, data, l.message(map[string][]byte{key: data}))
+	ok, err := l.m.result(cmd, "设置")
+	if err == nil {
+		l.invalidate(key)
+	}
+	return ok, err
+}
+
+// PutIfAbsent key 不存在时设置值，返回是否设置成功
+func (l *LocalCachedMap) PutIfAbsent(ctx context.Context, key string, v interface{}) (bool, error) {
+	data, err := l.Encode(v)
+	if err != nil {
+		return false, err
+	}
+	cmd := l.Root().Eval(ctx, localCachedMapScript.putIfAbsentScript, l.keys(), key, data, l.message(map[string][]byte{key: data}))
+	ok, err := l.m.result(cmd, "设置")
+	if ok {
+		l.invalidate(key)
+	}
+	return ok, err
+}
+
+// Replace key 存在时替换值，将旧值解码到 old 中(old 为 nil 时忽略)；key 不存在时不替换，返回 false
+func (l *LocalCachedMap) Replace(ctx context.Context, key string, v interface{}, old interface{}) (bool, error) {
+	data, err := l.Encode(v)
+	if err != nil {
+		return false, err
+	}
+	cmd := l.Root().Eval(ctx, localCachedMapScript.replaceScript, l.keys(), key, data, l.message(map[string][]byte{key: data}))
+	ok, err := l.m.decode(cmd, old, "替换")
+	if ok {
+		l.invalidate(key)
+	}
+	return ok, err
+}
+
+// Remove 删除 key，将删除前的值解码到 old 中(old 为 nil 时忽略)；key 不存在时返回 false
+func (l *LocalCachedMap) Remove(ctx context.Context, key string, old interface{}) (bool, error) {
+	cmd := l.Root().Eval(ctx, localCachedMapScript.removeScript, l.keys(), key, l.message(map[string][]byte{key: nil}))
+	ok, err := l.m.decode(cmd, old, "删除")
+	if err == nil {
+		l.invalidate(key)
+	}
+	return ok, err
+}
+
+// FastRemove 删除多个 key，不返回旧值，返回实际删除的数量
+func (l *LocalCachedMap) FastRemove(ctx context.Context, keys ...string) (int64, error) {
+	if len(keys) == 0 {
+		return 0, nil
+	}
+
+	removed := make(map[string][]byte, len(keys))
+	for _, key := range keys {
+		removed[key] = nil
+	}
+	args := append([]interface{}{l.message(removed)}, stringsToArgs(keys)...)
+	res, err := l.Root().Eval(ctx, localCachedMapScript.fastRemoveScript, l.keys(), args...).Int64()
+	if err != nil {
+		l.Root().Logger.Errorf("删除 LocalCachedMap 元素失败: %s, 错误: %v", l.Name(), err)
+		return 0, err
+	}
+	l.invalidate(keys...)
+	return res, nil
+}
+
+// PutAll 批量设置
+func (l *LocalCachedMap) PutAll(ctx context.Context, entries map[string]interface{}) error {
+	if len(entries) == 0 {
+		return nil
+	}
+
+	encoded := make(map[string][]byte, len(entries))
+	args := make([]interface{}, 1, 1+2*len(entries))
+	for k, v := range entries {
+		data, err := l.Encode(v)
+		if err != nil {
+			return err
+		}
+		encoded[k] = data
+		args = append(args, k, data)
+	}
+	args[0] = l.message(encoded)
+
+	if err := l.Root().Eval(ctx, localCachedMapScript.putAllScript, l.keys(), args...).Err(); err != nil {
+		l.Root().Logger.Errorf("批量设置 LocalCachedMap 失败: %s, 错误: %v", l.Name(), err)
+		return err
+	}
+	keys := make([]string, 0, len(encoded))
+	for k := range encoded {
+		keys = append(keys, k)
+	}
+	l.invalidate(keys...)
+	return nil
+}
+
+// Delete 删除 Map，并通知所有实例清空本地缓存；Map 不存在时返回 false
+func (l *LocalCachedMap) Delete(ctx context.Context) (bool, error) {
+	msg := ""
+	if l.options.syncStrategy != SyncNone {
+		msg = l.Key() + ":" + localCachedMapActionClear + "." + l.id
+	}
+	res, err := l.Root().Eval(ctx, localCachedMapScript.deleteScript, l.keys(), msg).Int64()
+	if err != nil {
+		l.Root().Logger.Errorf("删除 LocalCachedMap 失败: %s, 错误: %v", l.Name(), err)
+		return false, err
+	}
+	l.ClearLocalCache()
+	return res == 1, nil
+}
+
+// Keys 基于 HSCAN 遍历 redis 中的 key，不经过本地缓存
+func (l *LocalCachedMap) Keys(count int64) *KeyIterator {
+	return l.m.Keys(count)
+}
+
+// Values 基于 HSCAN 遍历 redis 中的值，不经过本地缓存
+func (l *LocalCachedMap) Values(count int64) *ValueIterator {
+	return l.m.Values(count)
+}
+
+// Entries 基于 HSCAN 遍历 redis 中的键值对，不经过本地缓存
+func (l *LocalCachedMap) Entries(count int64) *EntryIterator {
+	return l.m.Entries(count)
+}
+
+// GetLock 返回与 key 对应的互斥锁，与同名 Map 的 GetLock 是同一把锁
+func (l *LocalCachedMap) GetLock(key string, opts ...mutex.Option) *mutex.Mutex {
+	return l.m.GetLock(key, opts...)
+}
+
+// Close 取消订阅，之后本地缓存不再与其他实例同步
+func (l *LocalCachedMap) Close() {
+	l.closeOnce.Do(func() {
+		l.pubSub.Close()
+		l.reconnect.Close()
+		l.ClearLocalCache()
+	})
+}
+
+// load 优先从本地缓存读取，未命中时从 redis 加载并写入本地缓存
+func (l *LocalCachedMap) load(ctx context.Context, key string) (string, bool, error) {
+	l.mu.Lock()
+	data, ok := l.cache.get(key)
+	generation := l.generation
+	l.mu.Unlock()
+	if ok {
+		return data, true, nil
+	}
+
+	data, err := l.Root().Eval(ctx, mapScript.getScript, []string{l.Key()}, key).Text()
+	if err == redis.Nil {
+		return "", false, nil
+	}
+	if err != nil {
+		l.Root().Logger.Errorf("查询 LocalCachedMap 元素失败: %s, 错误: %v", l.Name(), err)
+		return "", false, err
+	}
+
+	l.mu.Lock()
+	if l.generation == generation {
+		l.cache.put(key, data)
+	}
+	l.mu.Unlock()
+	return data, true, nil
+}
+
+// invalidate 删除本地缓存中的 key，并使写入前开始、尚未完成的加载失效。
+// 当前实例的写入只删除本地缓存，不写入新值：同一实例中并发写入同一 key 时，
+// 写入返回的顺序可能与 redis 执行的顺序不同，写入新值会使本地缓存保留较旧的值
+func (l *LocalCachedMap) invalidate(keys ...string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	for _, key := range keys {
+		l.cache.remove(key)
+	}
+	l.generation++
+}
+
+func (l *LocalCachedMap) clearLocked() {
+	l.cache.clear()
+	l.generation++
+}
+
+// keys 返回写入脚本使用的 KEYS
+func (l *LocalCachedMap) keys() []string {
+	return []string{l.Key(), l.Root().RedisChannelName}
+}
+
+// message 构造同步消息，格式为 "主key:动作.发送方标识.元素列表"；
+// 元素之间以 ',' 分隔，SyncUpdate 时每个元素为 "key=值"，key 与值均为 base64 编码，避免与分隔符冲突。
+// 值为 nil 表示删除，此时总是通知删除；SyncNone 时返回空字符串，脚本不发布消息
+func (l *LocalCachedMap) message(entries map[string][]byte) string {
+	if l.options.syncStrategy == SyncNone {
+		return ""
+	}
+
+	action := localCachedMapActionUpdate
+	items := make([]string, 0, len(entries))
+	for _, v := range entries {
+		if v == nil || l.options.syncStrategy == SyncInvalidate {
+			action = localCachedMapActionInvalidate
+		}
+	}
+	for k, v := range entries {
+		item := base64.RawStdEncoding.EncodeToString([]byte(k))
+		if action == localCachedMapActionUpdate {
+			item += "=" + base64.RawStdEncoding.EncodeToString(v)
+		}
+		items = append(items, item)
+	}
+	return l.Key() + ":" + action + "." + l.id + "." + strings.Join(items, ",")
+}
+
+// listen 处理其他实例发出的同步消息以及订阅重连通知，直到 Close
+func (l *LocalCachedMap) listen(notices, reconnects <-chan string) {
+	for notices != nil || reconnects != nil {
+		select {
+		case msg, ok := <-notices:
+			if !ok {
+				notices = nil
+				continue
+			}
+			l.handle(msg)
+		case _, ok := <-reconnects:
+			if !ok {
+				reconnects = nil
+				continue
+			}
+			l.onReconnect()
+		}
+	}
+}
+
+// handle 处理同步消息，忽略自己发出的消息
+func (l *LocalCachedMap) handle(msg string) {
+	parts := strings.SplitN(msg, ".", 3)
+	if len(parts) < 2 {
+		l.Root().Logger.Warnf("收到无法解析的 LocalCachedMap 消息: %s, 消息: %s", l.Name(), msg)
+		return
+	}
+	if parts[1] == l.id {
+		return
+	}
+
+	if parts[0] == localCachedMapActionClear {
+		l.Root().Logger.Debugf("LocalCachedMap 已被删除，清空本地缓存: %s", l.Name())
+		l.ClearLocalCache()
+		return
+	}
+	if len(parts) != 3 {
+		l.Root().Logger.Warnf("收到无法解析的 LocalCachedMap 消息: %s, 消息: %s", l.Name(), msg)
+		return
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	for _, item := range strings.Split(parts[2], ",") {
+		var encodedKey, encodedValue string
+		if idx := strings.IndexByte(item, '='); idx >= 0 {
+			encodedKey, encodedValue = item[:idx], item[idx+1:]
+		} else {
+			encodedKey = item
+		}
+		key, err := base64.RawStdEncoding.DecodeString(encodedKey)
+		if err != nil {
+			l.Root().Logger.Warnf("收到无法解析的 LocalCachedMap 消息: %s, 消息: %s", l.Name(), msg)
+			l.clearLocked()
+			return
+		}
+
+		switch parts[0] {
+		case localCachedMapActionUpdate:
+			value, err := base64.RawStdEncoding.DecodeString(encodedValue)
+			if err != nil {
+				l.cache.remove(string(key))
+				l.generation++
+				continue
+			}
+			l.cache.put(string(key), string(value))
+			// 更新同样会使正在加载的旧值失效
+			l.generation++
+		default:
+			l.cache.remove(string(key))
+			l.generation++
+		}
+	}
+}
+
+// onReconnect 按 ReconnectionStrategy 处理本地缓存
+func (l *LocalCachedMap) onReconnect() {
+	switch l.options.reconnection {
+	case ReconnectNone:
+		return
+	case ReconnectReload:
+		l.Root().Logger.Infof("redis 订阅已重连，重新加载 LocalCachedMap 本地缓存: %s", l.Name())
+		l.reload(context.Background())
+	default:
+		l.Root().Logger.Infof("redis 订阅已重连，清空 LocalCachedMap 本地缓存: %s", l.Name())
+		l.ClearLocalCache()
+	}
+}
+
+// reload 清空本地缓存后从 redis 重新加载，直到本地缓存已满
+func (l *LocalCachedMap) reload(ctx context.Context) {
+	l.mu.Lock()
+	l.clearLocked()
+	generation := l.generation
+	l.mu.Unlock()
+
+	it := l.m.Entries(reloadBatchSize)
+	for it.Next(ctx) {
+		e := it.Entry()
+
+		l.mu.Lock()
+		if l.generation != generation || (l.options.cacheSize > 0 && l.cache.len() >= l.options.cacheSize) {
+			// 加载期间有其他写入，或本地缓存已满，停止加载，其余元素在读取时加载
+			l.mu.Unlock()
+			return
+		}
+		l.cache.put(e.Key, string(e.raw))
+		l.mu.Unlock()
+	}
+	if err := it.Err(); err != nil {
+		l.Root().Logger.Errorf("重新加载 LocalCachedMap 失败: %s, 错误: %v", l.Name(), err)
+		l.ClearLocalCache()
+	}
+}
+
+// localCachedMapLuaPrelude LocalCachedMap 写入脚本共用的 lua 函数
+// KEYS[1] Map
+// KEYS[2] 发布订阅的channel
+const localCachedMapLuaPrelude = `
+	-- 同步消息为空时不发布
+	local function notify(msg)
+		if msg ~= '' then
+			redis.call('publish',KEYS[2],msg)
+		end
+	end
+`
+
+func init() {
+	localCachedMapScript.putScript = backend.NewScript("localCachedMap.put", localCachedMapLuaPrelude+`
+	-- ARGV[1] key
+	-- ARGV[2] 值
+	-- ARGV[3] 同步消息
+	local old = redis.call('hget',KEYS[1],ARGV[1])
+	redis.call('hset',KEYS[1],ARGV[1],ARGV[2])
+	notify(ARGV[3])
+	return old
+`)
+
+	localCachedMapScript.fastPutScript = backend.NewScript("localCachedMap.fastPut", localCachedMapLuaPrelude+`
+	-- ARGV[1] key
+	-- ARGV[2] 值
+	-- ARGV[3] 同步消息
+	local n = redis.call('hset',KEYS[1],ARGV[1],ARGV[2])
+	notify(ARGV[3])
+	return n
+`)
+
+	localCachedMapScript.putIfAbsentScript = backend.NewScript("localCachedMap.putIfAbsent", localCachedMapLuaPrelude+`
+	-- ARGV[1] key
+	-- ARGV[2] 值
+	-- ARGV[3] 同步消息
+	if redis.call('hsetnx',KEYS[1],ARGV[1],ARGV[2]) == 0 then
+		return 0
+	end
+	notify(ARGV[3])
+	return 1
+`)
+
+	localCachedMapScript.replaceScript = backend.NewScript("localCachedMap.replace", localCachedMapLuaPrelude+`
+	-- ARGV[1] key
+	-- ARGV[2] 值
+	-- ARGV[3] 同步消息
+	local old = redis.call('hget',KEYS[1],ARGV[1])
+	if old ~= false then
+		redis.call('hset',KEYS[1],ARGV[1],ARGV[2])
+		notify(ARGV[3])
+	end
+	return old
+`)
+
+	localCachedMapScript.removeScript = backend.NewScript("localCachedMap.remove", localCachedMapLuaPrelude+`
+	-- ARGV[1] key
+	-- ARGV[2] 同步消息
+	local old = redis.call('hget',KEYS[1],ARGV[1])
+	if old ~= false then
+		redis.call('hdel',KEYS[1],ARGV[1])
+		notify(ARGV[2])
+	end
+	return old
+`)
+
+	localCachedMapScript.fastRemoveScript = backend.NewScript("localCachedMap.fastRemove", localCachedMapLuaPrelude+`
+	-- ARGV[1] 同步消息
+	-- ARGV[2...] key 列表
+	-- 分批展开参数，unpack 的参数个数受 lua 栈大小的限制
+	local n = 0
+	for i = 2, #ARGV, 1000 do
+		n = n + redis.call('hdel',KEYS[1],unpack(ARGV,i,math.min(i+999,#ARGV)))
+	end
+	if n > 0 then
+		notify(ARGV[1])
+	end
+	return n
+`)
+
+	localCachedMapScript.putAllScript = backend.NewScript("localCachedMap.putAll", localCachedMapLuaPrelude+`
+	-- ARGV[1] 同步消息
+	-- ARGV[2...] key、值交替的列表
+	-- 分批展开参数，unpack 的参数个数受 lua 栈大小的限制；每批的数量为偶数，key 与值不会被拆开
+	for i = 2, #ARGV, 1000 do
+		redis.call('hmset',KEYS[1],unpack(ARGV,i,math.min(i+999,#ARGV)))
+	end
+	notify(ARGV[1])
+	return 1
+`)
+
+	localCachedMapScript.deleteScript = backend.NewScript("localCachedMap.delete", localCachedMapLuaPrelude+`
+	-- ARGV[1] 同步消息
+	local n = redis.call('del',KEYS[1])
+	if n > 0 then
+		notify(ARGV[1])
+	end
+	return n
+`)
+}
@@ -0,0 +1,228 @@
+package hashmap_test
+
+import (
+	"context"
+	"runtime"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+
+	"github.com/MaricoHan/redisson"
+	"github.com/MaricoHan/redisson/hashmap"
+	"github.com/MaricoHan/redisson/mutex"
+	"github.com/MaricoHan/redisson/pkg/utils"
+	"github.com/MaricoHan/redisson/pkg/utils/pubsub"
+)
+
+// waitFor 等待条件成立，同步消息经 redis 转发，是异步到达的
+func waitFor(cond func() bool) bool {
+	for i := 0; i < 100; i++ {
+		if cond() {
+			return true
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	return false
+}
+
+func TestLocalCachedMap(t *testing.T) {
+	client := redis.NewClient(&redis.Options{Addr: ":6379"})
+	client.Del(context.Background(), "localCachedMapKey")
+	r1 := redisson.New(context.Background(), client)
+	r2 := redisson.New(context.Background(), client)
+	ctx := context.Background()
+
+	m1 := r1.NewLocalCachedMap("localCachedMapKey")
+	defer m1.Close()
+	m2 := r2.NewLocalCachedMap("localCachedMapKey")
+	defer m2.Close()
+
+	if _, err := m1.FastPut(ctx, "a", user{Name: "a"}); err != nil {
+		t.Error(err)
+		return
+	}
+	// 同一进程中的每个实例都会转发一次同步消息，等待处理完成；
+	// 加载期间收到的同步消息会使加载的值不写入本地缓存，因此重复读取直到写入
+	time.Sleep(100 * time.Millisecond)
+	var u user
+	if !waitFor(func() bool {
+		ok, err := m2.Get(ctx, "a", &u)
+		return err == nil && ok && u.Name == "a" && m2.CachedSize() == 1
+	}) {
+		t.Errorf("value should be cached: %+v, %d", u, m2.CachedSize())
+		return
+	}
+
+	// 测试：本地缓存命中时不访问 redis
+	client.HSet(ctx, "localCachedMapKey", "a", `{"Name":"bypass"}`)
+	if ok, _ := m2.Get(ctx, "a", &u); !ok || u.Name != "a" {
+		t.Errorf("value should be read from local cache: %+v", u)
+		return
+	}
+
+	// 测试：写入后通知其他实例删除本地缓存
+	if _, err := m1.Put(ctx, "a", user{Name: "b"}, nil); err != nil {
+		t.Error(err)
+		return
+	}
+	if !waitFor(func() bool { return m2.CachedSize() == 0 }) {
+		t.Error("local cache should be invalidated")
+		return
+	}
+	if ok, _ := m2.Get(ctx, "a", &u); !ok || u.Name != "b" {
+		t.Errorf("unexpected value: %+v", u)
+		return
+	}
+
+	// 测试：删除
+	if ok, _ := m1.Remove(ctx, "a", nil); !ok {
+		t.Error("remove failed")
+		return
+	}
+	if !waitFor(func() bool { return m2.CachedSize() == 0 }) {
+		t.Error("local cache should be invalidated")
+		return
+	}
+	if ok, _ := m2.ContainsKey(ctx, "a"); ok {
+		t.Error("key should be removed")
+		return
+	}
+
+	runtime.KeepAlive(r1)
+	runtime.KeepAlive(r2)
+}
+
+func TestLocalCachedMap_Update(t *testing.T) {
+	client := redis.NewClient(&redis.Options{Addr: ":6379"})
+	client.Del(context.Background(), "localCachedMapUpdateKey")
+	r1 := redisson.New(context.Background(), client)
+	r2 := redisson.New(context.Background(), client)
+	ctx := context.Background()
+
+	m1 := r1.NewLocalCachedMap("localCachedMapUpdateKey", hashmap.WithSyncStrategy(hashmap.SyncUpdate))
+	defer m1.Close()
+	m2 := r2.NewLocalCachedMap("localCachedMapUpdateKey", hashmap.WithSyncStrategy(hashmap.SyncUpdate),
+		hashmap.WithCacheSize(10), hashmap.WithEvictionPolicy(hashmap.EvictLFU))
+	defer m2.Close()
+
+	if err := m1.PutAll(ctx, map[string]interface{}{"a:1": 1, "b,2": 2}); err != nil {
+		t.Error(err)
+		return
+	}
+	// 新值随通知一起发送，其他实例无需访问 redis
+	if !waitFor(func() bool { return m2.CachedSize() == 2 }) {
+		t.Errorf("local cache should be updated: %d", m2.CachedSize())
+		return
+	}
+	client.Del(ctx, "localCachedMapUpdateKey")
+	entries, err := m2.GetAll(ctx, "a:1", "b,2")
+	if err != nil || len(entries) != 2 {
+		t.Errorf("unexpected entries: %v, %v", entries, err)
+		return
+	}
+	var v int
+	if err := entries["b,2"].Value(&v); err != nil || v != 2 {
+		t.Errorf("unexpected value: %d, %v", v, err)
+		return
+	}
+
+	// 测试：删除 Map 时清空所有实例的本地缓存
+	if _, err := m1.Delete(ctx); err != nil {
+		t.Error(err)
+		return
+	}
+	_ = m1.PutAll(ctx, map[string]interface{}{"c": 3})
+	if _, err := m1.Delete(ctx); err != nil {
+		t.Error(err)
+		return
+	}
+	if !waitFor(func() bool { return m2.CachedSize() == 0 }) {
+		t.Errorf("local cache should be cleared: %d", m2.CachedSize())
+		return
+	}
+
+	runtime.KeepAlive(r1)
+	runtime.KeepAlive(r2)
+}
+
+func TestLocalCachedMap_Reconnect(t *testing.T) {
+	client := redis.NewClient(&redis.Options{Addr: ":6379"})
+	client.Del(context.Background(), "localCachedMapReconnectKey")
+	r := redisson.New(context.Background(), client)
+	ctx := context.Background()
+
+	clear := r.NewLocalCachedMap("localCachedMapReconnectKey")
+	defer clear.Close()
+	reload := r.NewLocalCachedMap("localCachedMapReconnectKey", hashmap.WithReconnectionStrategy(hashmap.ReconnectReload))
+	defer reload.Close()
+
+	if err := clear.PutAll(ctx, map[string]interface{}{"a": 1, "b": 2, "c": 3}); err != nil {
+		t.Error(err)
+		return
+	}
+	// 当前实例的写入只删除本地缓存，读取后才缓存
+	for _, key := range []string{"a", "b", "c"} {
+		var v int
+		if ok, err := clear.Get(ctx, key, &v); err != nil || !ok {
+			t.Errorf("get failed: %s, %v", key, err)
+			return
+		}
+	}
+	if clear.CachedSize() != 3 {
+		t.Errorf("unexpected cached size: %d", clear.CachedSize())
+		return
+	}
+
+	// 等待写入的同步消息处理完成，再模拟实例的 redis 订阅重连
+	time.Sleep(100 * time.Millisecond)
+	pubsub.Publish(utils.ChannelName(utils.ChannelName("redisson_pubsub")), mutex.ReconnectedMessage)
+
+	if !waitFor(func() bool { return clear.CachedSize() == 0 }) {
+		t.Errorf("local cache should be cleared: %d", clear.CachedSize())
+	}
+	if !waitFor(func() bool { return reload.CachedSize() == 3 }) {
+		t.Errorf("local cache should be reloaded: %d", reload.CachedSize())
+	}
+
+	runtime.KeepAlive(r)
+}
+
+// TestLocalCachedMap_ConcurrentPut
+// @Description: 测试：同一实例并发写入同一 key 后，本地缓存与 redis 中的值一致
+// @param t
+func TestLocalCachedMap_ConcurrentPut(t *testing.T) {
+	client := redis.NewClient(&redis.Options{Addr: ":6379"})
+	client.Del(context.Background(), "localCachedMapConcurrentKey")
+	r := redisson.New(context.Background(), client)
+	ctx := context.Background()
+
+	m := r.NewLocalCachedMap("localCachedMapConcurrentKey")
+	defer m.Close()
+
+	wg := sync.WaitGroup{}
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			if _, err := m.FastPut(ctx, "a", i); err != nil {
+				t.Error(err)
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	var cached int
+	if ok, err := m.Get(ctx, "a", &cached); err != nil || !ok {
+		t.Errorf("get failed: %v", err)
+		return
+	}
+	if stored := client.HGet(ctx, "localCachedMapConcurrentKey", "a").Val(); stored != strconv.Itoa(cached) {
+		t.Errorf("local cache is stale: %d, redis: %s", cached, stored)
+	}
+
+	client.Del(context.Background(), "localCachedMapConcurrentKey")
+	runtime.KeepAlive(r)
+}
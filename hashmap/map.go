@@ -0,0 +1,348 @@
+// Package hashmap 提供基于 redis hash 的分布式 Map
+package hashmap
+
+import (
+	"context"
+	"strconv"
+
+	"github.com/go-redis/redis/v8"
+
+	"github.com/MaricoHan/redisson/mutex"
+	"github.com/MaricoHan/redisson/pkg/backend"
+	"github.com/MaricoHan/redisson/pkg/object"
+)
+
+var mapScript = struct {
+	getScript         *backend.Script
+	putScript         *backend.Script
+	putIfAbsentScript *backend.Script
+	replaceScript     *backend.Script
+	removeScript      *backend.Script
+	fastPutScript     *backend.Script
+	fastRemoveScript  *backend.Script
+	addAndGetScript   *backend.Script
+	getAllScript      *backend.Script
+	putAllScript      *backend.Script
+	sizeScript        *backend.Script
+	containsKeyScript *backend.Script
+	scanScript        *backend.Script
+}{}
+
+// Map 是基于 redis hash 的分布式 Map，键原样保存为 hash 的 field，值通过对象的编解码器编码
+type Map struct {
+	*object.Object
+}
+
+func NewMap(root *mutex.Root, name string, opts ...object.Option) *Map {
+	root.Logger.Debugf("创建 Map 实例: %s", name)
+	return &Map{Object: object.New(root, name).With(opts...)}
+}
+
+// Get 将 key 对应的值解码到 v 中，v 必须为指针；key 不存在时返回 false
+func (m *Map) Get(ctx context.Context, key string, v interface{}) (bool, error) {
+	data, ok, err := m.CachedLoad(key, func() (string, bool, error) {
+		return m.text(m.Root().Eval(ctx, mapScript.getScript, []string{m.Key()}, key), "查询")
+	})
+	if err != nil || !ok {
+		return false, err
+	}
+	return true, m.Decode([]byte(data), v)
+}
+
+// Put 设置 key 对应的值，将旧值解码到 old 中(old 为 nil 时忽略)；旧值不存在时返回 false
+func (m *Map) Put(ctx context.Context, key string, v interface{}, old interface{}) (bool, error) {
+	defer m.InvalidateCache()
+
+	data, err := m.Encode(v)
+	if err != nil {
+		return false, err
+	}
+	return m.decode(m.Root().Eval(ctx, mapScript.putScript, []string{m.Key()}, key, data), old, "设置")
+}
+
+// PutIfAbsent key 不存在时设置值，返回是否设置成功
+func (m *Map) PutIfAbsent(ctx context.Context, key string, v interface{}) (bool, error) {
+	defer m.InvalidateCache()
+
+	data, err := m.Encode(v)
+	if err != nil {
+		return false, err
+	}
+	return m.result(m.Root().Eval(ctx, mapScript.putIfAbsentScript, []string{m.Key()}, key, data), "设置")
+}
+
+// Replace key 存在时替换值，将旧值解码到 old 中(old 为 nil 时忽略)；key 不存在时不替换，返回 false
+func (m *Map) Replace(ctx context.Context, key string, v interface{}, old interface{}) (bool, error) {
+	defer m.InvalidateCache()
+
+	data, err := m.Encode(v)
+	if err != nil {
+		return false, err
+	}
+	return m.decode(m.Root().Eval(ctx, mapScript.replaceScript, []string{m.Key()}, key, data), old, "替换")
+}
+
+// Remove 删除 key，将删除前的值解码到 old 中(old 为 nil 时忽略)；key 不存在时返回 false
+func (m *Map) Remove(ctx context.Context, key string, old interface{}) (bool, error) {
+	defer m.InvalidateCache()
+
+	return m.decode(m.Root().Eval(ctx, mapScript.removeScript, []string{m.Key()}, key), old, "删除")
+}
+
+// FastPut 设置 key 对应的值，不返回旧值；key 为新增时返回 true
+func (m *Map) FastPut(ctx context.Context, key string, v interface{}) (bool, error) {
+	defer m.InvalidateCache()
+
+	data, err := m.Encode(v)
+	if err != nil {
+		return false, err
+	}
+	return m.result(m.Root().Eval(ctx, mapScript.fastPutScript, []string{m.Key()}, key, data), "设置")
+}
+
+// FastRemove 删除多个 key，不返回旧值，返回实际删除的数量
+func (m *Map) FastRemove(ctx context.Context, keys ...string) (int64, error) {
+	defer m.InvalidateCache()
+
+	if len(keys) == 0 {
+		return 0, nil
+	}
+	res, err := m.Root().Eval(ctx, mapScript.fastRemoveScript, []string{m.Key()}, stringsToArgs(keys)...).Int64()
+	if err != nil {
+		m.Root().Logger.Errorf("删除 Map 元素失败: %s, 错误: %v", m.Name(), err)
+		return 0, err
+	}
+	return res, nil
+}
+
+// AddAndGet 将 key 对应的整数值加 delta，返回新值；key 不存在时视为 0。
+// 值以十进制文本保存，与 JSON 编码的整数一致
+func (m *Map) AddAndGet(ctx context.Context, key string, delta int64) (int64, error) {
+	defer m.InvalidateCache()
+
+	res, err := m.Root().Eval(ctx, mapScript.addAndGetScript, []string{m.Key()}, key, delta).Text()
+	if err != nil {
+		m.Root().Logger.Errorf("累加 Map 元素失败: %s, 错误: %v", m.Name(), err)
+		return 0, err
+	}
+	return strconv.ParseInt(res, 10, 64)
+}
+
+// GetAll 批量查询，返回存在的 key 及其值
+func (m *Map) GetAll(ctx context.Context, keys ...string) (map[string]Entry, error) {
+	entries := make(map[string]Entry, len(keys))
+	if len(keys) == 0 {
+		return entries, nil
+	}
+
+	res, err := m.Root().Eval(ctx, mapScript.getAllScript, []string{m.Key()}, stringsToArgs(keys)...).Slice()
+	if err != nil {
+		m.Root().Logger.Errorf("批量查询 Map 失败: %s, 错误: %v", m.Name(), err)
+		return nil, err
+	}
+	for i, v := range res {
+		if s, ok := v.(string); ok {
+			entries[keys[i]] = m.entry(keys[i], s)
+		}
+	}
+	return entries, nil
+}
+
+// PutAll 批量设置
+func (m *Map) PutAll(ctx context.Context, entries map[string]interface{}) error {
+	defer m.InvalidateCache()
+
+	if len(entries) == 0 {
+		return nil
+	}
+
+	args := make([]interface{}, 0, 2*len(entries))
+	for k, v := range entries {
+		data, err := m.Encode(v)
+		if err != nil {
+			return err
+		}
+		args = append(args, k, data)
+	}
+	if err := m.Root().Eval(ctx, mapScript.putAllScript, []string{m.Key()}, args...).Err(); err != nil {
+		m.Root().Logger.Errorf("批量设置 Map 失败: %s, 错误: %v", m.Name(), err)
+		return err
+	}
+	return nil
+}
+
+// Size 返回元素数量
+func (m *Map) Size(ctx context.Context) (int64, error) {
+	res, err := m.Root().Eval(ctx, mapScript.sizeScript, []string{m.Key()}).Int64()
+	if err != nil {
+		m.Root().Logger.Errorf("查询 Map 元素数量失败: %s, 错误: %v", m.Name(), err)
+		return 0, err
+	}
+	return res, nil
+}
+
+// ContainsKey 查询 key 是否存在
+func (m *Map) ContainsKey(ctx context.Context, key string) (bool, error) {
+	return m.result(m.Root().Eval(ctx, mapScript.containsKeyScript, []string{m.Key()}, key), "查询")
+}
+
+// GetLock 返回与 key 对应的互斥锁，用于对单个元素的"读取-修改-写入"加锁
+func (m *Map) GetLock(key string, opts ...mutex.Option) *mutex.Mutex {
+	return mutex.NewMutex(m.Root(), m.Name()+":lock:"+key, opts...)
+}
+
+// decode 解码脚本返回的值，nil 表示值不存在
+func (m *Map) decode(cmd *redis.Cmd, v interface{}, desc string) (bool, error) {
+	res, ok, err := m.text(cmd, desc)
+	if err != nil || !ok || v == nil {
+		return ok, err
+	}
+	return true, m.Decode([]byte(res), v)
+}
+
+// text 返回脚本返回的编码值，nil 表示值不存在
+func (m *Map) text(cmd *redis.Cmd, desc string) (string, bool, error) {
+	res, err := cmd.Text()
+	if err == redis.Nil {
+		return "", false, nil
+	}
+	if err != nil {
+		m.Root().Logger.Errorf("%s Map 元素失败: %s, 错误: %v", desc, m.Name(), err)
+		return "", false, err
+	}
+	return res, true, nil
+}
+
+// result 解析脚本返回的 0/1
+func (m *Map) result(cmd *redis.Cmd, desc string) (bool, error) {
+	res, err := cmd.Int64()
+	if err != nil {
+		m.Root().Logger.Errorf("%s Map 元素失败: %s, 错误: %v", desc, m.Name(), err)
+		return false, err
+	}
+	return res == 1, nil
+}
+
+func (m *Map) entry(key, value string) Entry {
+	return Entry{Key: key, raw: []byte(value), object: m.Object}
+}
+
+func stringsToArgs(s []string) []interface{} {
+	args := make([]interface{}, len(s))
+	for i := range s {
+		args[i] = s[i]
+	}
+	return args
+}
+
+func init() {
+	mapScript.getScript = backend.NewScript("map.get", `
+	-- KEYS[1] Map
+	-- ARGV[1] key
+	return redis.call('hget',KEYS[1],ARGV[1])
+`)
+
+	mapScript.putScript = backend.NewScript("map.put", `
+	-- KEYS[1] Map
+	-- ARGV[1] key
+	-- ARGV[2] 值
+	local old = redis.call('hget',KEYS[1],ARGV[1])
+	redis.call('hset',KEYS[1],ARGV[1],ARGV[2])
+	return old
+`)
+
+	mapScript.putIfAbsentScript = backend.NewScript("map.putIfAbsent", `
+	-- KEYS[1] Map
+	-- ARGV[1] key
+	-- ARGV[2] 值
+	return redis.call('hsetnx',KEYS[1],ARGV[1],ARGV[2])
+`)
+
+	mapScript.replaceScript = backend.NewScript("map.replace", `
+	-- KEYS[1] Map
+	-- ARGV[1] key
+	-- ARGV[2] 值
+	local old = redis.call('hget',KEYS[1],ARGV[1])
+	if old ~= false then
+		redis.call('hset',KEYS[1],ARGV[1],ARGV[2])
+	end
+	return old
+`)
+
+	mapScript.removeScript = backend.NewScript("map.remove", `
+	-- KEYS[1] Map
+	-- ARGV[1] key
+	local old = redis.call('hget',KEYS[1],ARGV[1])
+	redis.call('hdel',KEYS[1],ARGV[1])
+	return old
+`)
+
+	mapScript.fastPutScript = backend.NewScript("map.fastPut", `
+	-- KEYS[1] Map
+	-- ARGV[1] key
+	-- ARGV[2] 值
+	return redis.call('hset',KEYS[1],ARGV[1],ARGV[2])
+`)
+
+	mapScript.fastRemoveScript = backend.NewScript("map.fastRemove", `
+	-- KEYS[1] Map
+	-- ARGV key 列表
+	-- 分批展开参数，unpack 的参数个数受 lua 栈大小的限制
+	local n = 0
+	for i = 1, #ARGV, 1000 do
+		n = n + redis.call('hdel',KEYS[1],unpack(ARGV,i,math.min(i+999,#ARGV)))
+	end
+	return n
+`)
+
+	mapScript.addAndGetScript = backend.NewScript("map.addAndGet", `
+	-- KEYS[1] Map
+	-- ARGV[1] key
+	-- ARGV[2] 增量
+	-- lua 中的数字为 double，以字符串返回新值，避免大整数的精度损失
+	redis.call('hincrby',KEYS[1],ARGV[1],ARGV[2])
+	return redis.call('hget',KEYS[1],ARGV[1])
+`)
+
+	mapScript.getAllScript = backend.NewScript("map.getAll", `
+	-- KEYS[1] Map
+	-- ARGV key 列表
+	-- 分批展开参数，unpack 的参数个数受 lua 栈大小的限制
+	local values = {}
+	for i = 1, #ARGV, 1000 do
+		local batch = redis.call('hmget',KEYS[1],unpack(ARGV,i,math.min(i+999,#ARGV)))
+		for j = 1, #batch do
+			values[#values+1] = batch[j]
+		end
+	end
+	return values
+`)
+
+	mapScript.putAllScript = backend.NewScript("map.putAll", `
+	-- KEYS[1] Map
+	-- ARGV key、值交替的列表
+	-- 分批展开参数，unpack 的参数个数受 lua 栈大小的限制；每批的数量为偶数，key 与值不会被拆开
+	for i = 1, #ARGV, 1000 do
+		redis.call('hmset',KEYS[1],unpack(ARGV,i,math.min(i+999,#ARGV)))
+	end
+	return 1
+`)
+
+	mapScript.sizeScript = backend.NewScript("map.size", `
+	-- KEYS[1] Map
+	return redis.call('hlen',KEYS[1])
+`)
+
+	mapScript.containsKeyScript = backend.NewScript("map.containsKey", `
+	-- KEYS[1] Map
+	-- ARGV[1] key
+	return redis.call('hexists',KEYS[1],ARGV[1])
+`)
+
+	mapScript.scanScript = backend.NewScript("map.scan", `
+	-- KEYS[1] Map
+	-- ARGV[1] 游标
+	-- ARGV[2] 每次扫描的数量
+	return redis.call('hscan',KEYS[1],ARGV[1],'count',ARGV[2])
+`)
+}
@@ -0,0 +1,237 @@
+package hashmap_test
+
+import (
+	"context"
+	"runtime"
+	"sort"
+	"strconv"
+	"testing"
+
+	"github.com/go-redis/redis/v8"
+
+	"github.com/MaricoHan/redisson"
+)
+
+type user struct {
+	Name string
+	Age  int
+}
+
+func TestMap(t *testing.T) {
+	client := redis.NewClient(&redis.Options{Addr: ":6379"})
+	client.Del(context.Background(), "mapKey")
+	r := redisson.New(context.Background(), client)
+	ctx := context.Background()
+
+	m := r.NewMap("mapKey")
+	var u user
+	if ok, err := m.Get(ctx, "u1", &u); err != nil || ok {
+		t.Errorf("map should be empty: %v", err)
+		return
+	}
+
+	// 测试：设置并返回旧值
+	if existed, err := m.Put(ctx, "u1", user{Name: "a", Age: 1}, nil); err != nil || existed {
+		t.Errorf("put failed: %v", err)
+		return
+	}
+	var old user
+	if existed, err := m.Put(ctx, "u1", user{Name: "b", Age: 2}, &old); err != nil || !existed || old.Name != "a" {
+		t.Errorf("unexpected old value: %+v, %v", old, err)
+		return
+	}
+	if ok, err := m.Get(ctx, "u1", &u); err != nil || !ok || u.Name != "b" || u.Age != 2 {
+		t.Errorf("unexpected value: %+v, %v", u, err)
+		return
+	}
+
+	// 测试：不存在时设置、存在时替换
+	if ok, _ := m.PutIfAbsent(ctx, "u1", user{}); ok {
+		t.Error("value should not be overwritten")
+		return
+	}
+	if ok, _ := m.Replace(ctx, "u2", user{}, nil); ok {
+		t.Error("absent key should not be replaced")
+		return
+	}
+	if ok, err := m.PutIfAbsent(ctx, "u2", user{Name: "c"}); err != nil || !ok {
+		t.Errorf("put if absent failed: %v", err)
+		return
+	}
+	if ok, err := m.Replace(ctx, "u2", user{Name: "d"}, &old); err != nil || !ok || old.Name != "c" {
+		t.Errorf("replace failed: %+v, %v", old, err)
+		return
+	}
+
+	// 测试：快速设置与删除
+	if created, err := m.FastPut(ctx, "u3", user{Name: "e"}); err != nil || !created {
+		t.Errorf("fast put failed: %v", err)
+		return
+	}
+	if created, _ := m.FastPut(ctx, "u3", user{Name: "f"}); created {
+		t.Error("fast put should update existing key")
+		return
+	}
+	if ok, err := m.Remove(ctx, "u3", &old); err != nil || !ok || old.Name != "f" {
+		t.Errorf("remove failed: %+v, %v", old, err)
+		return
+	}
+	if ok, _ := m.ContainsKey(ctx, "u3"); ok {
+		t.Error("key should be removed")
+		return
+	}
+
+	// 测试：整数累加
+	if n, err := m.AddAndGet(ctx, "visits", 5); err != nil || n != 5 {
+		t.Errorf("unexpected counter: %d, %v", n, err)
+		return
+	}
+	if n, err := m.AddAndGet(ctx, "visits", -2); err != nil || n != 3 {
+		t.Errorf("unexpected counter: %d, %v", n, err)
+		return
+	}
+	var visits int64
+	if ok, err := m.Get(ctx, "visits", &visits); err != nil || !ok || visits != 3 {
+		t.Errorf("counter should be readable as json: %d, %v", visits, err)
+		return
+	}
+	if n, err := m.FastRemove(ctx, "visits", "missing"); err != nil || n != 1 {
+		t.Errorf("fast remove failed: %d, %v", n, err)
+		return
+	}
+
+	// 测试：批量读写
+	if err := m.PutAll(ctx, map[string]interface{}{"u4": user{Name: "g"}, "u5": user{Name: "h"}}); err != nil {
+		t.Errorf("put all failed: %v", err)
+		return
+	}
+	entries, err := m.GetAll(ctx, "u1", "u4", "missing")
+	if err != nil || len(entries) != 2 {
+		t.Errorf("unexpected entries: %v, %v", entries, err)
+		return
+	}
+	if err := entries["u4"].Value(&u); err != nil || u.Name != "g" {
+		t.Errorf("unexpected value: %+v, %v", u, err)
+		return
+	}
+	if size, _ := m.Size(ctx); size != 4 {
+		t.Errorf("unexpected size: %d", size)
+		return
+	}
+}
+
+// TestMap_Batch
+// @Description: 测试：批量操作的 key 数量超过 lua unpack 的限制时分批执行
+// @param t
+func TestMap_Batch(t *testing.T) {
+	client := redis.NewClient(&redis.Options{Addr: ":6379"})
+	client.Del(context.Background(), "mapBatchKey")
+	r := redisson.New(context.Background(), client)
+	ctx := context.Background()
+
+	m := r.NewMap("mapBatchKey")
+	entries := make(map[string]interface{}, 10000)
+	keys := make([]string, 0, 10000)
+	for i := 0; i < 10000; i++ {
+		key := strconv.Itoa(i)
+		entries[key] = i
+		keys = append(keys, key)
+	}
+	if err := m.PutAll(ctx, entries); err != nil {
+		t.Error(err)
+		return
+	}
+	res, err := m.GetAll(ctx, keys...)
+	if err != nil || len(res) != 10000 {
+		t.Errorf("unexpected entries: %d, %v", len(res), err)
+		return
+	}
+	var v int
+	if err = res["9999"].Value(&v); err != nil || v != 9999 {
+		t.Errorf("unexpected value: %d, %v", v, err)
+		return
+	}
+	if n, err := m.FastRemove(ctx, keys...); err != nil || n != 10000 {
+		t.Errorf("unexpected removed: %d, %v", n, err)
+		return
+	}
+
+	client.Del(context.Background(), "mapBatchKey")
+	runtime.KeepAlive(r)
+}
+
+func TestMap_Iterator(t *testing.T) {
+	client := redis.NewClient(&redis.Options{Addr: ":6379"})
+	client.Del(context.Background(), "mapIterKey")
+	r := redisson.New(context.Background(), client)
+	ctx := context.Background()
+
+	m := r.NewMap("mapIterKey")
+	all := make(map[string]interface{})
+	for i := 0; i < 50; i++ {
+		all[string(rune('a'+i%26))+string(rune('a'+i/26))] = i
+	}
+	if err := m.PutAll(ctx, all); err != nil {
+		t.Error(err)
+		return
+	}
+
+	var keys []string
+	kit := m.Keys(7)
+	for kit.Next(ctx) {
+		keys = append(keys, kit.Key())
+	}
+	if kit.Err() != nil || len(keys) != 50 {
+		t.Errorf("unexpected keys: %d, %v", len(keys), kit.Err())
+		return
+	}
+	sort.Strings(keys)
+
+	sum := 0
+	vit := m.Values(7)
+	for vit.Next(ctx) {
+		var v int
+		if err := vit.Value(&v); err != nil {
+			t.Error(err)
+			return
+		}
+		sum += v
+	}
+	if sum != 49*50/2 {
+		t.Errorf("unexpected sum: %d", sum)
+		return
+	}
+
+	eit := m.Entries(0)
+	for eit.Next(ctx) {
+		e := eit.Entry()
+		var v int
+		if err := e.Value(&v); err != nil || v != all[e.Key] {
+			t.Errorf("unexpected entry: %s=%d, %v", e.Key, v, err)
+			return
+		}
+	}
+}
+
+func TestMap_GetLock(t *testing.T) {
+	client := redis.NewClient(&redis.Options{Addr: ":6379"})
+	client.Del(context.Background(), "mapLockKey")
+	r := redisson.New(context.Background(), client)
+	ctx := context.Background()
+
+	m := r.NewMap("mapLockKey")
+	lock := m.GetLock("u1")
+	if err := lock.Lock(ctx); err != nil {
+		t.Error(err)
+		return
+	}
+	if locked, _ := lock.IsLocked(ctx); !locked {
+		t.Error("entry should be locked")
+	}
+	if locked, _ := m.GetLock("u2").IsLocked(ctx); locked {
+		t.Error("other entries should not be locked")
+	}
+	if err := lock.Unlock(ctx); err != nil {
+		t.Error(err)
+	}
+}
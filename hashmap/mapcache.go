@@ -0,0 +1,410 @@
+package hashmap
+
+import (
+	"context"
+	"encoding/hex"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+
+	"github.com/MaricoHan/redisson/mutex"
+	"github.com/MaricoHan/redisson/pkg/backend"
+	"github.com/MaricoHan/redisson/pkg/object"
+	"github.com/MaricoHan/redisson/pkg/utils"
+	"github.com/MaricoHan/redisson/pkg/utils/pubsub"
+)
+
+var mapCacheScript = struct {
+	getScript         *backend.Script
+	putScript         *backend.Script
+	putIfAbsentScript *backend.Script
+	removeScript      *backend.Script
+	containsKeyScript *backend.Script
+	sizeScript        *backend.Script
+	evictScript       *backend.Script
+}{}
+
+// EventType 是 MapCache 元素的事件类型
+type EventType string
+
+const (
+	EventCreated EventType = "created"
+	EventUpdated EventType = "updated"
+	EventRemoved EventType = "removed"
+	EventExpired EventType = "expired"
+)
+
+// Event 是 MapCache 元素的事件
+type Event struct {
+	Type EventType
+	Key  string
+}
+
+// MapCache 是支持为每个元素单独设置过期时间(ttl)与最大空闲时间(maxIdle)的 Map。
+// 过期时间记录在附属的 zset 中，读取时过滤已过期的元素，并由 Redisson 实例的后台协程定期清理；
+// 元素的新增、更新、删除与过期事件通过实例的 pubsub 频道通知
+type MapCache struct {
+	*object.Object
+	scheduler *EvictionScheduler
+
+	ttlKey     string // 元素的过期时间戳（zset）
+	idleKey    string // 元素的空闲过期时间戳（zset）
+	maxIdleKey string // 元素的最大空闲时间（hash），读取时据此延长空闲过期时间
+
+	closeOnce sync.Once
+}
+
+// NewMapCache 创建 MapCache，scheduler 为 nil 时不在后台清理，过期的元素只在读取时过滤
+func NewMapCache(root *mutex.Root, scheduler *EvictionScheduler, name string, opts ...object.Option) *MapCache {
+	root.Logger.Debugf("创建 MapCache 实例: %s", name)
+
+	m := &MapCache{
+		Object:    object.New(root, name, "ttl", "idle", "maxidle").With(opts...),
+		scheduler: scheduler,
+	}
+	m.ttlKey = m.CompanionKey("ttl")
+	m.idleKey = m.CompanionKey("idle")
+	m.maxIdleKey = m.CompanionKey("maxidle")
+
+	if scheduler != nil {
+		scheduler.register(m)
+	}
+	return m
+}
+
+// Get 将 key 对应的值解码到 v 中，v 必须为指针；key 不存在或已过期时返回 false。
+// 设置了最大空闲时间的元素，每次读取都会延长其空闲过期时间
+func (m *MapCache) Get(ctx context.Context, key string, v interface{}) (bool, error) {
+	return m.decode(m.Root().Eval(ctx, mapCacheScript.getScript, m.keys(), key, m.eventPrefix()), v, "查询")
+}
+
+// Put 设置 key 对应的值，将旧值解码到 old 中(old 为 nil 时忽略)；旧值不存在或已过期时返回 false。
+// ttl、maxIdle 为 0 表示不限制
+func (m *MapCache) Put(ctx context.Context, key string, v interface{}, ttl, maxIdle time.Duration, old interface{}) (bool, error) {
+	data, err := m.Encode(v)
+	if err != nil {
+		return false, err
+	}
+	cmd := m.Root().Eval(ctx, mapCacheScript.putScript, m.keys(), key, data, toMillis(ttl), toMillis(maxIdle), m.eventPrefix(), 1)
+	return m.decode(cmd, old, "设置")
+}
+
+// FastPut 设置 key 对应的值，不返回旧值；key 为新增(或旧值已过期)时返回 true
+func (m *MapCache) FastPut(ctx context.Context, key string, v interface{}, ttl, maxIdle time.Duration) (bool, error) {
+	data, err := m.Encode(v)
+	if err != nil {
+		return false, err
+	}
+	cmd := m.Root().Eval(ctx, mapCacheScript.putScript, m.keys(), key, data, toMillis(ttl), toMillis(maxIdle), m.eventPrefix(), 0)
+	return m.result(cmd, "设置")
+}
+
+// PutIfAbsent key 不存在或已过期时设置值，返回是否设置成功
+func (m *MapCache) PutIfAbsent(ctx context.Context, key string, v interface{}, ttl, maxIdle time.Duration) (bool, error) {
+	data, err := m.Encode(v)
+	if err != nil {
+		return false, err
+	}
+	cmd := m.Root().Eval(ctx, mapCacheScript.putIfAbsentScript, m.keys(), key, data, toMillis(ttl), toMillis(maxIdle), m.eventPrefix())
+	return m.result(cmd, "设置")
+}
+
+// Remove 删除 key，将删除前的值解码到 old 中(old 为 nil 时忽略)；key 不存在或已过期时返回 false
+func (m *MapCache) Remove(ctx context.Context, key string, old interface{}) (bool, error) {
+	return m.decode(m.Root().Eval(ctx, mapCacheScript.removeScript, m.keys(), key, m.eventPrefix()), old, "删除")
+}
+
+// ContainsKey 查询 key 是否存在且未过期，与 Get 一样会延长空闲过期时间
+func (m *MapCache) ContainsKey(ctx context.Context, key string) (bool, error) {
+	return m.result(m.Root().Eval(ctx, mapCacheScript.containsKeyScript, m.keys(), key, m.eventPrefix()), "查询")
+}
+
+// Size 清理已过期的元素后返回元素数量
+func (m *MapCache) Size(ctx context.Context) (int64, error) {
+	res, err := m.Root().Eval(ctx, mapCacheScript.sizeScript, m.keys(), m.eventPrefix()).Int64()
+	if err != nil {
+		m.Root().Logger.Errorf("查询 MapCache 元素数量失败: %s, 错误: %v", m.Name(), err)
+		return 0, err
+	}
+	return res, nil
+}
+
+// Subscribe 订阅元素事件，不再使用时需要调用 Events.Close
+func (m *MapCache) Subscribe() *Events {
+	ps := pubsub.Subscribe(utils.ChannelName(m.Key()), pubsub.WithClock(m.Root().Clock))
+	e := &Events{
+		pubSub: ps,
+		ch:     make(chan Event, cap(ps.Channel())),
+	}
+
+	go func() {
+		defer close(e.ch)
+		for msg := range ps.Channel() {
+			event, ok := parseEvent(msg)
+			if !ok {
+				m.Root().Logger.Warnf("收到无法解析的 MapCache 事件: %s, 消息: %s", m.Name(), msg)
+				continue
+			}
+			select {
+			case e.ch <- event:
+			default:
+				m.Root().Logger.Warnf("MapCache 事件通道已满，丢弃事件: %s, 消息: %s", m.Name(), msg)
+			}
+		}
+	}()
+	return e
+}
+
+// Close 关闭当前 MapCache，同名的 MapCache 全部关闭后停止后台清理，不影响 redis 中的数据
+func (m *MapCache) Close() {
+	m.closeOnce.Do(func() {
+		if m.scheduler != nil {
+			m.scheduler.unregister(m)
+		}
+	})
+}
+
+// evict 清理最多 limit 个已过期的元素，返回清理的数量
+func (m *MapCache) evict(ctx context.Context, limit int64) (int64, error) {
+	return m.Root().Eval(ctx, mapCacheScript.evictScript, m.keys(), limit, m.eventPrefix()).Int64()
+}
+
+// keys 返回脚本使用的 KEYS
+func (m *MapCache) keys() []string {
+	return []string{m.Key(), m.ttlKey, m.idleKey, m.maxIdleKey, m.Root().RedisChannelName}
+}
+
+// eventPrefix 返回事件消息的前缀，消息格式为 "主key:事件类型.十六进制编码的元素key"
+func (m *MapCache) eventPrefix() string {
+	return m.Key() + ":"
+}
+
+func (m *MapCache) decode(cmd *redis.Cmd, v interface{}, desc string) (bool, error) {
+	res, err := cmd.Text()
+	if err == redis.Nil {
+		return false, nil
+	}
+	if err != nil {
+		m.Root().Logger.Errorf("%s MapCache 元素失败: %s, 错误: %v", desc, m.Name(), err)
+		return false, err
+	}
+	if v == nil {
+		return true, nil
+	}
+	return true, m.Decode([]byte(res), v)
+}
+
+func (m *MapCache) result(cmd *redis.Cmd, desc string) (bool, error) {
+	res, err := cmd.Int64()
+	if err != nil {
+		m.Root().Logger.Errorf("%s MapCache 元素失败: %s, 错误: %v", desc, m.Name(), err)
+		return false, err
+	}
+	return res == 1, nil
+}
+
+// Events 是 MapCache 元素事件的订阅
+type Events struct {
+	pubSub *pubsub.PubSub
+	ch     chan Event
+}
+
+// Channel 返回事件通道，Close 后关闭
+func (e *Events) Channel() <-chan Event {
+	return e.ch
+}
+
+// Close 取消订阅
+func (e *Events) Close() {
+	e.pubSub.Close()
+}
+
+// parseEvent 解析 "事件类型.十六进制编码的元素key" 格式的消息
+func parseEvent(msg string) (Event, bool) {
+	idx := strings.Index(msg, ".")
+	if idx < 0 {
+		return Event{}, false
+	}
+	key, err := hex.DecodeString(msg[idx+1:])
+	if err != nil {
+		return Event{}, false
+	}
+	return Event{Type: EventType(msg[:idx]), Key: string(key)}, true
+}
+
+func toMillis(d time.Duration) int64 {
+	return int64(d / time.Millisecond)
+}
+
+// mapCacheLuaPrelude MapCache 脚本共用的 lua 函数
+// KEYS[1] Map
+// KEYS[2] 元素的过期时间戳（zset）
+// KEYS[3] 元素的空闲过期时间戳（zset）
+// KEYS[4] 元素的最大空闲时间（hash）
+// KEYS[5] 发布订阅的channel
+const mapCacheLuaPrelude = backend.NowMillisLua + `
+	-- 发布事件，元素 key 以十六进制编码，避免其中的 ':' 影响消息解析
+	local function notify(prefix, event, field)
+		local encoded = string.gsub(field, '.', function(c)
+			return string.format('%02x', string.byte(c))
+		end)
+		redis.call('publish',KEYS[5],prefix .. event .. '.' .. encoded)
+	end
+
+	local function isExpired(field, now)
+		local ttl = redis.call('zscore',KEYS[2],field)
+		if ttl ~= false and tonumber(ttl) <= now then
+			return true
+		end
+		local idle = redis.call('zscore',KEYS[3],field)
+		return idle ~= false and tonumber(idle) <= now
+	end
+
+	local function removeEntry(field)
+		redis.call('hdel',KEYS[1],field)
+		redis.call('zrem',KEYS[2],field)
+		redis.call('zrem',KEYS[3],field)
+		redis.call('hdel',KEYS[4],field)
+	end
+
+	-- 读取元素，已过期时清理并发布过期事件，返回 false
+	local function load(field, now, prefix)
+		local value = redis.call('hget',KEYS[1],field)
+		if value ~= false and isExpired(field, now) then
+			removeEntry(field)
+			notify(prefix, 'expired', field)
+			return false
+		end
+		return value
+	end
+
+	-- 延长空闲过期时间
+	local function touch(field, now)
+		local maxIdle = redis.call('hget',KEYS[4],field)
+		if maxIdle ~= false then
+			redis.call('zadd',KEYS[3],now + tonumber(maxIdle),field)
+		end
+	end
+
+	local function store(field, value, ttl, maxIdle, now)
+		redis.call('hset',KEYS[1],field,value)
+		if ttl > 0 then
+			redis.call('zadd',KEYS[2],now + ttl,field)
+		else
+			redis.call('zrem',KEYS[2],field)
+		end
+		if maxIdle > 0 then
+			redis.call('hset',KEYS[4],field,maxIdle)
+			redis.call('zadd',KEYS[3],now + maxIdle,field)
+		else
+			redis.call('hdel',KEYS[4],field)
+			redis.call('zrem',KEYS[3],field)
+		end
+	end
+
+	-- 清理最多 limit 个已过期的元素，limit 小于 0 时不限制，返回清理的数量
+	local function evict(now, limit, prefix)
+		local n = 0
+		for _, key in ipairs({KEYS[2], KEYS[3]}) do
+			local fields
+			if limit < 0 then
+				fields = redis.call('zrangebyscore',key,'-inf',now)
+			elseif n < limit then
+				fields = redis.call('zrangebyscore',key,'-inf',now,'limit',0,limit - n)
+			else
+				break
+			end
+			for _, field in ipairs(fields) do
+				removeEntry(field)
+				notify(prefix, 'expired', field)
+				n = n + 1
+			end
+		end
+		return n
+	end
+`
+
+func init() {
+	mapCacheScript.getScript = backend.NewScript("mapCache.get", mapCacheLuaPrelude+`
+	-- ARGV[1] key
+	-- ARGV[2] 事件消息前缀
+	local now = nowMillis()
+	local value = load(ARGV[1], now, ARGV[2])
+	if value ~= false then
+		touch(ARGV[1], now)
+	end
+	return value
+`)
+
+	mapCacheScript.putScript = backend.NewScript("mapCache.put", mapCacheLuaPrelude+`
+	-- ARGV[1] key
+	-- ARGV[2] 值
+	-- ARGV[3] 过期时间，单位：ms，0 表示不过期
+	-- ARGV[4] 最大空闲时间，单位：ms，0 表示不限制
+	-- ARGV[5] 事件消息前缀
+	-- ARGV[6] 1-返回旧值 0-返回是否为新增
+	local now = nowMillis()
+	local old = load(ARGV[1], now, ARGV[5])
+	store(ARGV[1], ARGV[2], tonumber(ARGV[3]), tonumber(ARGV[4]), now)
+	if old == false then
+		notify(ARGV[5], 'created', ARGV[1])
+	else
+		notify(ARGV[5], 'updated', ARGV[1])
+	end
+	if ARGV[6] == '1' then
+		return old
+	end
+	return old == false and 1 or 0
+`)
+
+	mapCacheScript.putIfAbsentScript = backend.NewScript("mapCache.putIfAbsent", mapCacheLuaPrelude+`
+	-- ARGV[1] key
+	-- ARGV[2] 值
+	-- ARGV[3] 过期时间，单位：ms，0 表示不过期
+	-- ARGV[4] 最大空闲时间，单位：ms，0 表示不限制
+	-- ARGV[5] 事件消息前缀
+	local now = nowMillis()
+	if load(ARGV[1], now, ARGV[5]) ~= false then
+		return 0
+	end
+	store(ARGV[1], ARGV[2], tonumber(ARGV[3]), tonumber(ARGV[4]), now)
+	notify(ARGV[5], 'created', ARGV[1])
+	return 1
+`)
+
+	mapCacheScript.removeScript = backend.NewScript("mapCache.remove", mapCacheLuaPrelude+`
+	-- ARGV[1] key
+	-- ARGV[2] 事件消息前缀
+	local old = load(ARGV[1], nowMillis(), ARGV[2])
+	if old ~= false then
+		removeEntry(ARGV[1])
+		notify(ARGV[2], 'removed', ARGV[1])
+	end
+	return old
+`)
+
+	mapCacheScript.containsKeyScript = backend.NewScript("mapCache.containsKey", mapCacheLuaPrelude+`
+	-- ARGV[1] key
+	-- ARGV[2] 事件消息前缀
+	local now = nowMillis()
+	if load(ARGV[1], now, ARGV[2]) == false then
+		return 0
+	end
+	touch(ARGV[1], now)
+	return 1
+`)
+
+	mapCacheScript.sizeScript = backend.NewScript("mapCache.size", mapCacheLuaPrelude+`
+	-- ARGV[1] 事件消息前缀
+	evict(nowMillis(), -1, ARGV[1])
+	return redis.call('hlen',KEYS[1])
+`)
+
+	mapCacheScript.evictScript = backend.NewScript("mapCache.evict", mapCacheLuaPrelude+`
+	-- ARGV[1] 最多清理的数量
+	-- ARGV[2] 事件消息前缀
+	return evict(nowMillis(), tonumber(ARGV[1]), ARGV[2])
+`)
+}
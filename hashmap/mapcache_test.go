@@ -0,0 +1,180 @@
+package hashmap_test
+
+import (
+	"context"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+
+	"github.com/MaricoHan/redisson"
+	"github.com/MaricoHan/redisson/hashmap"
+	"github.com/MaricoHan/redisson/mutex"
+	"github.com/MaricoHan/redisson/pkg/clock"
+	"github.com/MaricoHan/redisson/pkg/loggers"
+)
+
+func TestMapCache(t *testing.T) {
+	client := redis.NewClient(&redis.Options{Addr: ":6379"})
+	r := redisson.New(context.Background(), client)
+	ctx := context.Background()
+
+	m := r.NewMapCache("mapCacheKey")
+	defer m.Close()
+	_, _ = m.Delete(ctx)
+
+	events := m.Subscribe()
+	defer events.Close()
+
+	if created, err := m.FastPut(ctx, "a:1", user{Name: "a"}, 0, 0); err != nil || !created {
+		t.Errorf("fast put failed: %v", err)
+		return
+	}
+	var old user
+	if existed, err := m.Put(ctx, "a:1", user{Name: "b"}, 0, 0, &old); err != nil || !existed || old.Name != "a" {
+		t.Errorf("unexpected old value: %+v, %v", old, err)
+		return
+	}
+	if ok, _ := m.PutIfAbsent(ctx, "a:1", user{}, 0, 0); ok {
+		t.Error("value should not be overwritten")
+		return
+	}
+	if ok, err := m.Remove(ctx, "a:1", &old); err != nil || !ok || old.Name != "b" {
+		t.Errorf("remove failed: %+v, %v", old, err)
+		return
+	}
+
+	// 测试：事件通知，key 中的 ':' 不影响解析；同一进程中的每个实例都会转发消息，事件可能重复
+	received := make(map[hashmap.EventType]bool)
+	timeout := time.After(time.Second)
+	for len(received) < 3 {
+		select {
+		case e := <-events.Channel():
+			if e.Key != "a:1" {
+				t.Errorf("unexpected event: %+v", e)
+				return
+			}
+			received[e.Type] = true
+		case <-timeout:
+			t.Errorf("events not received: %v", received)
+			return
+		}
+	}
+	if !received[hashmap.EventCreated] || !received[hashmap.EventUpdated] || !received[hashmap.EventRemoved] {
+		t.Errorf("unexpected events: %v", received)
+		return
+	}
+
+	// 测试：过期时间
+	if _, err := m.FastPut(ctx, "ttl", user{}, 100*time.Millisecond, 0); err != nil {
+		t.Error(err)
+		return
+	}
+	if ok, _ := m.ContainsKey(ctx, "ttl"); !ok {
+		t.Error("entry should exist")
+		return
+	}
+	time.Sleep(150 * time.Millisecond)
+	var u user
+	if ok, err := m.Get(ctx, "ttl", &u); err != nil || ok {
+		t.Errorf("entry should be expired: %v", err)
+		return
+	}
+	if n, _ := client.HLen(ctx, "mapCacheKey").Result(); n != 0 {
+		t.Errorf("expired entry should be removed on read: %d", n)
+		return
+	}
+
+	// 测试：最大空闲时间，读取后重新计时
+	if _, err := m.FastPut(ctx, "idle", user{}, 0, 200*time.Millisecond); err != nil {
+		t.Error(err)
+		return
+	}
+	for i := 0; i < 3; i++ {
+		time.Sleep(100 * time.Millisecond)
+		if ok, _ := m.Get(ctx, "idle", &u); !ok {
+			t.Error("entry read recently should not be expired")
+			return
+		}
+	}
+	time.Sleep(250 * time.Millisecond)
+	if size, _ := m.Size(ctx); size != 0 {
+		t.Errorf("idle entry should be expired: %d", size)
+		return
+	}
+
+	runtime.KeepAlive(r)
+}
+
+func TestMapCache_Eviction(t *testing.T) {
+	client := redis.NewClient(&redis.Options{Addr: ":6379"})
+	client.Del(context.Background(), "mapCacheEvictKey", "{mapCacheEvictKey}:ttl", "{mapCacheEvictKey}:idle", "{mapCacheEvictKey}:maxidle")
+	fake := clock.NewFake(time.Now())
+	r := redisson.NewWithConfig(context.Background(), client, &redisson.Config{Clock: fake})
+	ctx := context.Background()
+
+	m := r.NewMapCache("mapCacheEvictKey")
+	defer m.Close()
+	events := m.Subscribe()
+	defer events.Close()
+
+	for _, key := range []string{"a", "b", "c"} {
+		if _, err := m.FastPut(ctx, key, key, 50*time.Millisecond, 0); err != nil {
+			t.Error(err)
+			return
+		}
+	}
+	if _, err := m.FastPut(ctx, "d", "d", 0, 0); err != nil {
+		t.Error(err)
+		return
+	}
+	time.Sleep(100 * time.Millisecond)
+
+	// 推进假时钟，触发后台清理
+	fake.BlockUntil(1)
+	fake.Advance(5 * time.Second)
+
+	expired := make(map[string]bool)
+	timeout := time.After(2 * time.Second)
+	for len(expired) < 3 {
+		select {
+		case e := <-events.Channel():
+			if e.Type == hashmap.EventExpired {
+				expired[e.Key] = true
+			}
+		case <-timeout:
+			t.Errorf("expired events not received: %v", expired)
+			return
+		}
+	}
+	if n, _ := client.HLen(ctx, "mapCacheEvictKey").Result(); n != 1 {
+		t.Errorf("expired entries should be evicted in background: %d", n)
+	}
+
+	runtime.KeepAlive(r)
+}
+
+// TestMapCache_NilClock
+// @Description: 测试：未设置时钟的 Root 使用真实时间，登记过期清理时不会 panic
+// @param t
+func TestMapCache_NilClock(t *testing.T) {
+	root := &mutex.Root{
+		Client: redis.NewClient(&redis.Options{Addr: ":6379"}),
+		UUID:   "uuid",
+		Logger: loggers.Logger(),
+	}
+	scheduler := hashmap.NewEvictionScheduler(root)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go scheduler.Run(ctx)
+
+	m := hashmap.NewMapCache(root, scheduler, "mapCacheNilClockKey")
+	defer m.Close()
+	if _, err := m.FastPut(context.Background(), "a", "a", time.Minute, 0); err != nil {
+		t.Error(err)
+		return
+	}
+
+	root.Client.Del(context.Background(), "mapCacheNilClockKey", "{mapCacheNilClockKey}:ttl", "{mapCacheNilClockKey}:idle", "{mapCacheNilClockKey}:maxidle")
+}
@@ -0,0 +1,214 @@
+package mutex
+
+import (
+	"github.com/MaricoHan/redisson/pkg/backend/memory"
+)
+
+// 以下为锁脚本在内存实现中的 Go 版本，逻辑与对应的 lua 脚本逐行一致，修改脚本时需要同步修改
+
+// registerMutexMemoryScripts 在互斥锁脚本创建后调用
+func registerMutexMemoryScripts() {
+	memory.RegisterScript(mutexScript.lockScript.Name, func(s *memory.Store, keys []string, args []string) interface{} {
+		if !s.Exists(keys[0]) {
+			s.Set(keys[0], args[0])
+			s.PExpire(keys[0], memory.Int(args[1]))
+			return nil
+		}
+		return s.PTTL(keys[0])
+	})
+
+	memory.RegisterScript(mutexScript.renewalScript.Name, func(s *memory.Store, keys []string, args []string) interface{} {
+		if v, ok := s.Get(keys[0]); ok && v == args[1] {
+			return boolToInt(s.PExpire(keys[0], memory.Int(args[0])))
+		}
+		return int64(0)
+	})
+
+	memory.RegisterScript(mutexScript.unlockScript.Name, func(s *memory.Store, keys []string, args []string) interface{} {
+		if s.Exists(keys[0]) {
+			if v, _ := s.Get(keys[0]); v != args[0] {
+				return int64(0)
+			}
+			s.Del(keys[0])
+		}
+		s.Publish(keys[1], args[1])
+		return int64(1)
+	})
+
+	memory.RegisterScript(mutexScript.forceUnlockScript.Name, func(s *memory.Store, keys []string, args []string) interface{} {
+		n := s.Del(keys[0])
+		s.Publish(keys[1], args[0])
+		return n
+	})
+}
+
+// registerRWMutexMemoryScripts 在读写锁脚本创建后调用
+func registerRWMutexMemoryScripts() {
+	memory.RegisterScript(rwMutexScript.lockScript.Name, func(s *memory.Store, keys []string, args []string) interface{} {
+		now := s.NowMillis()
+		pruneReaders(s, keys[0], keys[2], now)
+		if !s.Exists(keys[0]) {
+			s.Set(keys[0], args[0])
+			s.PExpire(keys[0], memory.Int(args[1]))
+			s.ZRem(keys[1], args[0])
+			return nil
+		}
+		if args[2] == "1" {
+			// 登记写意向
+			s.ZAdd(keys[1], float64(now+memory.Int(args[1])), args[0])
+			if s.PTTL(keys[1]) < memory.Int(args[1]) {
+				s.PExpire(keys[1], memory.Int(args[1]))
+			}
+		}
+		return s.PTTL(keys[0])
+	})
+
+	memory.RegisterScript(rwMutexScript.rLockScript.Name, func(s *memory.Store, keys []string, args []string) interface{} {
+		if s.Type(keys[0]) == "string" {
+			return s.PTTL(keys[0])
+		}
+		now := s.NowMillis()
+		pruneReaders(s, keys[0], keys[2], now)
+		// 有写者在等待时，新的读者排在写者之后；已持有读锁的协程可以重入
+		if s.Exists(keys[1]) && !s.HExists(keys[0], args[0]) {
+			s.ZRemRangeByScore(keys[1], float64(now))
+			if s.ZCard(keys[1]) > 0 {
+				pTTL := s.PTTL(keys[0])
+				if pTTL <= 0 {
+					pTTL = s.PTTL(keys[1])
+				}
+				if pTTL < 1 {
+					pTTL = 1
+				}
+				return pTTL
+			}
+		}
+		s.HIncrBy(keys[0], args[0], 1)
+		s.ZAdd(keys[2], float64(now+memory.Int(args[1])), args[0])
+		expireByLeases(s, keys[0], keys[2], now)
+		return nil
+	})
+
+	memory.RegisterScript(rwMutexScript.renewalScript.Name, func(s *memory.Store, keys []string, args []string) interface{} {
+		switch s.Type(keys[0]) {
+		case "string":
+			if v, _ := s.Get(keys[0]); v == args[1] {
+				return boolToInt(s.PExpire(keys[0], memory.Int(args[0])))
+			}
+			return int64(0)
+		case "hash":
+			now := s.NowMillis()
+			pruneReaders(s, keys[0], keys[1], now)
+			if !s.HExists(keys[0], args[1]) {
+				return int64(0)
+			}
+			// 只延长自己的租约，其他读者的租约不受影响
+			s.ZAdd(keys[1], float64(now+memory.Int(args[0])), args[1])
+			expireByLeases(s, keys[0], keys[1], now)
+			return int64(1)
+		default:
+			return int64(0)
+		}
+	})
+
+	memory.RegisterScript(rwMutexScript.unlockScript.Name, func(s *memory.Store, keys []string, args []string) interface{} {
+		switch s.Type(keys[0]) {
+		case "hash":
+			if !s.HExists(keys[0], args[0]) {
+				return int64(0)
+			}
+			if s.HIncrBy(keys[0], args[0], -1) > 0 {
+				return int64(2)
+			}
+			s.HDel(keys[0], args[0])
+			s.ZRem(keys[2], args[0])
+			if s.HLen(keys[0]) > 0 {
+				return int64(2)
+			}
+			s.Del(keys[0], keys[2])
+			s.Publish(keys[1], args[1])
+			return int64(1)
+		case "none":
+			s.Publish(keys[1], args[1])
+			return int64(1)
+		}
+		if v, _ := s.Get(keys[0]); v == args[0] {
+			s.Del(keys[0])
+			s.Publish(keys[1], args[1])
+			return int64(1)
+		}
+		return int64(0)
+	})
+
+	memory.RegisterScript(rwMutexScript.forceUnlockScript.Name, func(s *memory.Store, keys []string, args []string) interface{} {
+		n := s.Del(keys[0])
+		s.Del(keys[2])
+		s.Publish(keys[1], args[0])
+		return n
+	})
+
+	memory.RegisterScript(rwMutexScript.downgradeScript.Name, func(s *memory.Store, keys []string, args []string) interface{} {
+		if s.Type(keys[0]) != "string" {
+			return int64(0)
+		}
+		if v, _ := s.Get(keys[0]); v != args[0] {
+			return int64(0)
+		}
+		now := s.NowMillis()
+		s.Del(keys[0])
+		s.HSet(keys[0], args[0], "1")
+		s.ZAdd(keys[2], float64(now+memory.Int(args[1])), args[0])
+		expireByLeases(s, keys[0], keys[2], now)
+		s.Publish(keys[1], args[2])
+		return int64(1)
+	})
+
+	memory.RegisterScript(rwMutexScript.upgradeScript.Name, func(s *memory.Store, keys []string, args []string) interface{} {
+		pruneReaders(s, keys[0], keys[1], s.NowMillis())
+		if s.Type(keys[0]) != "hash" || !s.HExists(keys[0], args[0]) {
+			return int64(0)
+		}
+		if v, _ := s.HGet(keys[0], args[0]); s.HLen(keys[0]) > 1 || memory.Int(v) > 1 {
+			return int64(2)
+		}
+		s.Del(keys[0], keys[1])
+		s.Set(keys[0], args[0])
+		s.PExpire(keys[0], memory.Int(args[1]))
+		return int64(1)
+	})
+
+	memory.RegisterScript(rwMutexScript.withdrawScript.Name, func(s *memory.Store, keys []string, args []string) interface{} {
+		return s.ZRem(keys[0], args[0])
+	})
+}
+
+// pruneReaders 对应 rwMutexLuaPrelude 中的同名函数
+func pruneReaders(s *memory.Store, lockKey, leaseKey string, now int64) {
+	if s.Type(lockKey) == "hash" {
+		for _, id := range s.ZRangeByScore(leaseKey, float64(now)) {
+			s.HDel(lockKey, id)
+		}
+	}
+	s.ZRemRangeByScore(leaseKey, float64(now))
+}
+
+// expireByLeases 对应 rwMutexLuaPrelude 中的同名函数
+func expireByLeases(s *memory.Store, lockKey, leaseKey string, now int64) {
+	leases := s.ZRange(leaseKey)
+	if len(leases) == 0 {
+		return
+	}
+	pTTL := int64(leases[len(leases)-1].Score) - now
+	if pTTL < 1 {
+		pTTL = 1
+	}
+	s.PExpire(lockKey, pTTL)
+	s.PExpire(leaseKey, pTTL)
+}
+
+func boolToInt(b bool) int64 {
+	if b {
+		return 1
+	}
+	return 0
+}
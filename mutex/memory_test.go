@@ -0,0 +1,145 @@
+package mutex
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/go-redis/redis/v8"
+
+	"github.com/MaricoHan/redisson/pkg/backend"
+	"github.com/MaricoHan/redisson/pkg/backend/memory"
+	"github.com/MaricoHan/redisson/pkg/loggers"
+)
+
+// 脚本调用中的 key，执行时替换为各用例独立的 key
+const (
+	lockKey = iota
+	intentKey
+	leaseKey
+	channelKey
+)
+
+type scriptCall struct {
+	script func() *backend.Script
+	keys   []int
+	args   []interface{}
+}
+
+// TestMemoryScripts_Parity
+// @Description: 测试：锁脚本的 Go 版本与 lua 脚本对同样的调用序列返回相同的结果，锁的 key 状态一致
+// @param t
+func TestMemoryScripts_Parity(t *testing.T) {
+	var (
+		lock = func(id string, writerPreferred int) scriptCall {
+			return scriptCall{func() *backend.Script { return rwMutexScript.lockScript }, []int{lockKey, intentKey, leaseKey}, []interface{}{id, 10000, writerPreferred}}
+		}
+		rLock = func(id string) scriptCall {
+			return scriptCall{func() *backend.Script { return rwMutexScript.rLockScript }, []int{lockKey, intentKey, leaseKey}, []interface{}{id, 10000}}
+		}
+		rwRenewal = func(id string) scriptCall {
+			return scriptCall{func() *backend.Script { return rwMutexScript.renewalScript }, []int{lockKey, leaseKey}, []interface{}{10000, id}}
+		}
+		rwUnlock = func(id string) scriptCall {
+			return scriptCall{func() *backend.Script { return rwMutexScript.unlockScript }, []int{lockKey, channelKey, leaseKey}, []interface{}{id, "unlock"}}
+		}
+		rwForceUnlock = scriptCall{func() *backend.Script { return rwMutexScript.forceUnlockScript }, []int{lockKey, channelKey, leaseKey}, []interface{}{"unlock"}}
+		downgrade     = func(id string) scriptCall {
+			return scriptCall{func() *backend.Script { return rwMutexScript.downgradeScript }, []int{lockKey, channelKey, leaseKey}, []interface{}{id, 10000, "downgrade"}}
+		}
+		upgrade = func(id string) scriptCall {
+			return scriptCall{func() *backend.Script { return rwMutexScript.upgradeScript }, []int{lockKey, leaseKey}, []interface{}{id, 10000}}
+		}
+		withdraw = func(id string) scriptCall {
+			return scriptCall{func() *backend.Script { return rwMutexScript.withdrawScript }, []int{intentKey}, []interface{}{id}}
+		}
+
+		mLock = func(id string) scriptCall {
+			return scriptCall{func() *backend.Script { return mutexScript.lockScript }, []int{lockKey}, []interface{}{id, 10000}}
+		}
+		mRenewal = func(id string) scriptCall {
+			return scriptCall{func() *backend.Script { return mutexScript.renewalScript }, []int{lockKey}, []interface{}{10000, id}}
+		}
+		mUnlock = func(id string) scriptCall {
+			return scriptCall{func() *backend.Script { return mutexScript.unlockScript }, []int{lockKey, channelKey}, []interface{}{id, "unlock"}}
+		}
+		mForceUnlock = scriptCall{func() *backend.Script { return mutexScript.forceUnlockScript }, []int{lockKey, channelKey}, []interface{}{"unlock"}}
+	)
+
+	cases := []struct {
+		name  string
+		calls []scriptCall
+	}{
+		{"mutex", []scriptCall{
+			mLock("a"), mLock("b"), mRenewal("a"), mRenewal("b"), mUnlock("b"), mUnlock("a"), mUnlock("a"),
+			mForceUnlock, mLock("a"), mForceUnlock, mRenewal("a"),
+		}},
+		{"rwmutex_write_read", []scriptCall{
+			lock("a", 0), rLock("b"), rwRenewal("a"), rwRenewal("b"), rwUnlock("b"), rwUnlock("a"),
+			rLock("b"), rLock("c"), rLock("b"), lock("a", 0), rwRenewal("b"), upgrade("b"),
+			rwUnlock("c"), rwUnlock("b"), upgrade("b"), rwUnlock("b"), rwUnlock("b"),
+		}},
+		{"rwmutex_writer_preferred", []scriptCall{
+			rLock("b"), lock("a", 1), rLock("c"), rLock("b"), withdraw("a"), rLock("c"),
+			rwUnlock("b"), rwUnlock("b"), rwUnlock("c"), lock("a", 1),
+		}},
+		{"rwmutex_downgrade", []scriptCall{
+			lock("a", 0), downgrade("b"), downgrade("a"), rLock("c"), upgrade("a"), rwUnlock("c"), upgrade("a"),
+			downgrade("a"), rwForceUnlock, rwForceUnlock, rwUnlock("a"),
+		}},
+	}
+
+	client := redis.NewClient(&redis.Options{Addr: ":6379"})
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			keys := []string{"parity:" + c.name, "parity:" + c.name + ":intent", "parity:" + c.name + ":leases", "parity:" + c.name + ":channel"}
+			client.Del(context.Background(), keys...)
+			defer client.Del(context.Background(), keys...)
+
+			redisSteps := runCalls(backend.NewRedis(client, loggers.Logger()), c.calls, keys)
+			memorySteps := runCalls(memory.New(), c.calls, keys)
+			for i := range redisSteps {
+				if redisSteps[i] != memorySteps[i] {
+					t.Errorf("step %d (%s): lua: %s, go: %s", i, c.calls[i].script().Name, redisSteps[i], memorySteps[i])
+				}
+			}
+		})
+	}
+}
+
+// runCalls 依次执行脚本，返回每一步的结果以及执行后锁的 key 的状态
+func runCalls(b backend.Backend, calls []scriptCall, keys []string) []string {
+	ctx := context.Background()
+	steps := make([]string, 0, len(calls))
+	for _, call := range calls {
+		callKeys := make([]string, 0, len(call.keys))
+		for _, k := range call.keys {
+			callKeys = append(callKeys, keys[k])
+		}
+
+		var res string
+		val, err := b.Eval(ctx, call.script(), callKeys, call.args...).Result()
+		switch {
+		case err == redis.Nil:
+			res = "nil"
+		case err != nil:
+			res = "error"
+		default:
+			res = normalize(val)
+		}
+
+		lockTTL, _ := b.PTTL(ctx, keys[lockKey])
+		leases, _ := b.Exists(ctx, keys[leaseKey])
+		intent, _ := b.Exists(ctx, keys[intentKey])
+		steps = append(steps, fmt.Sprintf("%s lock=%s leases=%v intent=%v", res, normalize(int64(lockTTL)), leases, intent))
+	}
+	return steps
+}
+
+// normalize 把剩余过期时间统一为 "ttl"，只比较是否存在过期时间，不比较具体的毫秒数
+func normalize(v interface{}) string {
+	if n, ok := v.(int64); ok && n > 2 {
+		return "ttl"
+	}
+	return fmt.Sprint(v)
+}
@@ -9,20 +9,17 @@ import (
 
 	"github.com/go-redis/redis/v8"
 
+	"github.com/MaricoHan/redisson/pkg/backend"
 	"github.com/MaricoHan/redisson/pkg/types"
 	"github.com/MaricoHan/redisson/pkg/utils"
 	"github.com/MaricoHan/redisson/pkg/utils/pubsub"
 )
 
 var mutexScript = struct {
-	lockScript    string
-	lockScriptSha string
-
-	renewalScript    string
-	renewalScriptSha string
-
-	unlockScript    string
-	unlockScriptSha string
+	lockScript        *backend.Script
+	renewalScript     *backend.Script
+	unlockScript      *backend.Script
+	forceUnlockScript *backend.Script
 }{}
 
 type Mutex struct {
@@ -33,6 +30,7 @@ type Mutex struct {
 func NewMutex(root *Root, name string, opts ...Option) *Mutex {
 	base := &baseMutex{
 		Name:    name,
+		key:     root.Key(name),
 		release: make(chan struct{}),
 		options: &options{},
 	}
@@ -57,14 +55,11 @@ func (m *Mutex) Lock(ctx context.Context) error {
 
 	m.root.Logger.Debugf("尝试获取互斥锁: %s, 过期时间: %dms", m.Name, pExpireNum)
 
-	ctx, cancel := context.WithTimeout(ctx, m.options.waitTimeout)
-	defer cancel()
-
 	var err error
 	// 先订阅，再申请锁
 	if m.pubSub == nil {
-		m.pubSub = pubsub.Subscribe(utils.ChannelName(m.Name))
-		m.root.Logger.Debugf("订阅锁通道: %s", utils.ChannelName(m.Name))
+		m.pubSub = pubsub.Subscribe(utils.ChannelName(m.key), pubsub.WithClock(m.root.clock()))
+		m.root.Logger.Debugf("订阅锁通道: %s", utils.ChannelName(m.key))
 	}
 
 	// 申请锁
@@ -82,28 +77,18 @@ func (m *Mutex) Lock(ctx context.Context) error {
 	go func() {
 		wg.Done()
 
-		ticker := time.NewTicker(m.options.expiration / 3)
+		ticker := m.root.clock().NewTicker(m.options.expiration / 3)
 		defer ticker.Stop()
 
 		m.root.Logger.Debugf("启动互斥锁续期协程: %s, 续期间隔: %v", m.Name, m.options.expiration/3)
 
-		// 上传脚本
-		if mutexScript.renewalScriptSha == "" {
-			mutexScript.renewalScriptSha, err = m.root.Client.ScriptLoad(ctx, mutexScript.renewalScript).Result()
-			if err != nil {
-				m.root.Logger.Errorf("加载互斥锁续期脚本失败: %v", err)
-				return
-			}
-			m.root.Logger.Debugf("加载互斥锁续期脚本成功: %s", mutexScript.renewalScriptSha)
-		}
-
 		for {
 			select {
 			case <-m.release:
 				m.root.Logger.Debugf("互斥锁续期协程收到退出信号: %s", m.Name)
 				return
-			case <-ticker.C:
-				res, err := m.root.Client.EvalSha(context.TODO(), mutexScript.renewalScriptSha, []string{m.Name}, pExpireNum, clientID).Int64()
+			case <-ticker.C():
+				res, err := m.root.backend().Eval(context.TODO(), mutexScript.renewalScript, []string{m.key}, pExpireNum, clientID).Int64()
 				if err != nil {
 					m.root.Logger.Errorf("互斥锁续期失败: %s, 错误: %v", m.Name, err)
 					return
@@ -122,50 +107,14 @@ func (m *Mutex) Lock(ctx context.Context) error {
 }
 
 func (m *Mutex) tryLock(ctx context.Context, clientID string, pExpireNum int64) error {
-	// 尝试加锁
 	m.root.Logger.Debugf("尝试获取互斥锁: %s, 客户端ID: %s", m.Name, clientID)
-	pTTL, err := m.lockInner(ctx, clientID, pExpireNum)
-	if err != nil {
-		m.root.Logger.Errorf("获取互斥锁内部操作失败: %s, 错误: %v", m.Name, err)
-		return err
-	}
-	if pTTL == 0 {
-		m.root.Logger.Debugf("成功获取互斥锁: %s", m.Name)
-		return nil
-	}
-
-	m.root.Logger.Debugf("互斥锁已被占用: %s, TTL: %dms, 等待解锁或过期", m.Name, pTTL)
-
-	select {
-	case <-ctx.Done():
-		// 申请锁的耗时如果大于等于最大等待时间，则申请锁失败.
-		m.root.Logger.Warnf("获取互斥锁等待超时: %s", m.Name)
-		return types.ErrWaitTimeout
-	case <-time.After(time.Duration(pTTL) * time.Millisecond):
-		// 针对"redis 中存在未维护的锁"，即当锁自然过期后，并不会发布通知的锁
-		m.root.Logger.Debugf("互斥锁等待过期后重试: %s", m.Name)
-		return m.tryLock(ctx, clientID, pExpireNum)
-	case <-m.pubSub.Channel():
-		// 收到解锁通知，则尝试抢锁
-		m.root.Logger.Debugf("收到互斥锁解锁通知，尝试获取: %s", m.Name)
-		return m.tryLock(ctx, clientID, pExpireNum)
-	}
+	return m.waitLock(ctx, m.root, "互斥锁", func(ctx context.Context) (int64, error) {
+		return m.lockInner(ctx, clientID, pExpireNum)
+	}, 0)
 }
 
 func (m *Mutex) lockInner(ctx context.Context, clientID string, pExpireNum int64) (int64, error) {
-	// 上传脚本
-	if mutexScript.lockScriptSha == "" {
-		var err error
-		m.root.Logger.Debugf("加载互斥锁获取脚本")
-		mutexScript.lockScriptSha, err = m.root.Client.ScriptLoad(ctx, mutexScript.lockScript).Result()
-		if err != nil {
-			m.root.Logger.Errorf("加载互斥锁获取脚本失败: %v", err)
-			return 0, fmt.Errorf("load lock script err: %w", err)
-		}
-		m.root.Logger.Debugf("加载互斥锁获取脚本成功: %s", mutexScript.lockScriptSha)
-	}
-
-	pTTL, err := m.root.Client.EvalSha(ctx, mutexScript.lockScriptSha, []string{m.Name}, clientID, pExpireNum).Result()
+	pTTL, err := m.root.backend().Eval(ctx, mutexScript.lockScript, []string{m.key}, clientID, pExpireNum).Result()
 	if err == redis.Nil {
 		m.root.Logger.Debugf("互斥锁获取成功: %s", m.Name)
 		return 0, nil
@@ -197,24 +146,12 @@ func (m *Mutex) Unlock(ctx context.Context) error {
 func (m *Mutex) unlockInner(ctx context.Context, goID int64) error {
 	clientID := m.root.UUID + ":" + strconv.FormatInt(goID, 10)
 
-	// 上传脚本
-	if mutexScript.unlockScriptSha == "" {
-		var err error
-		m.root.Logger.Debugf("加载互斥锁释放脚本")
-		mutexScript.unlockScriptSha, err = m.root.Client.ScriptLoad(ctx, mutexScript.unlockScript).Result()
-		if err != nil {
-			m.root.Logger.Errorf("加载互斥锁释放脚本失败: %v", err)
-			return fmt.Errorf("load unlock script err: %w", err)
-		}
-		m.root.Logger.Debugf("加载互斥锁释放脚本成功: %s", mutexScript.unlockScriptSha)
-	}
-
-	res, err := m.root.Client.EvalSha(
+	res, err := m.root.backend().Eval(
 		ctx,
-		mutexScript.unlockScriptSha,
-		[]string{m.Name, m.root.RedisChannelName},
+		mutexScript.unlockScript,
+		[]string{m.key, m.root.RedisChannelName},
 		clientID,
-		m.Name+":unlock",
+		m.key+":unlock",
 	).Int64()
 	if err != nil {
 		m.root.Logger.Errorf("执行互斥锁释放脚本失败: %v", err)
@@ -233,8 +170,41 @@ func (m *Mutex) unlockInner(ctx context.Context, goID int64) error {
 	return nil
 }
 
+// IsLocked 查询锁当前是否被持有
+func (m *Mutex) IsLocked(ctx context.Context) (bool, error) {
+	locked, err := m.root.backend().Exists(ctx, m.key)
+	if err != nil {
+		m.root.Logger.Errorf("查询互斥锁状态失败: %s, 错误: %v", m.Name, err)
+		return false, err
+	}
+	return locked, nil
+}
+
+// RemainTTL 查询锁的剩余过期时间，锁不存在时返回 0
+func (m *Mutex) RemainTTL(ctx context.Context) (time.Duration, error) {
+	return m.root.RemainTTL(ctx, m.key)
+}
+
+// ForceUnlock 不校验持有者，强制释放锁并发布解锁通知，返回锁在释放前是否存在
+func (m *Mutex) ForceUnlock(ctx context.Context) (bool, error) {
+	m.root.Logger.Warnf("强制释放互斥锁: %s", m.Name)
+
+	res, err := m.root.backend().Eval(
+		ctx,
+		mutexScript.forceUnlockScript,
+		[]string{m.key, m.root.RedisChannelName},
+		m.key+":unlock",
+	).Int64()
+	if err != nil {
+		m.root.Logger.Errorf("执行互斥锁强制释放脚本失败: %v", err)
+		return false, err
+	}
+
+	return res == 1, nil
+}
+
 func init() {
-	mutexScript.lockScript = `
+	mutexScript.lockScript = backend.NewScript("mutex.lock", `
 	-- KEYS[1] 锁名
 	-- ARGV[1] 协程唯一标识：客户端标识+协程ID
 	-- ARGV[2] 过期时间
@@ -244,9 +214,9 @@ func init() {
 		return nil
 	end
 	return redis.call('pttl',KEYS[1])
-`
+`)
 
-	mutexScript.renewalScript = `
+	mutexScript.renewalScript = backend.NewScript("mutex.renewal", `
 	-- KEYS[1] 锁名
 	-- ARGV[1] 过期时间
 	-- ARGV[2] 客户端协程唯一标识
@@ -254,9 +224,9 @@ func init() {
 		return redis.call('pexpire',KEYS[1],ARGV[1])
 	end
 	return 0
-`
+`)
 
-	mutexScript.unlockScript = `
+	mutexScript.unlockScript = backend.NewScript("mutex.unlock", `
 	-- KEYS[1] 锁名
 	-- KEYS[2] 发布订阅的channel
 	-- ARGV[1] 协程唯一标识：客户端标识+协程ID
@@ -270,5 +240,16 @@ func init() {
 	end
 	redis.call('publish',KEYS[2],ARGV[2])
 	return 1
-`
+`)
+
+	mutexScript.forceUnlockScript = backend.NewScript("mutex.forceUnlock", `
+	-- KEYS[1] 锁名
+	-- KEYS[2] 发布订阅的channel
+	-- ARGV[1] 解锁时发布的消息
+	local n = redis.call('del',KEYS[1])
+	redis.call('publish',KEYS[2],ARGV[1])
+	return n
+`)
+
+	registerMutexMemoryScripts()
 }
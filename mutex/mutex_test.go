@@ -2,6 +2,7 @@ package mutex
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"strconv"
 	"sync"
@@ -10,6 +11,8 @@ import (
 
 	"github.com/go-redis/redis/v8"
 
+	"github.com/MaricoHan/redisson/pkg/loggers"
+	"github.com/MaricoHan/redisson/pkg/types"
 	"github.com/MaricoHan/redisson/pkg/utils"
 )
 
@@ -17,6 +20,7 @@ var (
 	mutex = NewMutex(&Root{
 		Client: redis.NewClient(&redis.Options{Addr: ":6379"}),
 		UUID:   "uuid",
+		Logger: loggers.Logger(),
 	}, "mutexKey", []Option{
 		WithExpireDuration(10 * time.Second),
 		WithWaitTimeout(20 * time.Second),
@@ -153,8 +157,154 @@ func TestMutex_Renewal(t *testing.T) {
 	// 测试：达到过期时间的 1/3，如果未主动释放锁，锁的过期时间会被重置
 	ticker := time.Tick(time.Second)
 	for range ticker {
-		fmt.Println(mutex.root.Client.PTTL(context.Background(), mutex.Name).Val())
+		fmt.Println(mutex.root.Client.PTTL(context.Background(), mutex.key).Val())
 	}
 
 	time.After(2 * time.Minute)
 }
+
+// TestMutex_KeyPrefix
+// @Description: 测试：命名空间前缀会作用于锁的 key 与频道名
+// @param t
+func TestMutex_KeyPrefix(t *testing.T) {
+	root := &Root{
+		Client:    redis.NewClient(&redis.Options{Addr: ":6379"}),
+		UUID:      "uuid",
+		Logger:    loggers.Logger(),
+		KeyPrefix: "tenant:",
+	}
+	root.RedisChannelName = root.ChannelName("redisson_pubsub")
+	m := NewMutex(root, "prefixMutexKey")
+
+	if m.key != "tenant:prefixMutexKey" {
+		t.Errorf("unexpected key: %s", m.key)
+		return
+	}
+
+	err := m.Lock(context.Background())
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	locked, err := m.IsLocked(context.Background())
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if !locked {
+		t.Error("mutex should be locked")
+		return
+	}
+	if n := root.Client.Exists(context.Background(), "prefixMutexKey").Val(); n != 0 {
+		t.Error("lock key should be prefixed")
+		return
+	}
+
+	err = m.Unlock(context.Background())
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	t.Log("unlock successfully")
+}
+
+// TestMutex_Lock_Cancel
+// @Description: 测试：调用方取消与等待超时返回不同的错误；WaitForever 时只受调用方 ctx 控制
+// @param t
+func TestMutex_Lock_Cancel(t *testing.T) {
+	holder := NewMutex(mutex.root, "cancelMutexKey")
+	err := holder.Lock(context.Background())
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	t.Log("lock successfully")
+
+	waitGroup := sync.WaitGroup{}
+	waitGroup.Add(1)
+	go func() {
+		defer waitGroup.Done()
+
+		// 测试：等待超时
+		waiter := NewMutex(mutex.root, "cancelMutexKey", WithWaitTimeout(time.Second))
+		err := waiter.Lock(context.Background())
+		if !errors.Is(err, types.ErrWaitTimeout) || !errors.Is(err, context.DeadlineExceeded) {
+			t.Errorf("expect wait timeout, got: %v", err)
+		}
+
+		// 测试：调用方取消
+		waiter = NewMutex(mutex.root, "cancelMutexKey", WithWaitTimeout(WaitForever))
+		ctx, cancel := context.WithCancel(context.Background())
+		time.AfterFunc(time.Second, cancel)
+		err = waiter.Lock(ctx)
+		if !errors.Is(err, types.ErrWaitCanceled) || !errors.Is(err, context.Canceled) {
+			t.Errorf("expect wait canceled, got: %v", err)
+		}
+	}()
+	waitGroup.Wait()
+
+	err = holder.Unlock(context.Background())
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	t.Log("unlock successfully")
+}
+
+// TestMutex_WaitStrategy
+// @Description: 测试：未收到解锁通知时，按重试策略轮询，无需等到锁过期
+// @param t
+func TestMutex_WaitStrategy(t *testing.T) {
+	holder := NewMutex(mutex.root, "waitStrategyMutexKey", WithExpireDuration(10*time.Second))
+	err := holder.Lock(context.Background())
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	t.Log("lock successfully")
+
+	// 模拟丢失的解锁通知：直接删除锁，不发布消息
+	time.AfterFunc(500*time.Millisecond, func() {
+		holder.root.Client.Del(context.Background(), holder.key)
+	})
+
+	waitGroup := sync.WaitGroup{}
+	waitGroup.Add(1)
+	go func() {
+		defer waitGroup.Done()
+		waiter := NewMutex(mutex.root, "waitStrategyMutexKey", WithWaitStrategy(FixedInterval(200*time.Millisecond)))
+		start := time.Now()
+		if err := waiter.Lock(context.Background()); err != nil {
+			t.Error(err)
+			return
+		}
+		if cost := time.Since(start); cost > 2*time.Second {
+			t.Errorf("waiter should poll instead of waiting for expiration, cost: %v", cost)
+		}
+		if err := waiter.Unlock(context.Background()); err != nil {
+			t.Error(err)
+		}
+	}()
+	waitGroup.Wait()
+}
+
+// TestMutex_WaitNoTTL
+// @Description: 测试：锁未设置过期时间时，按最短等待时间重试，不会不停顿地请求 redis
+// @param t
+func TestMutex_WaitNoTTL(t *testing.T) {
+	waiter := NewMutex(mutex.root, "waitNoTTLMutexKey", WithWaitTimeout(500*time.Millisecond))
+
+	var attempts int
+	err := waiter.waitLock(context.Background(), waiter.root, "互斥锁", func(ctx context.Context) (int64, error) {
+		attempts++
+		return -1, nil
+	}, 0)
+	if !errors.Is(err, types.ErrWaitTimeout) {
+		t.Errorf("expect wait timeout, got: %v", err)
+		return
+	}
+	if attempts > 10 {
+		t.Errorf("too many attempts: %d", attempts)
+	}
+}
@@ -1,36 +1,195 @@
 package mutex
 
 import (
+	"context"
+	"math"
+	"sync"
 	"time"
 
 	"github.com/go-redis/redis/v8"
 
+	"github.com/MaricoHan/redisson/pkg/backend"
+	"github.com/MaricoHan/redisson/pkg/clientcache"
+	"github.com/MaricoHan/redisson/pkg/clock"
+	"github.com/MaricoHan/redisson/pkg/codec"
 	"github.com/MaricoHan/redisson/pkg/loggers"
+	"github.com/MaricoHan/redisson/pkg/types"
+	"github.com/MaricoHan/redisson/pkg/utils"
 	"github.com/MaricoHan/redisson/pkg/utils/pubsub"
 )
 
 // Root 是所有锁的根结构，包含共享资源
 type Root struct {
-	Client *redis.Client
-	UUID   string // 自定义用于区分不同客户端的唯一标识
+	Client  redis.UniversalClient
+	Backend backend.Backend // 脚本执行、pubsub 等存储操作的实现，为空时基于 Client 创建 redis 实现
+	UUID    string          // 自定义用于区分不同客户端的唯一标识
 
-	RedisChannelName string           // redis 专用的 pubsub 频道名
-	Logger           loggers.Advanced // 日志接口
+	RedisChannelName string             // redis 专用的 pubsub 频道名
+	Logger           loggers.Advanced   // 日志接口
+	KeyPrefix        string             // 命名空间前缀，作用于所有 key 与频道名
+	Clock            clock.Clock        // 续期、等待使用的时钟，为空时使用真实时间
+	Codec            codec.Codec        // 分布式对象默认的编解码器，为空时使用 JSON
+	ClientCache      *clientcache.Cache // 客户端缓存，为空时不缓存
+
+	backendOnce sync.Once
+}
+
+// clock 返回续期、等待使用的时钟
+func (r *Root) clock() clock.Clock {
+	if r.Clock == nil {
+		return clock.New()
+	}
+	return r.Clock
+}
+
+// backend 返回存储操作的实现
+func (r *Root) backend() backend.Backend {
+	r.backendOnce.Do(func() {
+		if r.Backend == nil {
+			r.Backend = backend.NewRedis(r.Client, r.Logger)
+		}
+	})
+	return r.Backend
+}
+
+// Key 返回加上命名空间前缀后的 redis key
+func (r *Root) Key(name string) string {
+	return r.KeyPrefix + name
+}
+
+// ChannelName 返回加上命名空间前缀后的 redis 频道名
+func (r *Root) ChannelName(name string) string {
+	return r.KeyPrefix + utils.ChannelName(name)
+}
+
+// ReconnectedMessage 是实例的 redis 订阅断线重连后，在 ReconnectChannelName 上发布的进程内消息
+const ReconnectedMessage = "reconnected"
+
+// ReconnectChannelName 返回进程内通知 redis 订阅已重连的频道，重连前发布的消息可能已经丢失
+func (r *Root) ReconnectChannelName() string {
+	return utils.ChannelName(r.RedisChannelName)
 }
 
 // baseMutex 是所有锁类型的基础结构
 type baseMutex struct {
 	Name    string
+	key     string // 加上命名空间前缀后，实际存储在 redis 中的 key
 	pubSub  *pubsub.PubSub
 	release chan struct{}
 
 	options *options
 }
 
+// WaitForever 作为 WithWaitTimeout 的参数时，获取锁会一直等待，直到加锁成功或调用方取消
+const WaitForever time.Duration = math.MaxInt64
+
+// minWait 单次等待的最短时间：锁未设置过期时间(PTTL 为 -1)等情况下计算出的等待时间小于等于 0，避免不停顿地重试
+const minWait = 100 * time.Millisecond
+
+// waitLock 循环调用 attempt 尝试加锁，直到加锁成功、等待超时或 ctx 被调用方取消。
+// attempt 返回 0 表示加锁成功，否则返回锁的剩余过期时间(ms)；maxWait > 0 时限制单次等待的最长时间。
+// 等待超时返回匹配 types.ErrWaitTimeout 的错误，调用方取消返回匹配 types.ErrWaitCanceled 的错误，二者均包装了对应的 ctx.Err()
+func (b *baseMutex) waitLock(ctx context.Context, root *Root, desc string, attempt func(ctx context.Context) (int64, error), maxWait time.Duration) error {
+	var (
+		clk      = root.clock()
+		logger   = root.Logger
+		deadline <-chan time.Time
+		timer    clock.Timer
+		notices  <-chan string
+		retries  int
+	)
+	if b.options.waitTimeout != WaitForever {
+		deadlineTimer := clk.NewTimer(b.options.waitTimeout)
+		defer deadlineTimer.Stop()
+		deadline = deadlineTimer.C()
+	}
+	if b.pubSub != nil {
+		notices = b.pubSub.Channel()
+	}
+	defer func() {
+		if timer != nil {
+			timer.Stop()
+		}
+	}()
+
+	for {
+		pTTL, err := attempt(ctx)
+		if err != nil {
+			if ctx.Err() != nil {
+				return b.waitError(ctx, logger, desc)
+			}
+			logger.Errorf("获取%s内部操作失败: %s, 错误: %v", desc, b.Name, err)
+			return err
+		}
+		if pTTL == 0 {
+			logger.Debugf("成功获取%s: %s", desc, b.Name)
+			return nil
+		}
+
+		logger.Debugf("%s已被占用: %s, TTL: %dms, 等待解锁或过期", desc, b.Name, pTTL)
+
+		retries++
+		wait := time.Duration(pTTL) * time.Millisecond
+		if next := b.options.waitStrategy.Next(retries, wait); next > 0 && (wait <= 0 || next < wait) {
+			wait = next
+		}
+		if maxWait > 0 && wait > maxWait {
+			wait = maxWait
+		}
+		if wait <= 0 {
+			wait = minWait
+		}
+		if timer == nil {
+			timer = clk.NewTimer(wait)
+		} else {
+			timer.Reset(wait)
+		}
+
+		select {
+		case <-ctx.Done():
+			return b.waitError(ctx, logger, desc)
+		case <-deadline:
+			return b.waitError(ctx, logger, desc)
+		case <-timer.C():
+			// 针对"redis 中存在未维护的锁"，即当锁自然过期后，并不会发布通知的锁；以及错过解锁通知的情况
+			logger.Debugf("%s等待 %v 后重试: %s", desc, wait, b.Name)
+			continue
+		case _, ok := <-notices:
+			if !ok {
+				// 订阅已被关闭，之后只依赖定时重试
+				notices = nil
+			}
+			// 收到解锁通知，则尝试抢锁
+			logger.Debugf("收到%s解锁通知，尝试获取: %s", desc, b.Name)
+		}
+
+		// 复用定时器前，确保其已停止且通道已排空
+		if !timer.Stop() {
+			select {
+			case <-timer.C():
+			default:
+			}
+		}
+	}
+}
+
+// waitError 区分调用方取消与等待超时
+func (b *baseMutex) waitError(ctx context.Context, logger loggers.Advanced, desc string) error {
+	if err := ctx.Err(); err != nil {
+		logger.Warnf("获取%s被调用方取消: %s, 原因: %v", desc, b.Name, err)
+		return types.Wrap(types.ErrWaitCanceled, err)
+	}
+	// 申请锁的耗时如果大于等于最大等待时间，则申请锁失败.
+	logger.Warnf("获取%s等待超时: %s", desc, b.Name)
+	return types.Wrap(types.ErrWaitTimeout, context.DeadlineExceeded)
+}
+
 // options 定义锁的配置选项
 type options struct {
-	expiration  time.Duration // 锁的过期时间
-	waitTimeout time.Duration // 获取锁的最大等待时间
+	expiration      time.Duration // 锁的过期时间
+	waitTimeout     time.Duration // 获取锁的最大等待时间
+	writerPreferred bool          // 读写锁是否写优先
+	waitStrategy    WaitStrategy  // 等待锁时的重试策略
 }
 
 // checkAndInit 检查并初始化选项的默认值
@@ -41,6 +200,9 @@ func (o *options) checkAndInit() {
 	if o.expiration <= 0 {
 		o.expiration = 10 * time.Second
 	}
+	if o.waitStrategy == nil {
+		o.waitStrategy = UntilExpire()
+	}
 }
 
 // Option 是配置锁选项的函数类型
@@ -53,9 +215,61 @@ func WithExpireDuration(dur time.Duration) Option {
 	}
 }
 
-// WithWaitTimeout 设置获取锁的最大等待时间
+// WithWaitTimeout 设置获取锁的最大等待时间，传入 WaitForever 时不限制等待时间
 func WithWaitTimeout(timeout time.Duration) Option {
 	return func(opt *options) {
 		opt.waitTimeout = timeout
 	}
 }
+
+// WithWaitStrategy 设置等待锁时的重试策略，与解锁通知同时生效，对互斥锁和读写锁的读写两端均生效。
+// 默认为 UntilExpire，即等到锁过期或收到解锁通知
+func WithWaitStrategy(strategy WaitStrategy) Option {
+	return func(opt *options) {
+		opt.waitStrategy = strategy
+	}
+}
+
+// WithWriterPreferred 设置读写锁为写优先：一旦有写者在等待，新的读者会排在写者之后，避免写者饥饿。
+// 写意向记录在 redis 中，对跨进程的读者同样生效；仅对读写锁的写锁生效
+func WithWriterPreferred() Option {
+	return func(opt *options) {
+		opt.writerPreferred = true
+	}
+}
+
+// Eval 原子地执行脚本，供锁以外的分布式对象使用
+func (r *Root) Eval(ctx context.Context, script *backend.Script, keys []string, args ...interface{}) *redis.Cmd {
+	return r.backend().Eval(ctx, script, keys, args...)
+}
+
+// Now 返回 Root 的时钟的当前时间
+func (r *Root) Now() time.Time {
+	return r.clock().Now()
+}
+
+// NewTimer 基于 Root 的时钟创建定时器
+func (r *Root) NewTimer(d time.Duration) clock.Timer {
+	return r.clock().NewTimer(d)
+}
+
+// NewTicker 基于 Root 的时钟创建周期定时器
+func (r *Root) NewTicker(d time.Duration) clock.Ticker {
+	return r.clock().NewTicker(d)
+}
+
+// RemainTTL 查询 key 的剩余过期时间，key 不存在时返回 0，未设置过期时间时返回 -1
+func (r *Root) RemainTTL(ctx context.Context, key string) (time.Duration, error) {
+	pTTL, err := r.backend().PTTL(ctx, key)
+	if err != nil {
+		r.Logger.Errorf("查询剩余过期时间失败: %s, 错误: %v", key, err)
+		return 0, err
+	}
+	switch pTTL {
+	case -2: // key 不存在
+		return 0, nil
+	case -1: // 未设置过期时间
+		return -1, nil
+	}
+	return pTTL, nil
+}
@@ -9,6 +9,7 @@ import (
 
 	"github.com/go-redis/redis/v8"
 
+	"github.com/MaricoHan/redisson/pkg/backend"
 	"github.com/MaricoHan/redisson/pkg/types"
 	"github.com/MaricoHan/redisson/pkg/utils"
 	"github.com/MaricoHan/redisson/pkg/utils/pubsub"
@@ -16,28 +17,29 @@ import (
 
 var (
 	rwMutexScript = struct {
-		lockScript    string
-		lockScriptSha string
-
-		rLockScript    string
-		rLockScriptSha string
-
-		renewalScript    string
-		renewalScriptSha string
-
-		unlockScript    string
-		unlockScriptSha string
+		lockScript        *backend.Script
+		rLockScript       *backend.Script
+		renewalScript     *backend.Script
+		unlockScript      *backend.Script
+		forceUnlockScript *backend.Script
+		downgradeScript   *backend.Script
+		upgradeScript     *backend.Script
+		withdrawScript    *backend.Script
 	}{}
 )
 
 type RWMutex struct {
 	root *Root
 	*baseMutex
+
+	intentKey string // 记录等待中的写者，与锁 key 位于同一个 hash slot
+	leaseKey  string // 记录每个读者的租约到期时间，与锁 key 位于同一个 hash slot
 }
 
 func NewRWMutex(r *Root, name string, opts ...Option) *RWMutex {
 	base := &baseMutex{
 		Name:    name,
+		key:     r.Key(name),
 		release: make(chan struct{}),
 		options: &options{},
 	}
@@ -47,12 +49,14 @@ func NewRWMutex(r *Root, name string, opts ...Option) *RWMutex {
 
 	base.options.checkAndInit()
 
-	r.Logger.Debugf("创建读写锁实例: %s, 过期时间: %v, 等待超时: %v",
-		name, base.options.expiration, base.options.waitTimeout)
+	r.Logger.Debugf("创建读写锁实例: %s, 过期时间: %v, 等待超时: %v, 写优先: %v",
+		name, base.options.expiration, base.options.waitTimeout, base.options.writerPreferred)
 
 	return &RWMutex{
 		root:      r,
 		baseMutex: base,
+		intentKey: "{" + base.key + "}:write_intent",
+		leaseKey:  "{" + base.key + "}:read_lease",
 	}
 }
 
@@ -62,19 +66,22 @@ func (r *RWMutex) Lock(ctx context.Context) error {
 
 	r.root.Logger.Debugf("尝试获取写锁: %s, 过期时间: %dms", r.Name, expiration)
 
-	ctx, cancel := context.WithTimeout(ctx, r.options.waitTimeout)
-	defer cancel()
-
 	var err error
 	// 先订阅，再申请锁
 	if r.pubSub == nil {
-		r.pubSub = pubsub.Subscribe(utils.ChannelName(r.Name))
-		r.root.Logger.Debugf("订阅锁通道: %s", utils.ChannelName(r.Name))
+		r.pubSub = pubsub.Subscribe(utils.ChannelName(r.key), pubsub.WithClock(r.root.clock()))
+		r.root.Logger.Debugf("订阅锁通道: %s", utils.ChannelName(r.key))
 	}
 
 	clientID := r.root.UUID + ":" + strconv.FormatInt(utils.GoID(), 10)
 	if err = r.tryLock(ctx, clientID, expiration); err != nil {
 		r.root.Logger.Errorf("获取写锁失败: %s, 客户端ID: %s, 错误: %v", r.Name, clientID, err)
+		if r.options.writerPreferred {
+			// 放弃等待，撤回写意向，让读者可以继续加锁
+			if zErr := r.root.backend().Eval(context.Background(), rwMutexScript.withdrawScript, []string{r.intentKey}, clientID).Err(); zErr != nil {
+				r.root.Logger.Errorf("撤回写意向失败: %s, 客户端ID: %s, 错误: %v", r.Name, clientID, zErr)
+			}
+		}
 		return err
 	}
 
@@ -86,28 +93,18 @@ func (r *RWMutex) Lock(ctx context.Context) error {
 	go func() {
 		wg.Done()
 
-		ticker := time.NewTicker(r.options.expiration / 3)
+		ticker := r.root.clock().NewTicker(r.options.expiration / 3)
 		defer ticker.Stop()
 
 		r.root.Logger.Debugf("启动写锁续期协程: %s, 续期间隔: %v", r.Name, r.options.expiration/3)
 
-		// 上传脚本
-		if rwMutexScript.renewalScriptSha == "" {
-			rwMutexScript.renewalScriptSha, err = r.root.Client.ScriptLoad(ctx, rwMutexScript.renewalScript).Result()
-			if err != nil {
-				r.root.Logger.Errorf("加载写锁续期脚本失败: %v", err)
-				return
-			}
-			r.root.Logger.Debugf("加载写锁续期脚本成功: %s", rwMutexScript.renewalScriptSha)
-		}
-
 		for {
 			select {
 			case <-r.release:
 				r.root.Logger.Debugf("写锁续期协程收到退出信号: %s", r.Name)
 				return
-			case <-ticker.C:
-				res, err := r.root.Client.EvalSha(context.TODO(), rwMutexScript.renewalScriptSha, []string{r.Name}, expiration, clientID).Int64()
+			case <-ticker.C():
+				res, err := r.root.backend().Eval(context.TODO(), rwMutexScript.renewalScript, []string{r.key, r.leaseKey}, expiration, clientID).Int64()
 				if err != nil {
 					r.root.Logger.Errorf("写锁续期失败: %s, 错误: %v", r.Name, err)
 					return
@@ -126,50 +123,26 @@ func (r *RWMutex) Lock(ctx context.Context) error {
 }
 
 func (r *RWMutex) tryLock(ctx context.Context, clientID string, expiration int64) error {
-	// 尝试加锁
 	r.root.Logger.Debugf("尝试获取写锁: %s, 客户端ID: %s", r.Name, clientID)
-	pTTL, err := r.lockInner(ctx, clientID, expiration)
-	if err != nil {
-		r.root.Logger.Errorf("获取写锁内部操作失败: %s, 错误: %v", r.Name, err)
-		return err
-	}
-	if pTTL == 0 {
-		r.root.Logger.Debugf("成功获取写锁: %s", r.Name)
-		return nil
-	}
 
-	r.root.Logger.Debugf("写锁已被占用: %s, TTL: %dms, 等待解锁或过期", r.Name, pTTL)
-
-	select {
-	case <-ctx.Done():
-		// 申请锁的耗时如果大于等于最大等待时间，则申请锁失败.
-		r.root.Logger.Warnf("获取写锁等待超时: %s", r.Name)
-		return types.ErrWaitTimeout
-	case <-time.After(time.Duration(pTTL) * time.Millisecond):
-		// 针对"redis 中存在未维护的锁"，即当锁自然过期后，并不会发布通知的锁
-		r.root.Logger.Debugf("写锁等待过期后重试: %s", r.Name)
-		return r.tryLock(ctx, clientID, expiration)
-	case <-r.pubSub.Channel():
-		// 收到解锁通知，则尝试抢锁
-		r.root.Logger.Debugf("收到写锁解锁通知，尝试获取: %s", r.Name)
-		return r.tryLock(ctx, clientID, expiration)
+	// 写优先时，写意向的有效期等于锁的过期时间，需要在意向过期前重试以刷新意向
+	var maxWait time.Duration
+	if r.options.writerPreferred {
+		maxWait = r.options.expiration / 2
 	}
+
+	return r.waitLock(ctx, r.root, "写锁", func(ctx context.Context) (int64, error) {
+		return r.lockInner(ctx, clientID, expiration)
+	}, maxWait)
 }
 
 func (r *RWMutex) lockInner(ctx context.Context, clientID string, expiration int64) (int64, error) {
-	// 上传脚本
-	if rwMutexScript.lockScriptSha == "" {
-		var err error
-		r.root.Logger.Debugf("加载写锁获取脚本")
-		rwMutexScript.lockScriptSha, err = r.root.Client.ScriptLoad(ctx, rwMutexScript.lockScript).Result()
-		if err != nil {
-			r.root.Logger.Errorf("加载写锁获取脚本失败: %v", err)
-			return 0, fmt.Errorf("load lock script err: %w", err)
-		}
-		r.root.Logger.Debugf("加载写锁获取脚本成功: %s", rwMutexScript.lockScriptSha)
+	writerPreferred := 0
+	if r.options.writerPreferred {
+		writerPreferred = 1
 	}
 
-	pTTL, err := r.root.Client.EvalSha(ctx, rwMutexScript.lockScriptSha, []string{r.Name}, clientID, expiration).Result()
+	pTTL, err := r.root.backend().Eval(ctx, rwMutexScript.lockScript, []string{r.key, r.intentKey, r.leaseKey}, clientID, expiration, writerPreferred).Result()
 	if err == redis.Nil {
 		r.root.Logger.Debugf("写锁获取成功: %s", r.Name)
 		return 0, nil
@@ -189,14 +162,11 @@ func (r *RWMutex) RLock(ctx context.Context) error {
 
 	r.root.Logger.Debugf("尝试获取读锁: %s, 过期时间: %dms", r.Name, pExpireNum)
 
-	ctx, cancel := context.WithTimeout(ctx, r.options.waitTimeout)
-	defer cancel()
-
 	var err error
 	// 先订阅，再申请锁
 	if r.pubSub == nil {
-		r.pubSub = pubsub.Subscribe(utils.ChannelName(r.Name))
-		r.root.Logger.Debugf("订阅锁通道: %s", utils.ChannelName(r.Name))
+		r.pubSub = pubsub.Subscribe(utils.ChannelName(r.key), pubsub.WithClock(r.root.clock()))
+		r.root.Logger.Debugf("订阅锁通道: %s", utils.ChannelName(r.key))
 	}
 
 	clientID := r.root.UUID + ":" + strconv.FormatInt(utils.GoID(), 10)
@@ -213,28 +183,18 @@ func (r *RWMutex) RLock(ctx context.Context) error {
 	go func() {
 		wg.Done()
 
-		ticker := time.NewTicker(r.options.expiration / 3)
+		ticker := r.root.clock().NewTicker(r.options.expiration / 3)
 		defer ticker.Stop()
 
 		r.root.Logger.Debugf("启动读锁续期协程: %s, 续期间隔: %v", r.Name, r.options.expiration/3)
 
-		// 上传脚本
-		if rwMutexScript.renewalScriptSha == "" {
-			rwMutexScript.renewalScriptSha, err = r.root.Client.ScriptLoad(ctx, rwMutexScript.renewalScript).Result()
-			if err != nil {
-				r.root.Logger.Errorf("加载读锁续期脚本失败: %v", err)
-				return
-			}
-			r.root.Logger.Debugf("加载读锁续期脚本成功: %s", rwMutexScript.renewalScriptSha)
-		}
-
 		for {
 			select {
 			case <-r.release:
 				r.root.Logger.Debugf("读锁续期协程收到退出信号: %s", r.Name)
 				return
-			case <-ticker.C:
-				res, err := r.root.Client.EvalSha(context.TODO(), rwMutexScript.renewalScriptSha, []string{r.Name}, pExpireNum, clientID).Int64()
+			case <-ticker.C():
+				res, err := r.root.backend().Eval(context.TODO(), rwMutexScript.renewalScript, []string{r.key, r.leaseKey}, pExpireNum, clientID).Int64()
 				if err != nil {
 					r.root.Logger.Errorf("读锁续期失败: %s, 错误: %v", r.Name, err)
 					return
@@ -253,50 +213,14 @@ func (r *RWMutex) RLock(ctx context.Context) error {
 }
 
 func (r *RWMutex) tryRLock(ctx context.Context, clientID string, pExpireNum int64) error {
-	// 尝试加锁
 	r.root.Logger.Debugf("尝试获取读锁: %s, 客户端ID: %s", r.Name, clientID)
-	pTTL, err := r.rLockInner(ctx, clientID, pExpireNum)
-	if err != nil {
-		r.root.Logger.Errorf("获取读锁内部操作失败: %s, 错误: %v", r.Name, err)
-		return err
-	}
-	if pTTL == 0 {
-		r.root.Logger.Debugf("成功获取读锁: %s", r.Name)
-		return nil
-	}
-
-	r.root.Logger.Debugf("读锁已被占用: %s, TTL: %dms, 等待解锁或过期", r.Name, pTTL)
-
-	select {
-	case <-ctx.Done():
-		// 申请锁的耗时如果大于等于最大等待时间，则申请锁失败.
-		r.root.Logger.Warnf("获取读锁等待超时: %s", r.Name)
-		return types.ErrWaitTimeout
-	case <-time.After(time.Duration(pTTL) * time.Millisecond):
-		// 针对"redis 中存在未维护的锁"，即当锁自然过期后，并不会发布通知的锁
-		r.root.Logger.Debugf("读锁等待过期后重试: %s", r.Name)
-		return r.tryRLock(ctx, clientID, pExpireNum)
-	case <-r.pubSub.Channel():
-		// 收到解锁通知，则尝试抢锁
-		r.root.Logger.Debugf("收到读锁解锁通知，尝试获取: %s", r.Name)
-		return r.tryRLock(ctx, clientID, pExpireNum)
-	}
+	return r.waitLock(ctx, r.root, "读锁", func(ctx context.Context) (int64, error) {
+		return r.rLockInner(ctx, clientID, pExpireNum)
+	}, 0)
 }
 
 func (r *RWMutex) rLockInner(ctx context.Context, clientID string, pExpireNum int64) (int64, error) {
-	// 上传脚本
-	if rwMutexScript.rLockScriptSha == "" {
-		var err error
-		r.root.Logger.Debugf("加载读锁获取脚本")
-		rwMutexScript.rLockScriptSha, err = r.root.Client.ScriptLoad(ctx, rwMutexScript.rLockScript).Result()
-		if err != nil {
-			r.root.Logger.Errorf("加载读锁获取脚本失败: %v", err)
-			return 0, fmt.Errorf("load rlock script err: %w", err)
-		}
-		r.root.Logger.Debugf("加载读锁获取脚本成功: %s", rwMutexScript.rLockScriptSha)
-	}
-
-	pTTL, err := r.root.Client.EvalSha(ctx, rwMutexScript.rLockScriptSha, []string{r.Name}, clientID, pExpireNum).Result()
+	pTTL, err := r.root.backend().Eval(ctx, rwMutexScript.rLockScript, []string{r.key, r.intentKey, r.leaseKey}, clientID, pExpireNum).Result()
 	if err == redis.Nil {
 		r.root.Logger.Debugf("读锁获取成功: %s", r.Name)
 		return 0, nil
@@ -328,24 +252,12 @@ func (r *RWMutex) Unlock(ctx context.Context) error {
 func (r *RWMutex) unlockInner(ctx context.Context, goID int64) error {
 	clientID := r.root.UUID + ":" + strconv.FormatInt(goID, 10)
 
-	// 上传脚本
-	if rwMutexScript.unlockScriptSha == "" {
-		var err error
-		r.root.Logger.Debugf("加载锁释放脚本")
-		rwMutexScript.unlockScriptSha, err = r.root.Client.ScriptLoad(ctx, rwMutexScript.unlockScript).Result()
-		if err != nil {
-			r.root.Logger.Errorf("加载锁释放脚本失败: %v", err)
-			return fmt.Errorf("load unlock script err: %w", err)
-		}
-		r.root.Logger.Debugf("加载锁释放脚本成功: %s", rwMutexScript.unlockScriptSha)
-	}
-
-	res, err := r.root.Client.EvalSha(
+	res, err := r.root.backend().Eval(
 		ctx,
-		rwMutexScript.unlockScriptSha,
-		[]string{r.Name, r.root.RedisChannelName},
+		rwMutexScript.unlockScript,
+		[]string{r.key, r.root.RedisChannelName, r.leaseKey},
 		clientID,
-		r.Name+":unlock",
+		r.key+":unlock",
 	).Int64()
 	if err != nil {
 		r.root.Logger.Errorf("执行锁释放脚本失败: %v", err)
@@ -364,34 +276,180 @@ func (r *RWMutex) unlockInner(ctx context.Context, goID int64) error {
 	return nil
 }
 
+// IsLocked 查询锁当前是否被持有
+func (r *RWMutex) IsLocked(ctx context.Context) (bool, error) {
+	locked, err := r.root.backend().Exists(ctx, r.key)
+	if err != nil {
+		r.root.Logger.Errorf("查询读写锁状态失败: %s, 错误: %v", r.Name, err)
+		return false, err
+	}
+	return locked, nil
+}
+
+// RemainTTL 查询锁的剩余过期时间，锁不存在时返回 0
+func (r *RWMutex) RemainTTL(ctx context.Context) (time.Duration, error) {
+	return r.root.RemainTTL(ctx, r.key)
+}
+
+// ForceUnlock 不校验持有者，强制释放锁并发布解锁通知，返回锁在释放前是否存在
+func (r *RWMutex) ForceUnlock(ctx context.Context) (bool, error) {
+	r.root.Logger.Warnf("强制释放读写锁: %s", r.Name)
+
+	res, err := r.root.backend().Eval(
+		ctx,
+		rwMutexScript.forceUnlockScript,
+		[]string{r.key, r.root.RedisChannelName, r.leaseKey},
+		r.key+":unlock",
+	).Int64()
+	if err != nil {
+		r.root.Logger.Errorf("执行读写锁强制释放脚本失败: %v", err)
+		return false, err
+	}
+
+	return res == 1, nil
+}
+
+// Downgrade 将当前协程持有的写锁原子地降级为读锁，期间不会释放锁，其他写者无法趁机加锁。
+// 降级后会通知等待中的读者重新尝试加锁，之后仍通过 Unlock 释放读锁
+func (r *RWMutex) Downgrade(ctx context.Context) error {
+	clientID := r.root.UUID + ":" + strconv.FormatInt(utils.GoID(), 10)
+
+	r.root.Logger.Debugf("尝试将写锁降级为读锁: %s, 客户端ID: %s", r.Name, clientID)
+
+	res, err := r.root.backend().Eval(
+		ctx,
+		rwMutexScript.downgradeScript,
+		[]string{r.key, r.root.RedisChannelName, r.leaseKey},
+		clientID,
+		int64(r.options.expiration/time.Millisecond),
+		r.key+":downgrade",
+	).Int64()
+	if err != nil {
+		r.root.Logger.Errorf("执行写锁降级脚本失败: %v", err)
+		return fmt.Errorf("downgrade err: %w", err)
+	}
+	if res == 0 {
+		r.root.Logger.Warnf("写锁降级失败，未持有写锁: %s, 客户端ID: %s", r.Name, clientID)
+		return types.ErrMismatch
+	}
+
+	r.root.Logger.Infof("成功将写锁降级为读锁: %s, 客户端ID: %s", r.Name, clientID)
+	return nil
+}
+
+// TryUpgrade 尝试将当前协程持有的读锁原子地升级为写锁。
+// 仅当当前协程是唯一的读者且未重入时才能升级，否则立即返回 types.ErrUpgradeConflict，不会等待，避免互相等待造成死锁
+func (r *RWMutex) TryUpgrade(ctx context.Context) error {
+	clientID := r.root.UUID + ":" + strconv.FormatInt(utils.GoID(), 10)
+
+	r.root.Logger.Debugf("尝试将读锁升级为写锁: %s, 客户端ID: %s", r.Name, clientID)
+
+	res, err := r.root.backend().Eval(
+		ctx,
+		rwMutexScript.upgradeScript,
+		[]string{r.key, r.leaseKey},
+		clientID,
+		int64(r.options.expiration/time.Millisecond),
+	).Int64()
+	if err != nil {
+		r.root.Logger.Errorf("执行读锁升级脚本失败: %v", err)
+		return fmt.Errorf("upgrade err: %w", err)
+	}
+	switch res {
+	case 0:
+		r.root.Logger.Warnf("读锁升级失败，未持有读锁: %s, 客户端ID: %s", r.Name, clientID)
+		return types.ErrMismatch
+	case 2:
+		r.root.Logger.Warnf("读锁升级失败，存在其他读者: %s, 客户端ID: %s", r.Name, clientID)
+		return types.ErrUpgradeConflict
+	}
+
+	r.root.Logger.Infof("成功将读锁升级为写锁: %s, 客户端ID: %s", r.Name, clientID)
+	return nil
+}
+
+// rwMutexLuaPrelude 读写锁脚本共用的 lua 函数
+const rwMutexLuaPrelude = backend.NowMillisLua + `
+	-- 清理租约已过期的读者：读者崩溃后，其持有的读锁不会被其他读者的续期延续
+	local function pruneReaders(lockKey, leaseKey, now)
+		if redis.call('type',lockKey)["ok"] == "hash" then
+			local expired = redis.call('zrangebyscore',leaseKey,'-inf',now)
+			for i = 1, #expired do
+				redis.call('hdel',lockKey,expired[i])
+			end
+		end
+		redis.call('zremrangebyscore',leaseKey,'-inf',now)
+	end
+
+	-- 将读锁与租约集合的过期时间设为最晚到期的读者租约
+	local function expireByLeases(lockKey, leaseKey, now)
+		local last = redis.call('zrange',leaseKey,-1,-1,'withscores')
+		if #last > 0 then
+			local pTTL = math.max(tonumber(last[2]) - now, 1)
+			redis.call('pexpire',lockKey,pTTL)
+			redis.call('pexpire',leaseKey,pTTL)
+		end
+	end
+`
+
 func init() {
-	rwMutexScript.lockScript = `
+	rwMutexScript.lockScript = backend.NewScript("rwmutex.lock", rwMutexLuaPrelude+`
 	-- KEYS[1] 锁名
+	-- KEYS[2] 等待中的写者集合（zset，score 为意向的过期时间戳）
+	-- KEYS[3] 读者租约集合（zset，score 为租约的过期时间戳）
 	-- ARGV[1] 协程唯一标识：客户端标识+协程ID
 	-- ARGV[2] 过期时间
+	-- ARGV[3] 是否写优先：1-是 0-否
+	local now = nowMillis()
+	pruneReaders(KEYS[1],KEYS[3],now)
 	if redis.call('exists',KEYS[1]) == 0 then
 		redis.call('set',KEYS[1],ARGV[1])
 		redis.call('pexpire',KEYS[1],ARGV[2])
+		redis.call('zrem',KEYS[2],ARGV[1])
 		return nil
 	end
+	if ARGV[3] == "1" then
+		-- 登记写意向，阻止后续的读者加锁；意向会过期，避免写者崩溃后读者被永久阻塞
+		redis.call('zadd',KEYS[2],now + tonumber(ARGV[2]),ARGV[1])
+		if redis.call('pttl',KEYS[2]) < tonumber(ARGV[2]) then
+			redis.call('pexpire',KEYS[2],ARGV[2])
+		end
+	end
 	return redis.call('pttl',KEYS[1])
-`
+`)
 
-	rwMutexScript.rLockScript = `
+	rwMutexScript.rLockScript = backend.NewScript("rwmutex.rLock", rwMutexLuaPrelude+`
 	-- KEYS[1] 锁名
+	-- KEYS[2] 等待中的写者集合（zset，score 为意向的过期时间戳）
+	-- KEYS[3] 读者租约集合（zset，score 为租约的过期时间戳）
 	-- ARGV[1] 协程唯一标识：客户端标识+协程ID
 	-- ARGV[2] 过期时间
 	local t = redis.call('type',KEYS[1])["ok"]
 	if t == "string" then
 		return redis.call('pttl',KEYS[1])
-	else
-		redis.call('hincrby',KEYS[1],ARGV[1],1)
-		redis.call('pexpire',KEYS[1],ARGV[2])
-		return nil
 	end
-`
-	rwMutexScript.renewalScript = `
+	local now = nowMillis()
+	pruneReaders(KEYS[1],KEYS[3],now)
+	-- 有写者在等待时，新的读者排在写者之后；已持有读锁的协程可以重入
+	if redis.call('exists',KEYS[2]) == 1 and redis.call('hexists',KEYS[1],ARGV[1]) == 0 then
+		redis.call('zremrangebyscore',KEYS[2],'-inf',now)
+		if redis.call('zcard',KEYS[2]) > 0 then
+			local pTTL = redis.call('pttl',KEYS[1])
+			if pTTL <= 0 then
+				pTTL = redis.call('pttl',KEYS[2])
+			end
+			return math.max(pTTL,1)
+		end
+	end
+	redis.call('hincrby',KEYS[1],ARGV[1],1)
+	redis.call('zadd',KEYS[3],now + tonumber(ARGV[2]),ARGV[1])
+	expireByLeases(KEYS[1],KEYS[3],now)
+	return nil
+`)
+
+	rwMutexScript.renewalScript = backend.NewScript("rwmutex.renewal", rwMutexLuaPrelude+`
 	-- KEYS[1] 锁名
+	-- KEYS[2] 读者租约集合（zset，score 为租约的过期时间戳）
 	-- ARGV[1] 过期时间
 	-- ARGV[2] 客户端协程唯一标识
 	local t = redis.call('type',KEYS[1])["ok"]
@@ -401,18 +459,24 @@ func init() {
 		end
 		return 0
 	elseif t == "hash" then
+		local now = nowMillis()
+		pruneReaders(KEYS[1],KEYS[2],now)
 		if redis.call('hexists',KEYS[1],ARGV[2])==0 then
 			return 0
 		end
-		return redis.call('pexpire',KEYS[1],ARGV[1])
+		-- 只延长自己的租约，其他读者的租约不受影响
+		redis.call('zadd',KEYS[2],now + tonumber(ARGV[1]),ARGV[2])
+		expireByLeases(KEYS[1],KEYS[2],now)
+		return 1
 	else
 		return 0
 	end
-`
+`)
 
-	rwMutexScript.unlockScript = `
+	rwMutexScript.unlockScript = backend.NewScript("rwmutex.unlock", `
 	-- KEYS[1] 锁名
 	-- KEYS[2] 发布订阅的channel
+	-- KEYS[3] 读者租约集合（zset，score 为租约的过期时间戳）
 	-- ARGV[1] 协程唯一标识：客户端标识+协程ID
 	-- ARGV[2] 解锁时发布的消息
 	-- 返回值：0-未解锁 1-解锁且整个rw锁已被删除 2-解锁且还有其他r锁存在
@@ -423,10 +487,11 @@ func init() {
 		end
 		if redis.call('hincrby',KEYS[1],ARGV[1],-1) <= 0 then
 			redis.call('hdel',KEYS[1],ARGV[1])
+			redis.call('zrem',KEYS[3],ARGV[1])
 			if (redis.call('hlen',KEYS[1]) > 0 )then
 				return 2
 			end
-			redis.call('del',KEYS[1])
+			redis.call('del',KEYS[1],KEYS[3])
 			redis.call('publish',KEYS[2],ARGV[2])
 			return 1
 		else
@@ -442,5 +507,63 @@ func init() {
 	else
 		return 0
 	end
-`
+`)
+
+	rwMutexScript.forceUnlockScript = backend.NewScript("rwmutex.forceUnlock", `
+	-- KEYS[1] 锁名
+	-- KEYS[2] 发布订阅的channel
+	-- KEYS[3] 读者租约集合（zset，score 为租约的过期时间戳）
+	-- ARGV[1] 解锁时发布的消息
+	local n = redis.call('del',KEYS[1])
+	redis.call('del',KEYS[3])
+	redis.call('publish',KEYS[2],ARGV[1])
+	return n
+`)
+
+	rwMutexScript.downgradeScript = backend.NewScript("rwmutex.downgrade", rwMutexLuaPrelude+`
+	-- KEYS[1] 锁名
+	-- KEYS[2] 发布订阅的channel
+	-- KEYS[3] 读者租约集合（zset，score 为租约的过期时间戳）
+	-- ARGV[1] 协程唯一标识：客户端标识+协程ID
+	-- ARGV[2] 过期时间
+	-- ARGV[3] 降级时发布的消息
+	-- 返回值：0-未持有写锁 1-降级成功
+	if redis.call('type',KEYS[1])["ok"] ~= "string" or redis.call('get',KEYS[1]) ~= ARGV[1] then
+		return 0
+	end
+	local now = nowMillis()
+	redis.call('del',KEYS[1])
+	redis.call('hset',KEYS[1],ARGV[1],1)
+	redis.call('zadd',KEYS[3],now + tonumber(ARGV[2]),ARGV[1])
+	expireByLeases(KEYS[1],KEYS[3],now)
+	redis.call('publish',KEYS[2],ARGV[3])
+	return 1
+`)
+
+	rwMutexScript.upgradeScript = backend.NewScript("rwmutex.upgrade", rwMutexLuaPrelude+`
+	-- KEYS[1] 锁名
+	-- KEYS[2] 读者租约集合（zset，score 为租约的过期时间戳）
+	-- ARGV[1] 协程唯一标识：客户端标识+协程ID
+	-- ARGV[2] 过期时间
+	-- 返回值：0-未持有读锁 1-升级成功 2-存在其他读者或读锁被重入
+	pruneReaders(KEYS[1],KEYS[2],nowMillis())
+	if redis.call('type',KEYS[1])["ok"] ~= "hash" or redis.call('hexists',KEYS[1],ARGV[1]) == 0 then
+		return 0
+	end
+	if redis.call('hlen',KEYS[1]) > 1 or tonumber(redis.call('hget',KEYS[1],ARGV[1])) > 1 then
+		return 2
+	end
+	redis.call('del',KEYS[1],KEYS[2])
+	redis.call('set',KEYS[1],ARGV[1])
+	redis.call('pexpire',KEYS[1],ARGV[2])
+	return 1
+`)
+
+	rwMutexScript.withdrawScript = backend.NewScript("rwmutex.withdraw", `
+	-- KEYS[1] 等待中的写者集合（zset，score 为意向的过期时间戳）
+	-- ARGV[1] 协程唯一标识：客户端标识+协程ID
+	return redis.call('zrem',KEYS[1],ARGV[1])
+`)
+
+	registerRWMutexMemoryScripts()
 }
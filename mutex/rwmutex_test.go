@@ -10,6 +10,8 @@ import (
 
 	"github.com/go-redis/redis/v8"
 
+	"github.com/MaricoHan/redisson/pkg/loggers"
+	"github.com/MaricoHan/redisson/pkg/types"
 	"github.com/MaricoHan/redisson/pkg/utils"
 )
 
@@ -17,6 +19,7 @@ var (
 	rwMutex = NewRWMutex(&Root{
 		Client: redis.NewClient(&redis.Options{Addr: ":6379"}),
 		UUID:   "uuid",
+		Logger: loggers.Logger(),
 	}, "rwMutexKey", []Option{
 		WithExpireDuration(10 * time.Second),
 		WithWaitTimeout(20 * time.Second),
@@ -141,7 +144,7 @@ func TestMutex_Lock_Renewal(t *testing.T) {
 	// 测试：达到过期时间的 1/3，如果未主动释放锁，写锁的过期时间会被重置
 	ticker := time.Tick(time.Second)
 	for range ticker {
-		fmt.Println(rwMutex.root.Client.PTTL(context.Background(), rwMutex.Name).Val())
+		fmt.Println(rwMutex.root.Client.PTTL(context.Background(), rwMutex.key).Val())
 	}
 
 	time.After(2 * time.Minute)
@@ -158,8 +161,174 @@ func TestMutex_RLock_Renewal(t *testing.T) {
 	// 测试：达到过期时间的 1/3，如果未主动释放锁，读锁的过期时间会被重置
 	ticker := time.Tick(time.Second)
 	for range ticker {
-		fmt.Println(rwMutex.root.Client.PTTL(context.Background(), rwMutex.Name).Val())
+		fmt.Println(rwMutex.root.Client.PTTL(context.Background(), rwMutex.key).Val())
 	}
 
 	time.After(2 * time.Minute)
 }
+
+// TestRWMutex_WriterPreferred
+// @Description: 测试：写优先模式下，有写者等待时，新的读者会排在写者之后
+// @param t
+func TestRWMutex_WriterPreferred(t *testing.T) {
+	root := rwMutex.root
+	opts := []Option{WithWriterPreferred(), WithWaitTimeout(20 * time.Second)}
+
+	reader := NewRWMutex(root, "writerPreferredKey", opts...)
+	err := reader.RLock(context.Background())
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	t.Log("rLock successfully")
+
+	order := make(chan string, 2)
+	group := sync.WaitGroup{}
+	group.Add(2)
+	go func() {
+		defer group.Done()
+		writer := NewRWMutex(root, "writerPreferredKey", opts...)
+		if err := writer.Lock(context.Background()); err != nil {
+			t.Error(err)
+			return
+		}
+		order <- "writer"
+		if err := writer.Unlock(context.Background()); err != nil {
+			t.Error(err)
+		}
+	}()
+	<-time.After(time.Second) // 等待写者登记写意向
+
+	go func() {
+		defer group.Done()
+		newReader := NewRWMutex(root, "writerPreferredKey", opts...)
+		if err := newReader.RLock(context.Background()); err != nil {
+			t.Error(err)
+			return
+		}
+		order <- "reader"
+		if err := newReader.Unlock(context.Background()); err != nil {
+			t.Error(err)
+		}
+	}()
+	<-time.After(time.Second)
+
+	err = reader.Unlock(context.Background())
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	group.Wait()
+
+	if first := <-order; first != "writer" {
+		t.Errorf("writer should acquire the lock before the new reader, got: %s", first)
+	}
+}
+
+// TestRWMutex_Downgrade_TryUpgrade
+// @Description: 测试：写锁可以降级为读锁；仅当唯一的读者时才能升级为写锁
+// @param t
+func TestRWMutex_Downgrade_TryUpgrade(t *testing.T) {
+	m := NewRWMutex(rwMutex.root, "downgradeKey")
+
+	err := m.Lock(context.Background())
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	t.Log("lock successfully")
+
+	err = m.Downgrade(context.Background())
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	t.Log("downgrade successfully")
+
+	// 测试：降级后其他读者可以加读锁，此时无法升级
+	other := NewRWMutex(rwMutex.root, "downgradeKey")
+	locked, release := make(chan struct{}), make(chan struct{})
+	group := sync.WaitGroup{}
+	group.Add(1)
+	go func() {
+		defer group.Done()
+		if err := other.RLock(context.Background()); err != nil {
+			t.Error(err)
+			close(locked)
+			return
+		}
+		close(locked)
+		<-release
+		if err := other.Unlock(context.Background()); err != nil {
+			t.Error(err)
+		}
+	}()
+	<-locked
+
+	if err = m.TryUpgrade(context.Background()); err != types.ErrUpgradeConflict {
+		t.Errorf("expect upgrade conflict, got: %v", err)
+	}
+	close(release)
+	group.Wait()
+
+	// 测试：唯一的读者可以升级为写锁
+	err = m.TryUpgrade(context.Background())
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	t.Log("upgrade successfully")
+
+	err = m.Unlock(context.Background())
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	t.Log("unlock successfully")
+}
+
+// TestRWMutex_ReaderLease
+// @Description: 测试：每个读者的租约独立过期，崩溃的读者不会被其他读者的续期延续，也不会阻塞写者
+// @param t
+func TestRWMutex_ReaderLease(t *testing.T) {
+	// 模拟一个加锁后崩溃、不再续期的读者
+	dead := NewRWMutex(rwMutex.root, "readerLeaseKey", WithExpireDuration(time.Second))
+	_, err := dead.rLockInner(context.Background(), "dead-reader", int64(time.Second/time.Millisecond))
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	live := NewRWMutex(rwMutex.root, "readerLeaseKey", WithExpireDuration(3*time.Second))
+	err = live.RLock(context.Background())
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	t.Log("rLock successfully")
+
+	// 等待崩溃读者的租约过期，期间存活读者会续期
+	<-time.After(2500 * time.Millisecond)
+	if live.root.Client.HExists(context.Background(), live.key, "dead-reader").Val() {
+		t.Error("expired reader should be pruned during renewal")
+		return
+	}
+
+	err = live.Unlock(context.Background())
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	writer := NewRWMutex(rwMutex.root, "readerLeaseKey")
+	pTTL, err := writer.lockInner(context.Background(), "writer", int64(writer.options.expiration/time.Millisecond))
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if pTTL != 0 {
+		t.Errorf("writer should not be blocked by expired reader, pTTL: %d", pTTL)
+		return
+	}
+	t.Log("lock successfully")
+}
@@ -0,0 +1,57 @@
+package mutex
+
+import (
+	"math/rand"
+	"time"
+)
+
+// WaitStrategy 决定等待锁时两次重试之间的间隔，与解锁通知同时生效：
+// 先收到解锁通知则立即重试，否则按策略给出的间隔定时重试，避免错过通知后一直等到锁过期。
+// 策略会被多个协程并发使用，实现需要是无状态的
+type WaitStrategy interface {
+	// Next 返回第 attempt 次（从 1 开始）重试前的等待时间，pTTL 为锁当前的剩余过期时间。
+	// 实际等待时间不会超过 pTTL
+	Next(attempt int, pTTL time.Duration) time.Duration
+}
+
+// WaitStrategyFunc 将普通函数适配为 WaitStrategy
+type WaitStrategyFunc func(attempt int, pTTL time.Duration) time.Duration
+
+func (f WaitStrategyFunc) Next(attempt int, pTTL time.Duration) time.Duration {
+	return f(attempt, pTTL)
+}
+
+// UntilExpire 一直等到锁过期或收到解锁通知，是默认的等待策略
+func UntilExpire() WaitStrategy {
+	return WaitStrategyFunc(func(_ int, pTTL time.Duration) time.Duration {
+		return pTTL
+	})
+}
+
+// FixedInterval 每隔固定的时间轮询一次
+func FixedInterval(interval time.Duration) WaitStrategy {
+	return WaitStrategyFunc(func(_ int, _ time.Duration) time.Duration {
+		return interval
+	})
+}
+
+// ExponentialBackoff 指数退避：第 n 次重试前等待 base*2^(n-1)，最长不超过 limit。
+// jitter 取值 [0, 1]，表示随机缩短等待时间的最大比例，用于打散同时等待的客户端
+func ExponentialBackoff(base, limit time.Duration, jitter float64) WaitStrategy {
+	if jitter < 0 {
+		jitter = 0
+	}
+	if jitter > 1 {
+		jitter = 1
+	}
+	return WaitStrategyFunc(func(attempt int, _ time.Duration) time.Duration {
+		wait := limit
+		if attempt < 63 && base < limit>>uint(attempt-1) {
+			wait = base << uint(attempt-1)
+		}
+		if jitter > 0 && wait > 0 {
+			wait -= time.Duration(rand.Int63n(int64(float64(wait)*jitter) + 1))
+		}
+		return wait
+	})
+}
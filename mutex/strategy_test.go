@@ -0,0 +1,51 @@
+package mutex
+
+import (
+	"testing"
+	"time"
+)
+
+func TestFixedInterval(t *testing.T) {
+	strategy := FixedInterval(100 * time.Millisecond)
+	for attempt := 1; attempt <= 3; attempt++ {
+		if wait := strategy.Next(attempt, 10*time.Second); wait != 100*time.Millisecond {
+			t.Errorf("attempt %d: unexpected wait %v", attempt, wait)
+		}
+	}
+}
+
+func TestExponentialBackoff(t *testing.T) {
+	strategy := ExponentialBackoff(10*time.Millisecond, time.Second, 0)
+	expects := []time.Duration{
+		10 * time.Millisecond,
+		20 * time.Millisecond,
+		40 * time.Millisecond,
+		80 * time.Millisecond,
+		160 * time.Millisecond,
+		320 * time.Millisecond,
+		640 * time.Millisecond,
+		time.Second,
+		time.Second,
+	}
+	for i, expect := range expects {
+		if wait := strategy.Next(i+1, 10*time.Second); wait != expect {
+			t.Errorf("attempt %d: expect %v, got %v", i+1, expect, wait)
+		}
+	}
+
+	// 测试：重试次数很大时不会溢出
+	if wait := strategy.Next(1000, 10*time.Second); wait != time.Second {
+		t.Errorf("unexpected wait %v", wait)
+	}
+}
+
+func TestExponentialBackoff_Jitter(t *testing.T) {
+	strategy := ExponentialBackoff(100*time.Millisecond, time.Second, 0.5)
+	for i := 0; i < 100; i++ {
+		wait := strategy.Next(1, 10*time.Second)
+		if wait < 50*time.Millisecond || wait > 100*time.Millisecond {
+			t.Errorf("wait out of range: %v", wait)
+			return
+		}
+	}
+}
@@ -0,0 +1,82 @@
+package backend
+
+import (
+	"context"
+	"crypto/sha1"
+	"encoding/hex"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// Backend 是锁等对象访问存储的接口，屏蔽了脚本执行、pubsub 与过期时间查询的具体实现。
+// 默认实现基于 redis，另有基于内存的实现用于无 redis 环境下的单元测试
+type Backend interface {
+	// Eval 原子地执行脚本，脚本返回 nil 时 Cmd 的错误为 redis.Nil
+	Eval(ctx context.Context, script *Script, keys []string, args ...interface{}) *redis.Cmd
+	// PTTL 查询 key 的剩余过期时间，key 不存在时返回 -2，未设置过期时间时返回 -1
+	PTTL(ctx context.Context, key string) (time.Duration, error)
+	// Exists 查询 key 是否存在
+	Exists(ctx context.Context, key string) (bool, error)
+	// Subscribe 订阅频道，脚本中 publish 的消息会投递到返回的订阅上
+	Subscribe(ctx context.Context, channels ...string) Subscription
+}
+
+// Preloader 由支持预先上传脚本的 Backend 实现
+type Preloader interface {
+	// Preload 上传所有已登记的脚本或函数库，避免首次执行时的额外往返
+	Preload(ctx context.Context) error
+}
+
+// Subscription 是一个频道订阅
+type Subscription interface {
+	Channel() <-chan *redis.Message
+	Close() error
+}
+
+// Reconnector 由连接断开后会自动重新订阅的 Subscription 实现
+type Reconnector interface {
+	// Reconnected 在重新订阅成功后收到通知，重新订阅前发布的消息可能已经丢失
+	Reconnected() <-chan struct{}
+}
+
+// Script 是一段具名的 lua 脚本，Name 用于日志以及内存实现中查找对应的逻辑
+type Script struct {
+	Name string
+	Src  string
+
+	hash string
+}
+
+var scripts []*Script
+
+// NewScript 创建并登记一段脚本，应当只在包初始化时调用
+func NewScript(name, src string) *Script {
+	sum := sha1.Sum([]byte(src))
+	s := &Script{
+		Name: name,
+		Src:  src,
+		hash: hex.EncodeToString(sum[:]),
+	}
+	scripts = append(scripts, s)
+	return s
+}
+
+// Hash 返回脚本的 sha1，与 SCRIPT LOAD 的返回值一致
+func (s *Script) Hash() string {
+	return s.hash
+}
+
+// NowMillisLua 定义 lua 函数 nowMillis，返回 redis 服务器当前的时间戳(ms)，拼接在需要服务器时间的脚本开头
+const NowMillisLua = `
+	-- 当前 redis 服务器时间戳，单位：ms
+	local function nowMillis()
+		local t = redis.call('time')
+		return tonumber(t[1]) * 1000 + math.floor(tonumber(t[2]) / 1000)
+	end
+`
+
+// Scripts 返回所有已登记的脚本
+func Scripts() []*Script {
+	return scripts
+}
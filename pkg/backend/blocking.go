@@ -0,0 +1,55 @@
+package backend
+
+import (
+	"sync"
+
+	"github.com/go-redis/redis/v8"
+
+	"github.com/MaricoHan/redisson/pkg/loggers"
+)
+
+// BlockingClient 管理执行 BLPOP 等阻塞命令的专用客户端。
+// 阻塞命令会长时间占用连接，使用与调用方相同配置、但连接池独立的客户端，避免与锁等操作争抢连接
+type BlockingClient struct {
+	source redis.UniversalClient
+	logger loggers.Advanced
+
+	once   sync.Once
+	client redis.UniversalClient
+}
+
+// NewBlockingClient 创建专用客户端的管理者，专用客户端在首次使用时创建
+func NewBlockingClient(source redis.UniversalClient, logger loggers.Advanced) *BlockingClient {
+	return &BlockingClient{source: source, logger: logger}
+}
+
+// Client 返回专用客户端，source 为空(例如基于内存的 Backend)时返回 nil
+func (b *BlockingClient) Client() redis.UniversalClient {
+	b.once.Do(func() {
+		switch c := b.source.(type) {
+		case nil:
+		case *redis.Client:
+			opt := *c.Options()
+			b.client = redis.NewClient(&opt)
+		case *redis.ClusterClient:
+			opt := *c.Options()
+			b.client = redis.NewClusterClient(&opt)
+		case *redis.Ring:
+			opt := *c.Options()
+			b.client = redis.NewRing(&opt)
+		default:
+			b.logger.Warnf("无法为 %T 创建专用客户端，阻塞命令将与其他操作共用连接池", b.source)
+			b.client = b.source
+		}
+	})
+	return b.client
+}
+
+// Close 关闭已创建的专用客户端
+func (b *BlockingClient) Close() error {
+	b.once.Do(func() {}) // 尚未创建时，之后也不再创建
+	if b.client == nil || b.client == b.source {
+		return nil
+	}
+	return b.client.Close()
+}
@@ -0,0 +1,183 @@
+package backend
+
+import (
+	"context"
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/go-redis/redis/v8"
+
+	"github.com/MaricoHan/redisson/pkg/loggers"
+)
+
+// functionLibraryPrefix 是 redis 7 函数库库名与函数名的前缀
+const functionLibraryPrefix = "redisson"
+
+// functionLibrary 把所有已登记的脚本作为一个带版本号的函数库安装到 redis，通过 FCALL 调用。
+// 库名与函数名都包含版本号，不同版本的函数库可以同时存在，滚动发布期间新旧客户端各自调用自己版本的函数，
+// 不会互相覆盖；旧版本的函数库不会自动删除，确认不再使用后可以通过 FUNCTION DELETE 删除
+type functionLibrary struct {
+	client redis.UniversalClient
+	logger loggers.Advanced
+
+	version         string // 所有脚本 sha 的摘要
+	name            string // 库名，例如 redisson_1a2b3c4d
+	versionFunction string // 返回 version 的函数名，用于检查函数库是否已安装
+	code            string
+	functions       map[string]string // 脚本 sha -> 函数名
+
+	mu          sync.Mutex
+	installed   bool
+	unsupported bool // redis 版本低于 7，不支持函数
+}
+
+func newFunctionLibrary(client redis.UniversalClient, logger loggers.Advanced, scripts []*Script) *functionLibrary {
+	l := &functionLibrary{
+		client:    client,
+		logger:    logger,
+		functions: make(map[string]string, len(scripts)),
+	}
+
+	sum := sha1.New()
+	for _, script := range scripts {
+		sum.Write([]byte(script.Hash()))
+	}
+	l.version = hex.EncodeToString(sum.Sum(nil))
+	l.name = functionLibraryPrefix + "_" + l.version[:8]
+	l.versionFunction = l.name + "_version"
+
+	code := &strings.Builder{}
+	fmt.Fprintf(code, "#!lua name=%s\n", l.name)
+	fmt.Fprintf(code, "redis.register_function{function_name='%s', callback=function() return '%s' end, flags={'no-writes'}}\n",
+		l.versionFunction, l.version)
+	for _, script := range scripts {
+		name := l.functionName(script)
+		l.functions[script.Hash()] = name
+		fmt.Fprintf(code, "redis.register_function('%s', function(KEYS, ARGV)\n%s\nend)\n", name, script.Src)
+	}
+	l.code = code.String()
+
+	return l
+}
+
+// functionName 返回脚本对应的函数名，例如 mutex.lock 对应 redisson_1a2b3c4d_mutex_lock。
+// 函数名在 redis 中全局唯一，包含库名才能与其他版本的函数库共存
+func (l *functionLibrary) functionName(script *Script) string {
+	return l.name + "_" + strings.ReplaceAll(script.Name, ".", "_")
+}
+
+// ensure 首次使用时安装函数库
+func (l *functionLibrary) ensure(ctx context.Context) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.unsupported {
+		return errFunctionsUnsupported
+	}
+	if l.installed {
+		return nil
+	}
+	return l.installLocked(ctx)
+}
+
+// install 检查当前版本的函数库是否已安装，未安装时安装
+func (l *functionLibrary) install(ctx context.Context) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.installLocked(ctx)
+}
+
+func (l *functionLibrary) installLocked(ctx context.Context) error {
+	install := func(ctx context.Context, client *redis.Client) error {
+		version, err := client.Do(ctx, "fcall", l.versionFunction, 0).Text()
+		if err != nil && !isFunctionNotFound(err) {
+			return err
+		}
+		if version == l.version {
+			return nil
+		}
+
+		// 不使用 REPLACE：同名的库只可能是同一版本，由其他客户端并发安装
+		l.logger.Infof("安装函数库: %s, 版本: %s, 节点: %s", l.name, l.version, client.Options().Addr)
+		err = client.Do(ctx, "function", "load", l.code).Err()
+		if isLibraryExists(err) {
+			return nil
+		}
+		return err
+	}
+
+	var err error
+	switch c := l.client.(type) {
+	case *redis.ClusterClient:
+		err = c.ForEachMaster(ctx, install)
+	case *redis.Ring:
+		err = c.ForEachShard(ctx, install)
+	case *redis.Client:
+		err = install(ctx, c)
+	default:
+		err = fmt.Errorf("unsupported client for functions: %T", l.client)
+	}
+	if err != nil {
+		if isUnknownCommand(err) {
+			l.logger.Errorf("redis 版本不支持函数，改用脚本执行: %v", err)
+			l.unsupported = true
+			return errFunctionsUnsupported
+		}
+		l.logger.Errorf("安装函数库失败: %s, 错误: %v", l.name, err)
+		return err
+	}
+
+	l.installed = true
+	l.logger.Debugf("函数库已就绪: %s, 版本: %s", l.name, l.version)
+	return nil
+}
+
+// forget 标记函数库需要重新安装
+func (l *functionLibrary) forget() {
+	l.mu.Lock()
+	l.installed = false
+	l.mu.Unlock()
+}
+
+// call 以 FCALL 调用脚本对应的函数，ok 为 false 表示函数库中没有该脚本
+func (l *functionLibrary) call(ctx context.Context, script *Script, keys []string, args ...interface{}) (cmd *redis.Cmd, ok bool) {
+	name, ok := l.functions[script.Hash()]
+	if !ok {
+		return nil, false
+	}
+
+	cmdArgs := make([]interface{}, 0, 3+len(keys)+len(args))
+	cmdArgs = append(cmdArgs, "fcall", name, len(keys))
+	for _, key := range keys {
+		cmdArgs = append(cmdArgs, key)
+	}
+	cmdArgs = append(cmdArgs, args...)
+
+	cmd = redis.NewCmd(ctx, cmdArgs...)
+	if len(keys) > 0 {
+		// 集群模式下按第一个 key 路由
+		cmd.SetFirstKeyPos(3)
+	}
+	_ = l.client.Process(ctx, cmd)
+	return cmd, true
+}
+
+var errFunctionsUnsupported = fmt.Errorf("redis functions unsupported")
+
+// isFunctionNotFound 判断是否为函数库被删除导致的错误
+func isFunctionNotFound(err error) bool {
+	return err != nil && strings.Contains(err.Error(), "Function not found")
+}
+
+// isLibraryExists 判断是否为同名函数库已存在导致的错误
+func isLibraryExists(err error) bool {
+	return err != nil && strings.Contains(err.Error(), "already exists")
+}
+
+// isUnknownCommand 判断是否为 redis 版本过低不支持该命令导致的错误
+func isUnknownCommand(err error) bool {
+	return err != nil && strings.Contains(err.Error(), "unknown command")
+}
@@ -0,0 +1,68 @@
+package backend
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/go-redis/redis/v8"
+
+	"github.com/MaricoHan/redisson/pkg/loggers"
+)
+
+func TestRedis_Functions(t *testing.T) {
+	client := redis.NewClient(&redis.Options{Addr: ":6379"})
+	b := NewRedis(client, loggers.Logger(), WithFunctions()).(*redisBackend)
+
+	// 测试：无论 redis 是否支持函数，都可以正常执行
+	res, err := b.Eval(context.Background(), testScript, []string{"backendTestKey"}, "1").Text()
+	if err != nil || res != "1" {
+		t.Errorf("eval failed: %s, %v", res, err)
+		return
+	}
+
+	if err = b.Preload(context.Background()); errors.Is(err, errFunctionsUnsupported) {
+		t.Skip("redis version does not support functions")
+	} else if err != nil {
+		t.Error(err)
+		return
+	}
+	if version := client.Do(context.Background(), "fcall", b.functions.versionFunction, 0).Val(); version != b.functions.version {
+		t.Errorf("unexpected library version: %v", version)
+		return
+	}
+
+	// 测试：函数库被删除后回退为脚本执行，并在下次执行前重新安装
+	if err = client.Do(context.Background(), "function", "flush").Err(); err != nil {
+		t.Error(err)
+		return
+	}
+	res, err = b.Eval(context.Background(), testScript, []string{"backendTestKey"}, "2").Text()
+	if err != nil || res != "2" {
+		t.Errorf("eval after function flush failed: %s, %v", res, err)
+		return
+	}
+	res, err = b.Eval(context.Background(), testScript, []string{"backendTestKey"}, "3").Text()
+	if err != nil || res != "3" {
+		t.Errorf("eval after reinstall failed: %s, %v", res, err)
+		return
+	}
+	if version := client.Do(context.Background(), "fcall", b.functions.versionFunction, 0).Val(); version != b.functions.version {
+		t.Errorf("library should be reinstalled, version: %v", version)
+	}
+
+	// 测试：不同版本的函数库可以共存，安装新版本不影响旧版本的客户端
+	// 未登记的脚本，使函数库的版本不同
+	otherScript := &Script{Name: "backend.other", Src: "return 'other'", hash: "0123456789abcdef0123456789abcdef01234567"}
+	other := newFunctionLibrary(client, loggers.Logger(), []*Script{testScript, otherScript})
+	if err = other.install(context.Background()); err != nil {
+		t.Error(err)
+		return
+	}
+	defer client.Do(context.Background(), "function", "delete", other.name)
+	if version := client.Do(context.Background(), "fcall", b.functions.versionFunction, 0).Val(); version != b.functions.version {
+		t.Errorf("library should not be replaced by another version, version: %v", version)
+	}
+
+	client.Del(context.Background(), "backendTestKey")
+}
@@ -0,0 +1,231 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+
+	"github.com/MaricoHan/redisson/pkg/backend"
+	"github.com/MaricoHan/redisson/pkg/clock"
+)
+
+// ScriptFunc 是脚本在内存实现中的 Go 版本，返回值遵循 redis 的转换规则：
+// 整数返回 int64，字符串返回 string，lua 的 nil 返回 nil（对应 redis.Nil）
+type ScriptFunc func(s *Store, keys []string, args []string) interface{}
+
+var (
+	scriptFuncs   = make(map[string]ScriptFunc)
+	scriptFuncsMu sync.RWMutex
+)
+
+// RegisterScript 登记脚本的 Go 实现，name 与 backend.Script 的 Name 对应
+func RegisterScript(name string, fn ScriptFunc) {
+	scriptFuncsMu.Lock()
+	defer scriptFuncsMu.Unlock()
+	scriptFuncs[name] = fn
+}
+
+// Backend 是基于内存的 backend.Backend 实现，语义与 redis 实现一致，适用于单进程内的单元测试
+type Backend struct {
+	mu    sync.Mutex
+	store *Store
+	clock clock.Clock
+
+	subMu sync.Mutex
+	subs  map[string][]*subscription
+}
+
+var _ backend.Backend = (*Backend)(nil)
+
+// New 创建一个空的内存 Backend
+func New() *Backend {
+	return NewWithClock(clock.New())
+}
+
+// NewWithClock 创建一个使用指定时钟的内存 Backend，key 的过期由该时钟决定，
+// 配合 clock.Fake 可以在测试中瞬间触发过期
+func NewWithClock(c clock.Clock) *Backend {
+	return &Backend{
+		store: &Store{
+			entries: make(map[string]*entry),
+			now:     c.Now,
+		},
+		clock: c,
+		subs:  make(map[string][]*subscription),
+	}
+}
+
+func (b *Backend) Eval(ctx context.Context, script *backend.Script, keys []string, args ...interface{}) *redis.Cmd {
+	cmd := redis.NewCmd(ctx)
+
+	scriptFuncsMu.RLock()
+	fn, ok := scriptFuncs[script.Name]
+	scriptFuncsMu.RUnlock()
+	if !ok {
+		cmd.SetErr(fmt.Errorf("memory backend: unsupported script %s", script.Name))
+		return cmd
+	}
+
+	strArgs := make([]string, len(args))
+	for i := range args {
+		strArgs[i] = toString(args[i])
+	}
+
+	val, err := b.run(fn, keys, strArgs)
+	switch {
+	case err != nil:
+		cmd.SetErr(err)
+	case val == nil:
+		cmd.SetErr(redis.Nil)
+	default:
+		cmd.SetVal(val)
+	}
+	return cmd
+}
+
+// run 独占 Store 执行脚本，执行结束后投递脚本中发布的消息
+func (b *Backend) run(fn ScriptFunc, keys, args []string) (val interface{}, err error) {
+	b.mu.Lock()
+	defer func() {
+		r := recover()
+		published := b.store.published
+		b.store.published = nil
+		b.mu.Unlock()
+
+		// 与 redis 一样，脚本出错时已执行的操作不会回滚
+		for _, msg := range published {
+			b.publish(msg)
+		}
+		if r != nil {
+			rErr, ok := r.(error)
+			if !ok {
+				panic(r)
+			}
+			val, err = nil, rErr
+		}
+	}()
+
+	return fn(b.store, keys, args), nil
+}
+
+func (b *Backend) PTTL(_ context.Context, key string) (time.Duration, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	pTTL := b.store.PTTL(key)
+	if pTTL < 0 {
+		return time.Duration(pTTL), nil
+	}
+	return time.Duration(pTTL) * time.Millisecond, nil
+}
+
+func (b *Backend) Exists(_ context.Context, key string) (bool, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return b.store.Exists(key), nil
+}
+
+func (b *Backend) Subscribe(_ context.Context, channels ...string) backend.Subscription {
+	b.subMu.Lock()
+	defer b.subMu.Unlock()
+
+	sub := &subscription{
+		backend:  b,
+		channels: channels,
+		msgChan:  make(chan *redis.Message, 100),
+	}
+	for _, channel := range channels {
+		b.subs[channel] = append(b.subs[channel], sub)
+	}
+	return sub
+}
+
+func (b *Backend) publish(msg message) {
+	b.subMu.Lock()
+	defer b.subMu.Unlock()
+
+	for _, sub := range b.subs[msg.channel] {
+		m := &redis.Message{Channel: msg.channel, Payload: msg.payload}
+		select {
+		case sub.msgChan <- m:
+			continue
+		default:
+		}
+
+		// 与 redis 的订阅一样，订阅者消费过慢时丢弃消息
+		timer := b.clock.NewTimer(time.Second)
+		select {
+		case sub.msgChan <- m:
+		case <-timer.C():
+		}
+		timer.Stop()
+	}
+}
+
+type subscription struct {
+	backend  *Backend
+	channels []string
+	msgChan  chan *redis.Message
+	closed   bool
+}
+
+func (s *subscription) Channel() <-chan *redis.Message {
+	return s.msgChan
+}
+
+func (s *subscription) Close() error {
+	s.backend.subMu.Lock()
+	defer s.backend.subMu.Unlock()
+
+	if s.closed {
+		return nil
+	}
+	s.closed = true
+
+	for _, channel := range s.channels {
+		subs := s.backend.subs[channel]
+		for i := range subs {
+			if subs[i] == s {
+				subs = append(subs[:i], subs[i+1:]...)
+				break
+			}
+		}
+		if len(subs) == 0 {
+			delete(s.backend.subs, channel)
+			continue
+		}
+		s.backend.subs[channel] = subs
+	}
+	close(s.msgChan)
+	return nil
+}
+
+// toString 按 go-redis 的规则将参数转换为字符串
+func toString(arg interface{}) string {
+	switch v := arg.(type) {
+	case string:
+		return v
+	case []byte:
+		return string(v)
+	case int:
+		return strconv.FormatInt(int64(v), 10)
+	case int64:
+		return strconv.FormatInt(v, 10)
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case bool:
+		if v {
+			return "1"
+		}
+		return "0"
+	case time.Duration:
+		return strconv.FormatInt(int64(v), 10)
+	default:
+		return fmt.Sprint(v)
+	}
+}
@@ -0,0 +1,88 @@
+package memory
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+
+	"github.com/MaricoHan/redisson/pkg/backend"
+)
+
+var testScript = backend.NewScript("memory.test", "")
+
+func init() {
+	RegisterScript(testScript.Name, func(s *Store, keys []string, args []string) interface{} {
+		switch args[0] {
+		case "set":
+			s.Set(keys[0], args[1])
+			s.PExpire(keys[0], Int(args[2]))
+			s.Publish(keys[1], args[1])
+			return int64(1)
+		case "hset":
+			s.HSet(keys[0], args[1], args[1])
+			return int64(1)
+		}
+		return nil
+	})
+}
+
+func TestBackend_Eval(t *testing.T) {
+	b := New()
+	sub := b.Subscribe(context.Background(), "channel")
+	defer sub.Close()
+
+	res, err := b.Eval(context.Background(), testScript, []string{"key", "channel"}, "set", "value", 100).Int64()
+	if err != nil || res != 1 {
+		t.Errorf("unexpected result: %d, %v", res, err)
+		return
+	}
+
+	// 测试：脚本中发布的消息会投递给订阅者
+	select {
+	case msg := <-sub.Channel():
+		if msg.Channel != "channel" || msg.Payload != "value" {
+			t.Errorf("unexpected message: %v", msg)
+		}
+	case <-time.After(time.Second):
+		t.Error("message not received")
+	}
+
+	// 测试：返回 nil 时错误为 redis.Nil
+	if err = b.Eval(context.Background(), testScript, nil, "nil").Err(); err != redis.Nil {
+		t.Errorf("expect redis.Nil, got: %v", err)
+	}
+
+	// 测试：类型不符时与 redis 一样返回错误
+	if err = b.Eval(context.Background(), testScript, []string{"key"}, "hset", "field").Err(); err == nil {
+		t.Error("expect wrong type error")
+	}
+
+	// 测试：未登记的脚本返回错误
+	if err = b.Eval(context.Background(), &backend.Script{Name: "unknown"}, nil).Err(); err == nil {
+		t.Error("expect unsupported script error")
+	}
+}
+
+func TestBackend_Expire(t *testing.T) {
+	b := New()
+	_, err := b.Eval(context.Background(), testScript, []string{"key", "channel"}, "set", "value", 100).Result()
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	pTTL, _ := b.PTTL(context.Background(), "key")
+	if pTTL <= 0 || pTTL > 100*time.Millisecond {
+		t.Errorf("unexpected pTTL: %v", pTTL)
+	}
+
+	<-time.After(150 * time.Millisecond)
+	if exists, _ := b.Exists(context.Background(), "key"); exists {
+		t.Error("key should be expired")
+	}
+	if pTTL, _ = b.PTTL(context.Background(), "key"); pTTL != -2 {
+		t.Errorf("expect -2, got: %v", pTTL)
+	}
+}
@@ -0,0 +1,292 @@
+package memory
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"time"
+)
+
+// Store 是内存实现的 keyspace，脚本的 Go 实现通过它读写数据。
+// 脚本执行期间 Store 被独占，因此脚本内的所有操作是原子的
+type Store struct {
+	entries map[string]*entry
+	now     func() time.Time
+
+	published []message // 脚本执行期间发布的消息，执行结束后统一投递
+}
+
+type entry struct {
+	value    interface{} // string、map[string]string 或 map[string]float64(zset)
+	expireAt time.Time   // 零值表示不过期
+}
+
+type message struct {
+	channel string
+	payload string
+}
+
+// wrongTypeError 对 key 执行了与其类型不符的操作，与 redis 一样中止脚本
+type wrongTypeError struct {
+	key string
+}
+
+func (e wrongTypeError) Error() string {
+	return "WRONGTYPE Operation against a key holding the wrong kind of value: " + e.key
+}
+
+// Now 返回当前时间，相当于脚本中的 redis.call('time')
+func (s *Store) Now() time.Time {
+	return s.now()
+}
+
+// NowMillis 返回当前时间戳，单位：ms
+func (s *Store) NowMillis() int64 {
+	return s.now().UnixNano() / int64(time.Millisecond)
+}
+
+func (s *Store) lookup(key string) *entry {
+	e, ok := s.entries[key]
+	if !ok {
+		return nil
+	}
+	if !e.expireAt.IsZero() && !s.now().Before(e.expireAt) {
+		delete(s.entries, key)
+		return nil
+	}
+	return e
+}
+
+// Type 返回 key 的类型：none、string、hash 或 zset
+func (s *Store) Type(key string) string {
+	e := s.lookup(key)
+	if e == nil {
+		return "none"
+	}
+	switch e.value.(type) {
+	case string:
+		return "string"
+	case map[string]string:
+		return "hash"
+	default:
+		return "zset"
+	}
+}
+
+func (s *Store) Exists(key string) bool {
+	return s.lookup(key) != nil
+}
+
+func (s *Store) Del(keys ...string) int64 {
+	var n int64
+	for _, key := range keys {
+		if s.lookup(key) != nil {
+			delete(s.entries, key)
+			n++
+		}
+	}
+	return n
+}
+
+// PExpire 设置 key 的过期时间，key 不存在时返回 false
+func (s *Store) PExpire(key string, ms int64) bool {
+	e := s.lookup(key)
+	if e == nil {
+		return false
+	}
+	if ms <= 0 {
+		delete(s.entries, key)
+		return true
+	}
+	e.expireAt = s.now().Add(time.Duration(ms) * time.Millisecond)
+	return true
+}
+
+// PTTL 返回 key 的剩余过期时间(ms)，key 不存在时返回 -2，未设置过期时间时返回 -1
+func (s *Store) PTTL(key string) int64 {
+	e := s.lookup(key)
+	if e == nil {
+		return -2
+	}
+	if e.expireAt.IsZero() {
+		return -1
+	}
+	return int64(e.expireAt.Sub(s.now()) / time.Millisecond)
+}
+
+// Publish 发布消息，消息在脚本执行结束后投递
+func (s *Store) Publish(channel, payload string) {
+	s.published = append(s.published, message{channel: channel, payload: payload})
+}
+
+// ---------- string ----------
+
+func (s *Store) Get(key string) (string, bool) {
+	e := s.lookup(key)
+	if e == nil {
+		return "", false
+	}
+	v, ok := e.value.(string)
+	if !ok {
+		panic(wrongTypeError{key: key})
+	}
+	return v, true
+}
+
+// Set 设置字符串，与 redis 的 SET 一样会清除原有的过期时间
+func (s *Store) Set(key, value string) {
+	s.entries[key] = &entry{value: value}
+}
+
+// ---------- hash ----------
+
+func (s *Store) hash(key string, create bool) map[string]string {
+	e := s.lookup(key)
+	if e == nil {
+		if !create {
+			return nil
+		}
+		h := make(map[string]string)
+		s.entries[key] = &entry{value: h}
+		return h
+	}
+	h, ok := e.value.(map[string]string)
+	if !ok {
+		panic(wrongTypeError{key: key})
+	}
+	return h
+}
+
+func (s *Store) HGet(key, field string) (string, bool) {
+	v, ok := s.hash(key, false)[field]
+	return v, ok
+}
+
+func (s *Store) HSet(key, field, value string) {
+	s.hash(key, true)[field] = value
+}
+
+func (s *Store) HExists(key, field string) bool {
+	_, ok := s.hash(key, false)[field]
+	return ok
+}
+
+func (s *Store) HLen(key string) int64 {
+	return int64(len(s.hash(key, false)))
+}
+
+func (s *Store) HIncrBy(key, field string, incr int64) int64 {
+	h := s.hash(key, true)
+	n, _ := strconv.ParseInt(h[field], 10, 64)
+	n += incr
+	h[field] = strconv.FormatInt(n, 10)
+	return n
+}
+
+// HDel 删除字段，与 redis 一样，hash 为空时 key 随之删除
+func (s *Store) HDel(key string, fields ...string) int64 {
+	h := s.hash(key, false)
+	var n int64
+	for _, field := range fields {
+		if _, ok := h[field]; ok {
+			delete(h, field)
+			n++
+		}
+	}
+	if h != nil && len(h) == 0 {
+		delete(s.entries, key)
+	}
+	return n
+}
+
+// ---------- zset ----------
+
+// Z 是 zset 中的一个成员
+type Z struct {
+	Member string
+	Score  float64
+}
+
+func (s *Store) zset(key string, create bool) map[string]float64 {
+	e := s.lookup(key)
+	if e == nil {
+		if !create {
+			return nil
+		}
+		z := make(map[string]float64)
+		s.entries[key] = &entry{value: z}
+		return z
+	}
+	z, ok := e.value.(map[string]float64)
+	if !ok {
+		panic(wrongTypeError{key: key})
+	}
+	return z
+}
+
+func (s *Store) ZAdd(key string, score float64, member string) {
+	s.zset(key, true)[member] = score
+}
+
+func (s *Store) ZRem(key string, members ...string) int64 {
+	z := s.zset(key, false)
+	var n int64
+	for _, member := range members {
+		if _, ok := z[member]; ok {
+			delete(z, member)
+			n++
+		}
+	}
+	if z != nil && len(z) == 0 {
+		delete(s.entries, key)
+	}
+	return n
+}
+
+func (s *Store) ZCard(key string) int64 {
+	return int64(len(s.zset(key, false)))
+}
+
+// ZRange 返回按 score 升序排列的所有成员
+func (s *Store) ZRange(key string) []Z {
+	z := s.zset(key, false)
+	res := make([]Z, 0, len(z))
+	for member, score := range z {
+		res = append(res, Z{Member: member, Score: score})
+	}
+	sort.Slice(res, func(i, j int) bool {
+		if res[i].Score == res[j].Score {
+			return res[i].Member < res[j].Member
+		}
+		return res[i].Score < res[j].Score
+	})
+	return res
+}
+
+// ZRangeByScore 返回 score <= max 的成员
+func (s *Store) ZRangeByScore(key string, max float64) []string {
+	var res []string
+	for _, z := range s.ZRange(key) {
+		if z.Score > max {
+			break
+		}
+		res = append(res, z.Member)
+	}
+	return res
+}
+
+// ZRemRangeByScore 删除 score <= max 的成员
+func (s *Store) ZRemRangeByScore(key string, max float64) int64 {
+	return s.ZRem(key, s.ZRangeByScore(key, max)...)
+}
+
+// ---------- 参数转换 ----------
+
+// Int 将脚本参数转换为整数，相当于 lua 中的 tonumber
+func Int(arg string) int64 {
+	n, err := strconv.ParseInt(arg, 10, 64)
+	if err != nil {
+		panic(fmt.Errorf("value is not an integer: %s", arg))
+	}
+	return n
+}
@@ -0,0 +1,163 @@
+package backend
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+
+	"github.com/MaricoHan/redisson/pkg/loggers"
+)
+
+type redisBackend struct {
+	client    redis.UniversalClient
+	logger    loggers.Advanced
+	scripts   *scriptRegistry
+	functions *functionLibrary // 为空时不使用函数
+}
+
+// RedisOption 定义基于 redis 的 Backend 的配置选项
+type RedisOption func(b *redisBackend)
+
+// WithFunctions 把所有脚本作为 redis 7 函数库安装，并以 FCALL 调用；
+// 函数库不可用时(例如 redis 版本低于 7)回退为脚本执行
+func WithFunctions() RedisOption {
+	return func(b *redisBackend) {
+		b.functions = newFunctionLibrary(b.client, b.logger, Scripts())
+	}
+}
+
+// NewRedis 创建基于 redis 的 Backend，client 可以是单机、哨兵、集群或 Ring 客户端
+func NewRedis(client redis.UniversalClient, logger loggers.Advanced, opts ...RedisOption) Backend {
+	b := &redisBackend{
+		client:  client,
+		logger:  logger,
+		scripts: newScriptRegistry(client, logger),
+	}
+	for i := range opts {
+		opts[i](b)
+	}
+	return b
+}
+
+// Eval 开启函数时优先以 FCALL 调用，函数库不可用，或已被删除、被其他版本替换时回退为脚本执行
+func (b *redisBackend) Eval(ctx context.Context, script *Script, keys []string, args ...interface{}) *redis.Cmd {
+	if b.functions != nil && b.functions.ensure(ctx) == nil {
+		cmd, ok := b.functions.call(ctx, script, keys, args...)
+		if !ok {
+			return b.evalScript(ctx, script, keys, args...)
+		}
+		if !isFunctionNotFound(cmd.Err()) {
+			return cmd
+		}
+		b.logger.Warnf("函数库已被删除或替换，改用脚本执行并在下次重新安装: %s", script.Name)
+		b.functions.forget()
+	}
+	return b.evalScript(ctx, script, keys, args...)
+}
+
+// evalScript 优先以 EVALSHA 执行脚本；脚本上传失败，或 redis 重启、SCRIPT FLUSH 导致脚本缓存丢失(NOSCRIPT)时，
+// 改用 EVAL 执行，并在下次执行前重新上传
+func (b *redisBackend) evalScript(ctx context.Context, script *Script, keys []string, args ...interface{}) *redis.Cmd {
+	if err := b.scripts.load(ctx, script); err != nil {
+		b.logger.Warnf("脚本上传失败，改用 EVAL 执行: %s, 错误: %v", script.Name, err)
+		return b.client.Eval(ctx, script.Src, keys, args...)
+	}
+
+	cmd := b.client.EvalSha(ctx, script.Hash(), keys, args...)
+	if isNoScript(cmd.Err()) {
+		b.logger.Warnf("redis 中的脚本缓存已丢失，改用 EVAL 执行并在下次重新上传: %s", script.Name)
+		b.scripts.forget(script)
+		return b.client.Eval(ctx, script.Src, keys, args...)
+	}
+	return cmd
+}
+
+// Preload 把所有已登记的脚本上传到 redis，集群模式下上传到每个主节点；
+// 开启函数时改为检查函数库版本，版本不一致时升级
+func (b *redisBackend) Preload(ctx context.Context) error {
+	if b.functions != nil {
+		return b.functions.install(ctx)
+	}
+
+	for _, script := range Scripts() {
+		b.scripts.forget(script)
+		if err := b.scripts.load(ctx, script); err != nil {
+			return err
+		}
+	}
+	b.logger.Infof("预加载脚本完成，共 %d 个", len(Scripts()))
+	return nil
+}
+
+func (b *redisBackend) PTTL(ctx context.Context, key string) (time.Duration, error) {
+	return b.client.PTTL(ctx, key).Result()
+}
+
+func (b *redisBackend) Exists(ctx context.Context, key string) (bool, error) {
+	n, err := b.client.Exists(ctx, key).Result()
+	return n == 1, err
+}
+
+func (b *redisBackend) Subscribe(ctx context.Context, channels ...string) Subscription {
+	s := &redisSubscription{
+		pubSub:      b.client.Subscribe(ctx, channels...),
+		msgChan:     make(chan *redis.Message, 100),
+		reconnected: make(chan struct{}, 1),
+		done:        make(chan struct{}),
+	}
+	go s.receive(ctx)
+	return s
+}
+
+// redisSubscription 基于 go-redis 的订阅，连接断开时由 go-redis 自动重连并重新订阅
+type redisSubscription struct {
+	pubSub      *redis.PubSub
+	msgChan     chan *redis.Message
+	reconnected chan struct{}
+	done        chan struct{}
+	closeOnce   sync.Once
+}
+
+// receive 转发消息；同一频道再次收到订阅确认，说明连接断开后重新订阅过
+func (s *redisSubscription) receive(ctx context.Context) {
+	defer close(s.msgChan)
+
+	subscribed := make(map[string]bool)
+	for msg := range s.pubSub.ChannelWithSubscriptions(ctx, cap(s.msgChan)) {
+		switch msg := msg.(type) {
+		case *redis.Subscription:
+			if msg.Kind != "subscribe" {
+				continue
+			}
+			if !subscribed[msg.Channel] {
+				subscribed[msg.Channel] = true
+				continue
+			}
+			select {
+			case s.reconnected <- struct{}{}:
+			default:
+			}
+		case *redis.Message:
+			select {
+			case s.msgChan <- msg:
+			case <-s.done:
+				return
+			}
+		}
+	}
+}
+
+func (s *redisSubscription) Channel() <-chan *redis.Message {
+	return s.msgChan
+}
+
+func (s *redisSubscription) Reconnected() <-chan struct{} {
+	return s.reconnected
+}
+
+func (s *redisSubscription) Close() error {
+	s.closeOnce.Do(func() { close(s.done) })
+	return s.pubSub.Close()
+}
@@ -0,0 +1,97 @@
+package backend
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	"github.com/go-redis/redis/v8"
+
+	"github.com/MaricoHan/redisson/pkg/loggers"
+)
+
+var testScript = NewScript("backend.test", `
+	-- KEYS[1] key
+	-- ARGV[1] 值
+	redis.call('set',KEYS[1],ARGV[1])
+	return redis.call('get',KEYS[1])
+`)
+
+func newTestBackend() Backend {
+	return NewRedis(redis.NewClient(&redis.Options{Addr: ":6379"}), loggers.Logger())
+}
+
+func TestRedis_Eval_NoScript(t *testing.T) {
+	b := newTestBackend()
+	client := b.(*redisBackend).client
+
+	res, err := b.Eval(context.Background(), testScript, []string{"backendTestKey"}, "1").Text()
+	if err != nil || res != "1" {
+		t.Errorf("eval failed: %s, %v", res, err)
+		return
+	}
+
+	// 测试：脚本缓存被清空后自动回退为 EVAL，并在下次执行前重新上传
+	if err = client.ScriptFlush(context.Background()).Err(); err != nil {
+		t.Error(err)
+		return
+	}
+	res, err = b.Eval(context.Background(), testScript, []string{"backendTestKey"}, "2").Text()
+	if err != nil || res != "2" {
+		t.Errorf("eval after script flush failed: %s, %v", res, err)
+		return
+	}
+	res, err = b.Eval(context.Background(), testScript, []string{"backendTestKey"}, "3").Text()
+	if err != nil || res != "3" {
+		t.Errorf("eval after reload failed: %s, %v", res, err)
+		return
+	}
+	if exists := client.ScriptExists(context.Background(), testScript.Hash()).Val(); len(exists) != 1 || !exists[0] {
+		t.Error("script should be reloaded")
+	}
+
+	client.Del(context.Background(), "backendTestKey")
+}
+
+func TestRedis_Eval_Concurrent(t *testing.T) {
+	b := newTestBackend()
+
+	wg := sync.WaitGroup{}
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := b.Eval(context.Background(), testScript, []string{"backendTestKey"}, "1").Err(); err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	b.(*redisBackend).client.Del(context.Background(), "backendTestKey")
+}
+
+func TestRedis_Preload(t *testing.T) {
+	b := newTestBackend()
+	client := b.(*redisBackend).client
+
+	if err := client.ScriptFlush(context.Background()).Err(); err != nil {
+		t.Error(err)
+		return
+	}
+	if err := b.(Preloader).Preload(context.Background()); err != nil {
+		t.Error(err)
+		return
+	}
+
+	// 测试：预加载后所有已登记的脚本都已上传
+	hashes := make([]string, 0, len(Scripts()))
+	for _, script := range Scripts() {
+		hashes = append(hashes, script.Hash())
+	}
+	for i, exists := range client.ScriptExists(context.Background(), hashes...).Val() {
+		if !exists {
+			t.Errorf("script not loaded: %s", Scripts()[i].Name)
+		}
+	}
+}
@@ -0,0 +1,90 @@
+package backend
+
+import (
+	"context"
+	"strings"
+	"sync"
+
+	"github.com/go-redis/redis/v8"
+
+	"github.com/MaricoHan/redisson/pkg/loggers"
+)
+
+// scriptRegistry 记录已上传到 redis 的脚本，每个 Backend(即每个 mutex.Root)独立持有一份
+type scriptRegistry struct {
+	client redis.UniversalClient
+	logger loggers.Advanced
+
+	mu     sync.RWMutex
+	loaded map[string]struct{} // 已上传的脚本 sha
+
+	loadMu sync.Mutex // 串行化上传，避免并发时重复上传同一脚本
+}
+
+func newScriptRegistry(client redis.UniversalClient, logger loggers.Advanced) *scriptRegistry {
+	return &scriptRegistry{
+		client: client,
+		logger: logger,
+		loaded: make(map[string]struct{}),
+	}
+}
+
+func (r *scriptRegistry) isLoaded(script *Script) bool {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	_, ok := r.loaded[script.Hash()]
+	return ok
+}
+
+// load 上传尚未上传的脚本，并发调用时只会上传一次
+func (r *scriptRegistry) load(ctx context.Context, script *Script) error {
+	if r.isLoaded(script) {
+		return nil
+	}
+
+	r.loadMu.Lock()
+	defer r.loadMu.Unlock()
+	if r.isLoaded(script) {
+		return nil
+	}
+
+	r.logger.Debugf("加载脚本: %s", script.Name)
+	if err := r.scriptLoad(ctx, script); err != nil {
+		r.logger.Errorf("加载脚本失败: %s, 错误: %v", script.Name, err)
+		return err
+	}
+	r.logger.Debugf("加载脚本成功: %s, sha: %s", script.Name, script.Hash())
+
+	r.mu.Lock()
+	r.loaded[script.Hash()] = struct{}{}
+	r.mu.Unlock()
+	return nil
+}
+
+// forget 标记脚本需要重新上传
+func (r *scriptRegistry) forget(script *Script) {
+	r.mu.Lock()
+	delete(r.loaded, script.Hash())
+	r.mu.Unlock()
+}
+
+// scriptLoad 执行 SCRIPT LOAD；集群与 Ring 中 EVALSHA 按 key 路由到不同节点，因此需要上传到每个节点
+func (r *scriptRegistry) scriptLoad(ctx context.Context, script *Script) error {
+	load := func(ctx context.Context, client *redis.Client) error {
+		return client.ScriptLoad(ctx, script.Src).Err()
+	}
+
+	switch c := r.client.(type) {
+	case *redis.ClusterClient:
+		return c.ForEachMaster(ctx, load)
+	case *redis.Ring:
+		return c.ForEachShard(ctx, load)
+	default:
+		return r.client.ScriptLoad(ctx, script.Src).Err()
+	}
+}
+
+// isNoScript 判断是否为脚本缓存丢失导致的错误
+func isNoScript(err error) bool {
+	return err != nil && strings.HasPrefix(err.Error(), "NOSCRIPT")
+}
@@ -0,0 +1,112 @@
+// Package clientcache 基于 redis 6 的 CLIENT TRACKING(BCAST 模式)实现客户端缓存：
+// 在专用连接上订阅 __redis__:invalidate，redis 中被跟踪的 key 发生变化时删除本地缓存中对应的值
+package clientcache
+
+import (
+	"container/list"
+	"sync"
+)
+
+// Cache 是有容量上限的本地缓存，按最近访问淘汰。
+// 值以 redis key 与 field 两级保存，field 为空表示整个 key(例如 Bucket)，否则为 hash 中的 field(例如 Map)；
+// redis 的失效通知以 key 为单位，删除时删除 key 下的所有 field
+type Cache struct {
+	mu    sync.Mutex
+	size  int
+	order *list.List // 表头为最近访问的值
+	items map[string]map[string]*list.Element
+	// generation 每次删除值时递增，从 redis 加载期间发生变化时，加载的值可能已经过时，不写入缓存
+	generation uint64
+}
+
+type entry struct {
+	key   string
+	field string
+	value string
+}
+
+// New 创建最多保存 size 个值的缓存，size 小于等于 0 时不限制
+func New(size int) *Cache {
+	return &Cache{
+		size:  size,
+		order: list.New(),
+		items: make(map[string]map[string]*list.Element),
+	}
+}
+
+// Load 优先从缓存读取，未命中时调用 load 从 redis 加载并写入缓存；值不存在时不缓存
+func (c *Cache) Load(key, field string, load func() (string, bool, error)) (string, bool, error) {
+	c.mu.Lock()
+	if e, ok := c.items[key][field]; ok {
+		c.order.MoveToFront(e)
+		c.mu.Unlock()
+		return e.Value.(*entry).value, true, nil
+	}
+	generation := c.generation
+	c.mu.Unlock()
+
+	value, ok, err := load()
+	if err != nil || !ok {
+		return value, ok, err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.generation == generation {
+		c.put(key, field, value)
+	}
+	return value, true, nil
+}
+
+// Invalidate 删除 key 下的所有值
+func (c *Cache) Invalidate(key string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for _, e := range c.items[key] {
+		c.order.Remove(e)
+	}
+	delete(c.items, key)
+	c.generation++
+}
+
+// Clear 清空缓存
+func (c *Cache) Clear() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.order.Init()
+	c.items = make(map[string]map[string]*list.Element)
+	c.generation++
+}
+
+// Len 返回缓存的值的数量
+func (c *Cache) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.order.Len()
+}
+
+func (c *Cache) put(key, field, value string) {
+	fields, ok := c.items[key]
+	if !ok {
+		fields = make(map[string]*list.Element)
+		c.items[key] = fields
+	}
+	if e, ok := fields[field]; ok {
+		e.Value.(*entry).value = value
+		c.order.MoveToFront(e)
+		return
+	}
+
+	if c.size > 0 && c.order.Len() >= c.size {
+		oldest := c.order.Back()
+		old := oldest.Value.(*entry)
+		c.order.Remove(oldest)
+		delete(c.items[old.key], old.field)
+		if len(c.items[old.key]) == 0 && old.key != key {
+			delete(c.items, old.key)
+		}
+	}
+	fields[field] = c.order.PushFront(&entry{key: key, field: field, value: value})
+}
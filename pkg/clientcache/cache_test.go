@@ -0,0 +1,86 @@
+package clientcache
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+
+	"github.com/MaricoHan/redisson/pkg/loggers"
+)
+
+func loader(value string, calls *int) func() (string, bool, error) {
+	return func() (string, bool, error) {
+		*calls++
+		return value, true, nil
+	}
+}
+
+func TestCache(t *testing.T) {
+	c := New(2)
+	var calls int
+
+	if v, _, _ := c.Load("map", "a", loader("1", &calls)); v != "1" || calls != 1 {
+		t.Errorf("unexpected value: %s, calls: %d", v, calls)
+	}
+	if v, _, _ := c.Load("map", "a", loader("x", &calls)); v != "1" || calls != 1 {
+		t.Errorf("value should be cached: %s, calls: %d", v, calls)
+	}
+	c.Load("map", "b", loader("2", &calls))
+	c.Load("bucket", "", loader("3", &calls)) // 淘汰最久未访问的 map.a
+	if c.Len() != 2 {
+		t.Errorf("unexpected len: %d", c.Len())
+	}
+	if _, _, _ = c.Load("map", "a", loader("1", &calls)); calls != 4 {
+		t.Errorf("map.a should be evicted, calls: %d", calls)
+	}
+
+	// 测试：以 key 为单位删除，只剩下 bucket
+	c.Invalidate("map")
+	if c.Len() != 1 {
+		t.Errorf("all fields should be invalidated: %d", c.Len())
+	}
+
+	// 测试：加载期间被删除的值不写入缓存
+	c.Load("other", "", func() (string, bool, error) {
+		c.Invalidate("other")
+		return "stale", true, nil
+	})
+	if c.Len() != 1 {
+		t.Errorf("stale value should not be cached: %d", c.Len())
+	}
+
+	// 测试：不存在的值不缓存
+	c.Load("missing", "", func() (string, bool, error) { return "", false, nil })
+	if c.Len() != 1 {
+		t.Errorf("absent value should not be cached: %d", c.Len())
+	}
+}
+
+func TestTrack(t *testing.T) {
+	client := redis.NewClient(&redis.Options{Addr: ":6379"})
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	c := New(100)
+	if err := Track(ctx, client, c, []string{"trackKey"}, loggers.Logger()); err != nil {
+		t.Skipf("client tracking is not supported: %v", err)
+	}
+
+	client.Set(ctx, "trackKey", "1", 0)
+	var calls int
+	c.Load("trackKey", "", loader("1", &calls))
+	if c.Len() != 1 {
+		t.Errorf("value should be cached: %d", c.Len())
+		return
+	}
+
+	client.Set(ctx, "trackKey", "2", 0)
+	for i := 0; i < 100 && c.Len() != 0; i++ {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if c.Len() != 0 {
+		t.Error("value should be invalidated by redis")
+	}
+}
@@ -0,0 +1,96 @@
+package clientcache
+
+import (
+	"context"
+	"errors"
+
+	"github.com/go-redis/redis/v8"
+
+	"github.com/MaricoHan/redisson/pkg/loggers"
+)
+
+// InvalidateChannel 是 redis 发布失效通知的频道
+const InvalidateChannel = "__redis__:invalidate"
+
+// ErrUnsupportedClient 客户端缓存需要在单个连接上开启跟踪，集群与 Ring 客户端的 key 分布在多个节点上，暂不支持
+var ErrUnsupportedClient = errors.New("client side caching only supports single node or sentinel client")
+
+// Track 在专用连接上开启 BCAST 模式的 CLIENT TRACKING，只跟踪以 prefixes 开头的 key(为空时跟踪所有 key)，
+// 并在同一连接上订阅失效通知，删除 cache 中对应的值，直到 ctx 被取消。
+//
+// 使用 RESP2 协议时，失效通知只能重定向到订阅了 __redis__:invalidate 的连接，
+// 因此在该连接建立(包括断线重连)后、订阅前，把跟踪重定向到连接自己；重连前的通知可能已经丢失，重连时清空缓存。
+// FLUSHALL/FLUSHDB 产生的通知不含 key，go-redis 无法解析，清空数据库后需要调用 Cache.Clear
+func Track(ctx context.Context, client redis.UniversalClient, cache *Cache, prefixes []string, logger loggers.Advanced) error {
+	c, ok := client.(*redis.Client)
+	if !ok {
+		return ErrUnsupportedClient
+	}
+
+	opt := *c.Options()
+	onConnect := opt.OnConnect
+	opt.OnConnect = func(ctx context.Context, cn *redis.Conn) error {
+		if onConnect != nil {
+			if err := onConnect(ctx, cn); err != nil {
+				return err
+			}
+		}
+
+		id, err := cn.ClientID(ctx).Result()
+		if err != nil {
+			return err
+		}
+		args := []interface{}{"client", "tracking", "on", "redirect", id, "bcast"}
+		for _, prefix := range prefixes {
+			args = append(args, "prefix", prefix)
+		}
+		cmd := redis.NewCmd(ctx, args...)
+		if err = cn.Process(ctx, cmd); err != nil {
+			logger.Errorf("开启客户端缓存跟踪失败: %v", err)
+			return err
+		}
+
+		cache.Clear()
+		logger.Infof("开启客户端缓存跟踪，连接: %d, 前缀: %v", id, prefixes)
+		return nil
+	}
+	// 专用客户端只用于订阅，不占用调用方的连接池
+	opt.PoolSize = 1
+	opt.MinIdleConns = 0
+	dedicated := redis.NewClient(&opt)
+
+	pubSub := dedicated.Subscribe(ctx, InvalidateChannel)
+	// 等待订阅完成，以便及时返回开启跟踪失败的错误
+	if _, err := pubSub.Receive(ctx); err != nil {
+		_ = pubSub.Close()
+		_ = dedicated.Close()
+		return err
+	}
+
+	go func() {
+		defer func() {
+			_ = pubSub.Close()
+			_ = dedicated.Close()
+			logger.Debug("关闭客户端缓存跟踪连接")
+		}()
+
+		ch := pubSub.Channel()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case msg, ok := <-ch:
+				if !ok {
+					return
+				}
+				for _, key := range msg.PayloadSlice {
+					cache.Invalidate(key)
+				}
+				if msg.Payload != "" {
+					cache.Invalidate(msg.Payload)
+				}
+			}
+		}
+	}()
+	return nil
+}
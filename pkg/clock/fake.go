@@ -0,0 +1,142 @@
+package clock
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// Fake 是可以手动推进的假时钟，只有调用 Advance 时时间才会流逝，定时器才会触发
+type Fake struct {
+	mu      sync.Mutex
+	cond    *sync.Cond
+	now     time.Time
+	waiters []*fakeWaiter
+}
+
+var _ Clock = (*Fake)(nil)
+
+// NewFake 创建一个从 now 开始的假时钟
+func NewFake(now time.Time) *Fake {
+	f := &Fake{now: now}
+	f.cond = sync.NewCond(&f.mu)
+	return f
+}
+
+func (f *Fake) Now() time.Time {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.now
+}
+
+func (f *Fake) NewTimer(d time.Duration) Timer {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	w := &fakeWaiter{clock: f, c: make(chan time.Time, 1)}
+	f.schedule(w, d)
+	return w
+}
+
+func (f *Fake) NewTicker(d time.Duration) Ticker {
+	if d <= 0 {
+		panic("non-positive interval for NewTicker")
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	w := &fakeWaiter{clock: f, c: make(chan time.Time, 1), period: d}
+	f.schedule(w, d)
+	return fakeTicker{w}
+}
+
+// Advance 推进时间，并按到期顺序触发期间到期的定时器
+func (f *Fake) Advance(d time.Duration) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	end := f.now.Add(d)
+	for {
+		sort.Slice(f.waiters, func(i, j int) bool {
+			return f.waiters[i].until.Before(f.waiters[j].until)
+		})
+		if len(f.waiters) == 0 || f.waiters[0].until.After(end) {
+			break
+		}
+
+		w := f.waiters[0]
+		f.now = w.until
+		// 与 time.Ticker 一样，接收方来不及消费时丢弃
+		select {
+		case w.c <- f.now:
+		default:
+		}
+		if w.period > 0 {
+			w.until = w.until.Add(w.period)
+		} else {
+			f.remove(w)
+		}
+	}
+	f.now = end
+}
+
+// BlockUntil 阻塞直到至少有 n 个定时器处于等待状态，用于在推进时间前确认被测协程已经开始等待
+func (f *Fake) BlockUntil(n int) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	for len(f.waiters) < n {
+		f.cond.Wait()
+	}
+}
+
+func (f *Fake) schedule(w *fakeWaiter, d time.Duration) {
+	w.until = f.now.Add(d)
+	f.waiters = append(f.waiters, w)
+	f.cond.Broadcast()
+}
+
+// remove 移除定时器，返回其是否处于等待状态
+func (f *Fake) remove(w *fakeWaiter) bool {
+	for i := range f.waiters {
+		if f.waiters[i] == w {
+			f.waiters = append(f.waiters[:i], f.waiters[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+type fakeWaiter struct {
+	clock  *Fake
+	c      chan time.Time
+	until  time.Time
+	period time.Duration // 大于 0 时为周期定时器
+}
+
+func (w *fakeWaiter) C() <-chan time.Time {
+	return w.c
+}
+
+func (w *fakeWaiter) Stop() bool {
+	w.clock.mu.Lock()
+	defer w.clock.mu.Unlock()
+	return w.clock.remove(w)
+}
+
+func (w *fakeWaiter) Reset(d time.Duration) bool {
+	w.clock.mu.Lock()
+	defer w.clock.mu.Unlock()
+
+	active := w.clock.remove(w)
+	w.clock.schedule(w, d)
+	return active
+}
+
+type fakeTicker struct {
+	*fakeWaiter
+}
+
+func (t fakeTicker) Stop() {
+	t.fakeWaiter.Stop()
+}
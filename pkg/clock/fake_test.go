@@ -0,0 +1,76 @@
+package clock
+
+import (
+	"testing"
+	"time"
+)
+
+func TestFake_Timer(t *testing.T) {
+	start := time.Unix(0, 0)
+	f := NewFake(start)
+	timer := f.NewTimer(time.Second)
+
+	f.Advance(999 * time.Millisecond)
+	select {
+	case <-timer.C():
+		t.Error("timer fired too early")
+		return
+	default:
+	}
+
+	f.Advance(time.Millisecond)
+	select {
+	case now := <-timer.C():
+		if !now.Equal(start.Add(time.Second)) {
+			t.Errorf("unexpected fire time: %v", now)
+		}
+	default:
+		t.Error("timer should fire")
+		return
+	}
+
+	// 测试：重置后重新计时，停止后不再触发
+	timer.Reset(time.Second)
+	if !timer.Stop() {
+		t.Error("timer should be active")
+	}
+	f.Advance(time.Hour)
+	select {
+	case <-timer.C():
+		t.Error("stopped timer should not fire")
+	default:
+	}
+}
+
+func TestFake_Ticker(t *testing.T) {
+	f := NewFake(time.Unix(0, 0))
+	ticker := f.NewTicker(time.Second)
+	defer ticker.Stop()
+
+	for i := 0; i < 3; i++ {
+		f.Advance(time.Second)
+		select {
+		case <-ticker.C():
+		default:
+			t.Errorf("tick %d missed", i)
+			return
+		}
+	}
+}
+
+func TestFake_BlockUntil(t *testing.T) {
+	f := NewFake(time.Unix(0, 0))
+	done := make(chan struct{})
+	go func() {
+		<-f.NewTimer(time.Minute).C()
+		close(done)
+	}()
+
+	f.BlockUntil(1)
+	f.Advance(time.Minute)
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Error("timer should fire after advance")
+	}
+}
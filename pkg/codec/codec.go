@@ -0,0 +1,124 @@
+// Package codec 定义分布式对象中值的编解码方式。
+// 同一个对象在不同服务中应当使用相同的编解码器，才能互相读取对方写入的值
+package codec
+
+import (
+	"bytes"
+	"encoding/gob"
+	"encoding/json"
+	"fmt"
+
+	"github.com/vmihailenco/msgpack/v5"
+	"google.golang.org/protobuf/proto"
+)
+
+// Codec 编解码分布式对象中的值
+type Codec interface {
+	// Marshal 将值编码为字节
+	Marshal(v interface{}) ([]byte, error)
+	// Unmarshal 将字节解码到 v 中，v 必须为指针
+	Unmarshal(data []byte, v interface{}) error
+}
+
+// JSON 返回基于 encoding/json 的编解码器，为默认的编解码器
+func JSON() Codec {
+	return jsonCodec{}
+}
+
+// MsgPack 返回基于 MessagePack 的编解码器，编码结果比 JSON 更紧凑
+func MsgPack() Codec {
+	return msgPackCodec{}
+}
+
+// Protobuf 返回基于 protobuf 的编解码器，值必须实现 proto.Message
+func Protobuf() Codec {
+	return protobufCodec{}
+}
+
+// Gob 返回基于 encoding/gob 的编解码器，仅适用于 Go 服务之间
+func Gob() Codec {
+	return gobCodec{}
+}
+
+// String 返回原样存取字符串的编解码器，值必须为 string 或 []byte，解码目标必须为 *string、*[]byte 或 *interface{}
+func String() Codec {
+	return stringCodec{}
+}
+
+type jsonCodec struct{}
+
+func (jsonCodec) Marshal(v interface{}) ([]byte, error) {
+	return json.Marshal(v)
+}
+
+func (jsonCodec) Unmarshal(data []byte, v interface{}) error {
+	return json.Unmarshal(data, v)
+}
+
+type msgPackCodec struct{}
+
+func (msgPackCodec) Marshal(v interface{}) ([]byte, error) {
+	return msgpack.Marshal(v)
+}
+
+func (msgPackCodec) Unmarshal(data []byte, v interface{}) error {
+	return msgpack.Unmarshal(data, v)
+}
+
+type protobufCodec struct{}
+
+func (protobufCodec) Marshal(v interface{}) ([]byte, error) {
+	m, ok := v.(proto.Message)
+	if !ok {
+		return nil, fmt.Errorf("protobuf codec: %T does not implement proto.Message", v)
+	}
+	return proto.Marshal(m)
+}
+
+func (protobufCodec) Unmarshal(data []byte, v interface{}) error {
+	m, ok := v.(proto.Message)
+	if !ok {
+		return fmt.Errorf("protobuf codec: %T does not implement proto.Message", v)
+	}
+	return proto.Unmarshal(data, m)
+}
+
+type gobCodec struct{}
+
+func (gobCodec) Marshal(v interface{}) ([]byte, error) {
+	buf := &bytes.Buffer{}
+	if err := gob.NewEncoder(buf).Encode(v); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func (gobCodec) Unmarshal(data []byte, v interface{}) error {
+	return gob.NewDecoder(bytes.NewReader(data)).Decode(v)
+}
+
+type stringCodec struct{}
+
+func (stringCodec) Marshal(v interface{}) ([]byte, error) {
+	switch s := v.(type) {
+	case string:
+		return []byte(s), nil
+	case []byte:
+		return s, nil
+	}
+	return nil, fmt.Errorf("string codec: unsupported type %T", v)
+}
+
+func (stringCodec) Unmarshal(data []byte, v interface{}) error {
+	switch p := v.(type) {
+	case *string:
+		*p = string(data)
+	case *[]byte:
+		*p = append((*p)[:0], data...)
+	case *interface{}:
+		*p = string(data)
+	default:
+		return fmt.Errorf("string codec: unsupported type %T", v)
+	}
+	return nil
+}
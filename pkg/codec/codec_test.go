@@ -0,0 +1,67 @@
+package codec
+
+import (
+	"testing"
+
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+)
+
+type user struct {
+	Name string
+	Age  int
+}
+
+func TestCodec(t *testing.T) {
+	for name, c := range map[string]Codec{
+		"json":    JSON(),
+		"msgpack": MsgPack(),
+		"gob":     Gob(),
+	} {
+		data, err := c.Marshal(user{Name: "redisson", Age: 3})
+		if err != nil {
+			t.Errorf("%s marshal failed: %v", name, err)
+			continue
+		}
+		var u user
+		if err = c.Unmarshal(data, &u); err != nil || u.Name != "redisson" || u.Age != 3 {
+			t.Errorf("%s unmarshal failed: %+v, %v", name, u, err)
+		}
+	}
+}
+
+func TestProtobuf(t *testing.T) {
+	c := Protobuf()
+	data, err := c.Marshal(wrapperspb.String("redisson"))
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	v := &wrapperspb.StringValue{}
+	if err = c.Unmarshal(data, v); err != nil || !proto.Equal(v, wrapperspb.String("redisson")) {
+		t.Errorf("unmarshal failed: %v, %v", v, err)
+		return
+	}
+
+	// 测试：值必须实现 proto.Message
+	if _, err = c.Marshal("redisson"); err == nil {
+		t.Error("expect error for non proto message")
+	}
+}
+
+func TestString(t *testing.T) {
+	c := String()
+	data, err := c.Marshal("redisson")
+	if err != nil || string(data) != "redisson" {
+		t.Errorf("marshal failed: %s, %v", data, err)
+		return
+	}
+	var s string
+	if err = c.Unmarshal(data, &s); err != nil || s != "redisson" {
+		t.Errorf("unmarshal failed: %s, %v", s, err)
+		return
+	}
+	if _, err = c.Marshal(1); err == nil {
+		t.Error("expect error for non string value")
+	}
+}
@@ -0,0 +1,208 @@
+// Package object 提供锁以外的分布式对象共用的部分，例如过期时间控制与删除
+package object
+
+import (
+	"context"
+	"time"
+
+	"github.com/MaricoHan/redisson/mutex"
+	"github.com/MaricoHan/redisson/pkg/backend"
+	"github.com/MaricoHan/redisson/pkg/codec"
+)
+
+var objectScript = struct {
+	expireScript      *backend.Script
+	clearExpireScript *backend.Script
+	deleteScript      *backend.Script
+}{}
+
+// Object 是一个分布式对象，由主 key 以及若干与主 key 位于同一个 hash slot 的附属 key 组成，
+// 过期时间控制与删除同时作用于所有 key
+type Object struct {
+	root *mutex.Root
+	name string
+	key  string
+	keys []string // 主 key 及附属 key
+
+	codec codec.Codec
+}
+
+// Option 定义分布式对象的可选项
+type Option func(o *Object)
+
+// WithCodec 指定对象使用的编解码器，覆盖 Config.Codec
+func WithCodec(c codec.Codec) Option {
+	return func(o *Object) {
+		o.codec = c
+	}
+}
+
+// New 创建分布式对象，companions 为附属 key 的后缀，见 CompanionKey
+func New(root *mutex.Root, name string, companions ...string) *Object {
+	o := &Object{
+		root: root,
+		name: name,
+		key:  root.Key(name),
+	}
+	o.keys = append(o.keys, o.key)
+	for _, suffix := range companions {
+		o.keys = append(o.keys, o.CompanionKey(suffix))
+	}
+	return o
+}
+
+// Companion 创建以附属 key 为主 key 的对象，与当前对象位于同一个 hash slot，并使用相同的编解码器
+func (o *Object) Companion(suffix string, companions ...string) *Object {
+	c := &Object{
+		root:  o.root,
+		name:  o.name + ":" + suffix,
+		key:   o.CompanionKey(suffix),
+		codec: o.codec,
+	}
+	c.keys = append(c.keys, c.key)
+	for _, s := range companions {
+		c.keys = append(c.keys, c.CompanionKey(s))
+	}
+	return c
+}
+
+// With 应用可选项，返回对象本身
+func (o *Object) With(opts ...Option) *Object {
+	for i := range opts {
+		opts[i](o)
+	}
+	return o
+}
+
+// Codec 返回对象使用的编解码器：优先使用对象单独指定的，其次为 Root 的，默认为 JSON
+func (o *Object) Codec() codec.Codec {
+	if o.codec != nil {
+		return o.codec
+	}
+	if o.root.Codec != nil {
+		return o.root.Codec
+	}
+	return codec.JSON()
+}
+
+// Encode 使用对象的编解码器编码值
+func (o *Object) Encode(v interface{}) ([]byte, error) {
+	data, err := o.Codec().Marshal(v)
+	if err != nil {
+		o.root.Logger.Errorf("编码失败: %s, 错误: %v", o.name, err)
+	}
+	return data, err
+}
+
+// Decode 使用对象的编解码器解码值
+func (o *Object) Decode(data []byte, v interface{}) error {
+	err := o.Codec().Unmarshal(data, v)
+	if err != nil {
+		o.root.Logger.Errorf("解码失败: %s, 错误: %v", o.name, err)
+	}
+	return err
+}
+
+// Name 返回对象名
+func (o *Object) Name() string {
+	return o.name
+}
+
+// Key 返回对象在 redis 中的主 key，已包含命名空间前缀
+func (o *Object) Key() string {
+	return o.key
+}
+
+// Root 返回对象所属的 Root
+func (o *Object) Root() *mutex.Root {
+	return o.root
+}
+
+// CompanionKey 返回与主 key 位于同一个 hash slot 的附属 key
+func (o *Object) CompanionKey(suffix string) string {
+	return "{" + o.key + "}:" + suffix
+}
+
+// CachedLoad 开启客户端缓存时优先从本地缓存读取 field(为空表示整个对象)对应的编码值，
+// 未命中时调用 load 从 redis 加载；未开启时直接调用 load
+func (o *Object) CachedLoad(field string, load func() (string, bool, error)) (string, bool, error) {
+	if o.root.ClientCache == nil {
+		return load()
+	}
+	return o.root.ClientCache.Load(o.key, field, load)
+}
+
+// InvalidateCache 删除客户端缓存中对象的值，修改对象后调用，使当前实例随后的读取不依赖 redis 的失效通知
+func (o *Object) InvalidateCache() {
+	if o.root.ClientCache != nil {
+		o.root.ClientCache.Invalidate(o.key)
+	}
+}
+
+// Expire 设置对象的过期时间，对象不存在时返回 false
+func (o *Object) Expire(ctx context.Context, ttl time.Duration) (bool, error) {
+	res, err := o.root.Eval(ctx, objectScript.expireScript, o.keys, int64(ttl/time.Millisecond)).Int64()
+	if err != nil {
+		o.root.Logger.Errorf("设置过期时间失败: %s, 错误: %v", o.name, err)
+		return false, err
+	}
+	return res == 1, nil
+}
+
+// ClearExpire 清除对象的过期时间，对象不存在或未设置过期时间时返回 false
+func (o *Object) ClearExpire(ctx context.Context) (bool, error) {
+	res, err := o.root.Eval(ctx, objectScript.clearExpireScript, o.keys).Int64()
+	if err != nil {
+		o.root.Logger.Errorf("清除过期时间失败: %s, 错误: %v", o.name, err)
+		return false, err
+	}
+	return res == 1, nil
+}
+
+// RemainTTL 查询对象的剩余过期时间，对象不存在时返回 0，未设置过期时间时返回 -1
+func (o *Object) RemainTTL(ctx context.Context) (time.Duration, error) {
+	return o.root.RemainTTL(ctx, o.key)
+}
+
+// IsExists 查询对象是否存在
+func (o *Object) IsExists(ctx context.Context) (bool, error) {
+	ttl, err := o.root.RemainTTL(ctx, o.key)
+	return ttl != 0, err
+}
+
+// Delete 删除对象，对象不存在时返回 false
+func (o *Object) Delete(ctx context.Context) (bool, error) {
+	defer o.InvalidateCache()
+
+	res, err := o.root.Eval(ctx, objectScript.deleteScript, o.keys).Int64()
+	if err != nil {
+		o.root.Logger.Errorf("删除对象失败: %s, 错误: %v", o.name, err)
+		return false, err
+	}
+	return res == 1, nil
+}
+
+func init() {
+	objectScript.expireScript = backend.NewScript("object.expire", `
+	-- KEYS 主 key 及附属 key
+	-- ARGV[1] 过期时间，单位：ms
+	for i = 2, #KEYS do
+		redis.call('pexpire',KEYS[i],ARGV[1])
+	end
+	return redis.call('pexpire',KEYS[1],ARGV[1])
+`)
+
+	objectScript.clearExpireScript = backend.NewScript("object.clearExpire", `
+	-- KEYS 主 key 及附属 key
+	for i = 2, #KEYS do
+		redis.call('persist',KEYS[i])
+	end
+	return redis.call('persist',KEYS[1])
+`)
+
+	objectScript.deleteScript = backend.NewScript("object.delete", `
+	-- KEYS 主 key 及附属 key
+	local n = redis.call('del',unpack(KEYS))
+	return n > 0 and 1 or 0
+`)
+}
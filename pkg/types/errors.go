@@ -7,8 +7,16 @@ import (
 const rootCodeSpace = "redisson"
 
 var (
-	ErrWaitTimeout = register(rootCodeSpace, 10000, "wait timeout")
-	ErrMismatch    = register(rootCodeSpace, 20001, "identity mismatch")
+	ErrWaitTimeout     = register(rootCodeSpace, 10000, "wait timeout")
+	ErrWaitCanceled    = register(rootCodeSpace, 10001, "wait canceled")
+	ErrMismatch        = register(rootCodeSpace, 20001, "identity mismatch")
+	ErrUpgradeConflict = register(rootCodeSpace, 20002, "other readers exist")
+
+	ErrRateNotSet        = register(rootCodeSpace, 30001, "rate is not set")
+	ErrPermitsExceedRate = register(rootCodeSpace, 30002, "permits exceed rate")
+
+	ErrBlockingUnsupported = register(rootCodeSpace, 40001, "blocking commands require a redis client")
+	ErrCapacityNotSet      = register(rootCodeSpace, 40002, "capacity is not set")
 )
 
 var usedCode = map[string]struct{}{}
@@ -23,6 +31,11 @@ func register(codeSpace string, code uint32, desc string) Error {
 	return err
 }
 
+// Wrap 用 cause 包装 e，返回的错误既可以通过 errors.Is 匹配 e，也可以匹配 cause
+func Wrap(e Error, cause error) error {
+	return wrapError{err: e, cause: cause}
+}
+
 type Error interface {
 	Error() string
 	Code() uint32
@@ -44,3 +57,18 @@ func (s sdkError) CodeSpace() string {
 func (s sdkError) Code() uint32 {
 	return s.code
 }
+
+type wrapError struct {
+	err   Error
+	cause error
+}
+
+func (w wrapError) Error() string {
+	return w.err.Error() + ": " + w.cause.Error()
+}
+func (w wrapError) Unwrap() error {
+	return w.cause
+}
+func (w wrapError) Is(target error) bool {
+	return target == w.err
+}
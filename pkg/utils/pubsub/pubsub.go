@@ -3,6 +3,8 @@ package pubsub
 import (
 	"sync"
 	"time"
+
+	"github.com/MaricoHan/redisson/pkg/clock"
 )
 
 var (
@@ -16,19 +18,23 @@ type PubSub struct {
 	msgChan            chan string
 	msgChanSize        int
 	msgChanSendTimeout time.Duration
-
-	chOnce sync.Once
+	clock              clock.Clock
+	closed             bool
 }
 
-func (p *PubSub) Channel() <-chan string {
-	p.chOnce.Do(func() {
-		// 设置默认值，后期可改为 option 模式作为入参
-		p.msgChanSize = 100
-		p.msgChanSendTimeout = time.Second
+// Option 是订阅的可选项
+type Option func(p *PubSub)
 
-		p.msgChan = make(chan string, p.msgChanSize)
-	})
+// WithClock 设置投递消息超时使用的时钟，默认为真实时间，传入 nil 时使用默认值
+func WithClock(c clock.Clock) Option {
+	return func(p *PubSub) {
+		if c != nil {
+			p.clock = c
+		}
+	}
+}
 
+func (p *PubSub) Channel() <-chan string {
 	return p.msgChan
 }
 
@@ -36,9 +42,11 @@ func (p *PubSub) Close() {
 	mu.Lock()
 	defer mu.Unlock()
 
-	if p.msgChan != nil {
-		close(p.msgChan)
+	if p.closed {
+		return
 	}
+	p.closed = true
+	close(p.msgChan)
 
 	subs, exists := channels[p.channelName]
 	if !exists {
@@ -56,17 +64,27 @@ func (p *PubSub) Close() {
 	// 如果没有订阅者了，则删除对应的频道
 	if len(subs) == 0 {
 		delete(channels, p.channelName)
+		return
 	}
+	channels[p.channelName] = subs
 }
 
-func Subscribe(channelName string) *PubSub {
+func Subscribe(channelName string, opts ...Option) *PubSub {
 	mu.Lock()
 	defer mu.Unlock()
 
 	pb := &PubSub{
 		channelName: channelName,
-		chOnce:      sync.Once{},
+		// 设置默认值，后期可改为 option 模式作为入参
+		msgChanSize:        100,
+		msgChanSendTimeout: time.Second,
+		clock:              clock.New(),
+	}
+	for i := range opts {
+		opts[i](pb)
 	}
+	// 在订阅时创建消息通道，避免与 Publish 并发访问
+	pb.msgChan = make(chan string, pb.msgChanSize)
 
 	channels[channelName] = append(channels[channelName], pb)
 
@@ -86,7 +104,16 @@ func Publish(channelName string, msg string) {
 	for _, sub := range subscribers {
 		select {
 		case sub.msgChan <- msg:
-		case <-time.After(sub.msgChanSendTimeout):
+			continue
+		default:
+		}
+
+		// 订阅者的缓冲区已满，最多等待 msgChanSendTimeout
+		timer := sub.clock.NewTimer(sub.msgChanSendTimeout)
+		select {
+		case sub.msgChan <- msg:
+		case <-timer.C():
 		}
+		timer.Stop()
 	}
 }
@@ -0,0 +1,187 @@
+package queue
+
+import (
+	"context"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+
+	"github.com/MaricoHan/redisson/mutex"
+	"github.com/MaricoHan/redisson/pkg/backend"
+	"github.com/MaricoHan/redisson/pkg/object"
+	"github.com/MaricoHan/redisson/pkg/types"
+)
+
+// blockInterval 阻塞命令每次等待的最长时间，到期后检查 ctx 是否已取消，再重新等待。
+// 不直接以 ctx 中断阻塞命令：读取超时会关闭连接，但 redis 可能已经取出元素，该元素会丢失
+const blockInterval = time.Second
+
+// BlockingQueue 是支持阻塞等待的队列，阻塞命令在专用客户端上执行，不占用锁等操作的连接池
+type BlockingQueue struct {
+	*Queue
+	blocker
+}
+
+func NewBlockingQueue(root *mutex.Root, client *backend.BlockingClient, name string, opts ...object.Option) *BlockingQueue {
+	q := NewQueue(root, name, opts...)
+	return &BlockingQueue{Queue: q, blocker: blocker{queue: q, client: client}}
+}
+
+// Take 取出队头元素并解码到 v 中，队列为空时一直等待，直到取到元素或 ctx 被取消。
+// 阻塞命令每次最多等待 1s 后才检查 ctx，因此 ctx 取消后最多延迟 1s 返回
+func (q *BlockingQueue) Take(ctx context.Context, v interface{}) error {
+	_, err := q.pop(ctx, head, mutex.WaitForever, v)
+	return err
+}
+
+// PollTimeout 取出队头元素，队列为空时最多等待 timeout(精度为 1s)；超时返回 false。
+// 阻塞命令每次最多等待 1s 后才检查 ctx，因此 ctx 取消后最多延迟 1s 返回
+func (q *BlockingQueue) PollTimeout(ctx context.Context, v interface{}, timeout time.Duration) (bool, error) {
+	return q.pop(ctx, head, timeout, v)
+}
+
+// BlockingDeque 是支持阻塞等待的双端队列
+type BlockingDeque struct {
+	*Deque
+	blocker
+}
+
+func NewBlockingDeque(root *mutex.Root, client *backend.BlockingClient, name string, opts ...object.Option) *BlockingDeque {
+	d := NewDeque(root, name, opts...)
+	return &BlockingDeque{Deque: d, blocker: blocker{queue: d.Queue, client: client}}
+}
+
+// Take 取出队头元素，与 TakeFirst 相同
+func (d *BlockingDeque) Take(ctx context.Context, v interface{}) error {
+	return d.TakeFirst(ctx, v)
+}
+
+// TakeFirst 取出队头元素，队列为空时一直等待，直到取到元素或 ctx 被取消；ctx 取消后最多延迟 1s 返回
+func (d *BlockingDeque) TakeFirst(ctx context.Context, v interface{}) error {
+	_, err := d.pop(ctx, head, mutex.WaitForever, v)
+	return err
+}
+
+// TakeLast 取出队尾元素，队列为空时一直等待，直到取到元素或 ctx 被取消；ctx 取消后最多延迟 1s 返回
+func (d *BlockingDeque) TakeLast(ctx context.Context, v interface{}) error {
+	_, err := d.pop(ctx, tail, mutex.WaitForever, v)
+	return err
+}
+
+// PollTimeout 取出队头元素，与 PollFirstTimeout 相同
+func (d *BlockingDeque) PollTimeout(ctx context.Context, v interface{}, timeout time.Duration) (bool, error) {
+	return d.PollFirstTimeout(ctx, v, timeout)
+}
+
+// PollFirstTimeout 取出队头元素，队列为空时最多等待 timeout(精度为 1s)；超时返回 false，ctx 取消后最多延迟 1s 返回
+func (d *BlockingDeque) PollFirstTimeout(ctx context.Context, v interface{}, timeout time.Duration) (bool, error) {
+	return d.pop(ctx, head, timeout, v)
+}
+
+// PollLastTimeout 取出队尾元素，队列为空时最多等待 timeout(精度为 1s)；超时返回 false，ctx 取消后最多延迟 1s 返回
+func (d *BlockingDeque) PollLastTimeout(ctx context.Context, v interface{}, timeout time.Duration) (bool, error) {
+	return d.pop(ctx, tail, timeout, v)
+}
+
+// blocker 实现阻塞取出
+type blocker struct {
+	queue  *Queue
+	client *backend.BlockingClient
+}
+
+// PollFromAny 依次检查当前队列以及 names 对应的队列，取出第一个非空队列的队头元素，都为空时最多等待 timeout。
+// 返回元素所在的队列名，超时返回 false，ctx 取消后最多延迟 1s 返回；集群模式下所有队列需要通过 hash tag 位于同一个 slot
+func (b blocker) PollFromAny(ctx context.Context, v interface{}, timeout time.Duration, names ...string) (string, bool, error) {
+	keys := make([]string, 0, len(names)+1)
+	keys = append(keys, b.queue.Key())
+	for _, name := range names {
+		keys = append(keys, b.queue.Root().Key(name))
+	}
+
+	key, data, ok, err := b.bpop(ctx, head, timeout, keys)
+	if err != nil || !ok {
+		return "", false, err
+	}
+
+	name := b.queue.Name()
+	for i := range names {
+		if keys[i+1] == key {
+			name = names[i]
+			break
+		}
+	}
+	return name, true, b.queue.Decode([]byte(data), v)
+}
+
+func (b blocker) pop(ctx context.Context, side string, timeout time.Duration, v interface{}) (bool, error) {
+	_, data, ok, err := b.bpop(ctx, side, timeout, []string{b.queue.Key()})
+	if err != nil || !ok {
+		return false, err
+	}
+	return true, b.queue.Decode([]byte(data), v)
+}
+
+// bpop 以 BLPOP/BRPOP 从 keys 中取出元素，timeout 为 mutex.WaitForever 时一直等待；返回元素所在的 key。
+// 截止时间基于 Root 的时钟计算
+func (b blocker) bpop(ctx context.Context, side string, timeout time.Duration, keys []string) (string, string, bool, error) {
+	client := b.client.Client()
+	if client == nil {
+		return "", "", false, types.ErrBlockingUnsupported
+	}
+
+	if ctx.Err() != nil {
+		return "", "", false, types.Wrap(types.ErrWaitCanceled, ctx.Err())
+	}
+
+	var deadline time.Time
+	if timeout != mutex.WaitForever {
+		deadline = b.queue.Root().Now().Add(timeout)
+	}
+
+	// 阻塞命令的超时以秒为单位，每次等待 blockInterval，因此实际等待时间会向上取整到 blockInterval 的整数倍
+	for {
+		var cmd *redis.StringSliceCmd
+		if side == head {
+			cmd = client.BLPop(detach(ctx), blockInterval, keys...)
+		} else {
+			cmd = client.BRPop(detach(ctx), blockInterval, keys...)
+		}
+		res, err := cmd.Result()
+		if err == nil {
+			return res[0], res[1], true, nil
+		}
+		if err != redis.Nil {
+			b.queue.Root().Logger.Errorf("阻塞取出队列元素失败: %s, 错误: %v", b.queue.Name(), err)
+			return "", "", false, err
+		}
+
+		if !deadline.IsZero() && !b.queue.Root().Now().Before(deadline) {
+			return "", "", false, nil
+		}
+		if ctx.Err() != nil {
+			b.queue.Root().Logger.Debugf("阻塞取出队列元素被调用方取消: %s, 原因: %v", b.queue.Name(), ctx.Err())
+			return "", "", false, types.Wrap(types.ErrWaitCanceled, ctx.Err())
+		}
+	}
+}
+
+// detached 保留 ctx 中的值，但去掉取消与截止时间，使阻塞命令不会因为 ctx 而中途放弃读取
+type detached struct {
+	context.Context
+}
+
+func detach(ctx context.Context) context.Context {
+	return detached{ctx}
+}
+
+func (detached) Deadline() (time.Time, bool) {
+	return time.Time{}, false
+}
+
+func (detached) Done() <-chan struct{} {
+	return nil
+}
+
+func (detached) Err() error {
+	return nil
+}
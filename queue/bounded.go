@@ -0,0 +1,295 @@
+package queue
+
+import (
+	"context"
+	"time"
+
+	"github.com/MaricoHan/redisson/mutex"
+	"github.com/MaricoHan/redisson/pkg/backend"
+	"github.com/MaricoHan/redisson/pkg/object"
+	"github.com/MaricoHan/redisson/pkg/types"
+	"github.com/MaricoHan/redisson/pkg/utils"
+	"github.com/MaricoHan/redisson/pkg/utils/pubsub"
+)
+
+var boundedQueueScript = struct {
+	trySetCapacityScript *backend.Script
+	offerScript          *backend.Script
+	pollScript           *backend.Script
+	drainScript          *backend.Script
+	notifyScript         *backend.Script
+	remainingScript      *backend.Script
+}{}
+
+const (
+	// boundedQueueActionRelease 元素被取出、腾出容量时发布的动作
+	boundedQueueActionRelease = "release"
+
+	// putRetryInterval 队列已满时，除通知外重新尝试添加的间隔，通知可能因为重连等原因丢失
+	putRetryInterval = time.Second
+)
+
+// BoundedBlockingQueue 是有界阻塞队列：容量保存在 redis 中，添加元素时原子地检查容量减去当前的元素数量，
+// 取出元素不需要归还容量，取出后通过实例共享的 pubsub 频道唤醒等待添加的生产者。
+// 使用前需要通过 TrySetCapacity 设置容量
+type BoundedBlockingQueue struct {
+	*BlockingQueue
+
+	capacityKey string // 容量
+}
+
+func NewBoundedBlockingQueue(root *mutex.Root, client *backend.BlockingClient, name string, opts ...object.Option) *BoundedBlockingQueue {
+	root.Logger.Debugf("创建有界阻塞队列实例: %s", name)
+
+	o := object.New(root, name, "capacity").With(opts...)
+	q := &Queue{Object: o}
+	return &BoundedBlockingQueue{
+		BlockingQueue: &BlockingQueue{Queue: q, blocker: blocker{queue: q, client: client}},
+		capacityKey:   o.CompanionKey("capacity"),
+	}
+}
+
+// TrySetCapacity 设置容量，容量已被设置时不会覆盖，返回 false
+func (q *BoundedBlockingQueue) TrySetCapacity(ctx context.Context, capacity int64) (bool, error) {
+	res, err := q.Root().Eval(ctx, boundedQueueScript.trySetCapacityScript, []string{q.capacityKey}, capacity).Int64()
+	if err != nil {
+		q.Root().Logger.Errorf("设置有界队列容量失败: %s, 错误: %v", q.Name(), err)
+		return false, err
+	}
+	if res == 0 {
+		q.Root().Logger.Debugf("有界队列容量已存在，不覆盖: %s", q.Name())
+		return false, nil
+	}
+
+	q.Root().Logger.Infof("设置有界队列容量成功: %s, 容量: %d", q.Name(), capacity)
+	return true, nil
+}
+
+// RemainingCapacity 返回剩余容量，即容量减去当前的元素数量；容量未设置时返回 types.ErrCapacityNotSet
+func (q *BoundedBlockingQueue) RemainingCapacity(ctx context.Context) (int64, error) {
+	res, err := q.Root().Eval(ctx, boundedQueueScript.remainingScript, []string{q.Key(), q.capacityKey}).Int64()
+	if err != nil {
+		q.Root().Logger.Errorf("查询有界队列剩余容量失败: %s, 错误: %v", q.Name(), err)
+		return 0, err
+	}
+	if res < 0 {
+		return 0, types.ErrCapacityNotSet
+	}
+	return res, nil
+}
+
+// Offer 在队尾添加元素，剩余容量不足以添加全部元素时不添加任何元素，立即返回 false
+func (q *BoundedBlockingQueue) Offer(ctx context.Context, v ...interface{}) (bool, error) {
+	if len(v) == 0 {
+		return true, nil
+	}
+
+	args := make([]interface{}, 0, len(v))
+	for i := range v {
+		data, err := q.Encode(v[i])
+		if err != nil {
+			return false, err
+		}
+		args = append(args, data)
+	}
+
+	res, err := q.Root().Eval(ctx, boundedQueueScript.offerScript, []string{q.Key(), q.capacityKey}, args...).Int64()
+	if err != nil {
+		q.Root().Logger.Errorf("添加有界队列元素失败: %s, 错误: %v", q.Name(), err)
+		return false, err
+	}
+	if res < 0 {
+		return false, types.ErrCapacityNotSet
+	}
+	return res == 1, nil
+}
+
+// Put 在队尾添加元素，队列已满时一直等待，直到腾出容量或 ctx 被取消
+func (q *BoundedBlockingQueue) Put(ctx context.Context, v interface{}) error {
+	// 先订阅，再添加，避免错过通知
+	sub := pubsub.Subscribe(utils.ChannelName(q.capacityKey), pubsub.WithClock(q.Root().Clock))
+	defer sub.Close()
+
+	timer := q.Root().NewTimer(putRetryInterval)
+	defer timer.Stop()
+
+	for {
+		ok, err := q.Offer(ctx, v)
+		if err != nil && ctx.Err() != nil {
+			return q.waitCanceled(ctx)
+		}
+		if err != nil || ok {
+			return err
+		}
+
+		q.Root().Logger.Debugf("有界队列已满: %s, 等待腾出容量", q.Name())
+		select {
+		case <-ctx.Done():
+			return q.waitCanceled(ctx)
+		case <-sub.Channel():
+			if !timer.Stop() {
+				select {
+				case <-timer.C():
+				default:
+				}
+			}
+		case <-timer.C():
+		}
+		timer.Reset(putRetryInterval)
+	}
+}
+
+// Poll 取出队头元素并唤醒等待添加的生产者；队列为空时返回 false
+func (q *BoundedBlockingQueue) Poll(ctx context.Context, v interface{}) (bool, error) {
+	keys := []string{q.Key(), q.Root().RedisChannelName}
+	return q.decode(q.Root().Eval(ctx, boundedQueueScript.pollScript, keys, q.releaseMessage()), v, "取出")
+}
+
+// DrainTo 原子地取出最多 max 个元素并唤醒等待添加的生产者，max 小于等于 0 时取出所有元素
+func (q *BoundedBlockingQueue) DrainTo(ctx context.Context, max int64) ([]Item, error) {
+	keys := []string{q.Key(), q.Root().RedisChannelName}
+	res, err := q.Root().Eval(ctx, boundedQueueScript.drainScript, keys, max, q.releaseMessage()).StringSlice()
+	if err != nil {
+		q.Root().Logger.Errorf("批量取出有界队列元素失败: %s, 错误: %v", q.Name(), err)
+		return nil, err
+	}
+
+	items := make([]Item, 0, len(res))
+	for _, data := range res {
+		items = append(items, q.item(data))
+	}
+	return items, nil
+}
+
+// Take 取出队头元素，队列为空时一直等待，直到取到元素或 ctx 被取消；ctx 取消后最多延迟 1s 返回
+func (q *BoundedBlockingQueue) Take(ctx context.Context, v interface{}) error {
+	_, err := q.PollTimeout(ctx, v, mutex.WaitForever)
+	return err
+}
+
+// PollTimeout 取出队头元素，队列为空时最多等待 timeout(精度为 1s)；超时返回 false，ctx 取消后最多延迟 1s 返回
+func (q *BoundedBlockingQueue) PollTimeout(ctx context.Context, v interface{}, timeout time.Duration) (bool, error) {
+	_, data, ok, err := q.bpop(ctx, head, timeout, []string{q.Key()})
+	if err != nil || !ok {
+		return false, err
+	}
+	q.notify(ctx, q.releaseMessage())
+	return true, q.Decode([]byte(data), v)
+}
+
+// PollFromAny 与 BlockingQueue.PollFromAny 相同，取出后唤醒元素所在队列上等待添加的生产者
+func (q *BoundedBlockingQueue) PollFromAny(ctx context.Context, v interface{}, timeout time.Duration, names ...string) (string, bool, error) {
+	name, ok, err := q.BlockingQueue.PollFromAny(ctx, v, timeout, names...)
+	if ok {
+		msg := q.releaseMessage()
+		if name != q.Name() {
+			// 元素所在的队列不是有界队列时没有订阅者，通知被忽略
+			msg = object.New(q.Root(), name).CompanionKey("capacity") + ":" + boundedQueueActionRelease
+		}
+		q.notify(ctx, msg)
+	}
+	return name, ok, err
+}
+
+// notify 阻塞取出元素后唤醒等待添加的生产者。阻塞命令无法在脚本中执行，取出与通知不是原子的，
+// 容量由添加时的元素数量决定，通知失败不会丢失容量，等待的生产者会在重新尝试时添加
+func (q *BoundedBlockingQueue) notify(ctx context.Context, msg string) {
+	err := q.Root().Eval(detach(ctx), boundedQueueScript.notifyScript, []string{q.Root().RedisChannelName}, msg).Err()
+	if err != nil {
+		q.Root().Logger.Warnf("通知有界队列腾出容量失败: %s, 错误: %v", q.Name(), err)
+	}
+}
+
+func (q *BoundedBlockingQueue) releaseMessage() string {
+	return q.capacityKey + ":" + boundedQueueActionRelease
+}
+
+// waitCanceled 返回等待被调用方取消的错误
+func (q *BoundedBlockingQueue) waitCanceled(ctx context.Context) error {
+	q.Root().Logger.Debugf("等待有界队列容量被调用方取消: %s, 原因: %v", q.Name(), ctx.Err())
+	return types.Wrap(types.ErrWaitCanceled, ctx.Err())
+}
+
+func init() {
+	boundedQueueScript.trySetCapacityScript = backend.NewScript("boundedQueue.trySetCapacity", `
+	-- KEYS[1] 容量
+	-- ARGV[1] 容量
+	if redis.call('exists',KEYS[1]) == 1 then
+		return 0
+	end
+	redis.call('set',KEYS[1],ARGV[1])
+	return 1
+`)
+
+	boundedQueueScript.offerScript = backend.NewScript("boundedQueue.offer", `
+	-- KEYS[1] 队列
+	-- KEYS[2] 容量
+	-- ARGV 元素
+	-- 返回值：1-添加成功 0-剩余容量不足 -1-容量未设置
+	local capacity = redis.call('get',KEYS[2])
+	if not capacity then
+		return -1
+	end
+	if tonumber(capacity) - redis.call('llen',KEYS[1]) < #ARGV then
+		return 0
+	end
+	-- 分批展开参数，unpack 的参数个数受 lua 栈大小的限制
+	for i = 1, #ARGV, 1000 do
+		redis.call('rpush',KEYS[1],unpack(ARGV,i,math.min(i+999,#ARGV)))
+	end
+	return 1
+`)
+
+	boundedQueueScript.pollScript = backend.NewScript("boundedQueue.poll", `
+	-- KEYS[1] 队列
+	-- KEYS[2] 发布订阅的channel
+	-- ARGV[1] 腾出容量后发布的消息
+	local value = redis.call('lpop',KEYS[1])
+	if value then
+		redis.call('publish',KEYS[2],ARGV[1])
+	end
+	return value
+`)
+
+	boundedQueueScript.drainScript = backend.NewScript("boundedQueue.drain", `
+	-- KEYS[1] 队列
+	-- KEYS[2] 发布订阅的channel
+	-- ARGV[1] 最多取出的数量，小于等于 0 时取出所有元素
+	-- ARGV[2] 腾出容量后发布的消息
+	local max = tonumber(ARGV[1])
+	if max <= 0 then
+		max = redis.call('llen',KEYS[1])
+	end
+	if max == 0 then
+		return {}
+	end
+	local values = redis.call('lrange',KEYS[1],0,max - 1)
+	redis.call('ltrim',KEYS[1],max,-1)
+	if #values > 0 then
+		redis.call('publish',KEYS[2],ARGV[2])
+	end
+	return values
+`)
+
+	boundedQueueScript.notifyScript = backend.NewScript("boundedQueue.notify", `
+	-- KEYS[1] 发布订阅的channel
+	-- ARGV[1] 腾出容量后发布的消息
+	redis.call('publish',KEYS[1],ARGV[1])
+	return 1
+`)
+
+	boundedQueueScript.remainingScript = backend.NewScript("boundedQueue.remaining", `
+	-- KEYS[1] 队列
+	-- KEYS[2] 容量
+	-- 返回值：剩余容量，容量未设置时返回 -1
+	local capacity = redis.call('get',KEYS[2])
+	if not capacity then
+		return -1
+	end
+	local remaining = tonumber(capacity) - redis.call('llen',KEYS[1])
+	if remaining < 0 then
+		return 0
+	end
+	return remaining
+`)
+}
@@ -0,0 +1,141 @@
+package queue_test
+
+import (
+	"context"
+	"errors"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+
+	"github.com/MaricoHan/redisson"
+	"github.com/MaricoHan/redisson/pkg/types"
+)
+
+// TestBoundedBlockingQueue
+// @Description: 测试：设置容量、剩余容量不足时添加失败、队列已满时 Put 等待并被取出元素唤醒
+// @param t
+func TestBoundedBlockingQueue(t *testing.T) {
+	client := redis.NewClient(&redis.Options{Addr: ":6379"})
+	r := redisson.New(context.Background(), client)
+	ctx := context.Background()
+
+	q := r.NewBoundedBlockingQueue("boundedQueueKey")
+	_, _ = q.Delete(ctx)
+
+	if _, err := q.Offer(ctx, job{ID: 1}); !errors.Is(err, types.ErrCapacityNotSet) {
+		t.Errorf("expect capacity not set, got: %v", err)
+		return
+	}
+	if ok, err := q.TrySetCapacity(ctx, 2); err != nil || !ok {
+		t.Errorf("set capacity failed: %v", err)
+		return
+	}
+	// 测试：容量已存在时不覆盖
+	if ok, _ := q.TrySetCapacity(ctx, 10); ok {
+		t.Error("capacity should not be overwritten")
+		return
+	}
+
+	// 测试：剩余容量不足时不添加任何元素
+	if ok, err := q.Offer(ctx, job{ID: 1}, job{ID: 2}, job{ID: 3}); err != nil || ok {
+		t.Errorf("offer should fail: %v", err)
+		return
+	}
+	if ok, err := q.Offer(ctx, job{ID: 1}, job{ID: 2}); err != nil || !ok {
+		t.Errorf("offer failed: %v", err)
+		return
+	}
+	if remaining, _ := q.RemainingCapacity(ctx); remaining != 0 {
+		t.Errorf("unexpected remaining capacity: %d", remaining)
+		return
+	}
+
+	// 测试：队列已满时等待，取出元素后被唤醒
+	putCtx, cancel := context.WithTimeout(ctx, 100*time.Millisecond)
+	defer cancel()
+	if err := q.Put(putCtx, job{ID: 3}); !errors.Is(err, types.ErrWaitCanceled) {
+		t.Errorf("put should be canceled: %v", err)
+		return
+	}
+	go func() {
+		time.Sleep(200 * time.Millisecond)
+		var j job
+		_ = q.Take(ctx, &j)
+	}()
+	start := time.Now()
+	if err := q.Put(ctx, job{ID: 3}); err != nil {
+		t.Error(err)
+		return
+	}
+	// 重新尝试的间隔为 1s，能在其之前添加说明收到了归还容量的通知
+	if elapsed := time.Since(start); elapsed > 900*time.Millisecond {
+		t.Errorf("put should be woken by release: %v", elapsed)
+		return
+	}
+
+	var j job
+	if ok, err := q.Poll(ctx, &j); err != nil || !ok || j.ID != 2 {
+		t.Errorf("unexpected job: %+v, %v", j, err)
+		return
+	}
+	if items, _ := q.DrainTo(ctx, 0); len(items) != 1 {
+		t.Errorf("unexpected items: %d", len(items))
+		return
+	}
+	if remaining, _ := q.RemainingCapacity(ctx); remaining != 2 {
+		t.Errorf("unexpected remaining capacity: %d", remaining)
+	}
+	runtime.KeepAlive(r)
+}
+
+// TestBoundedBlockingQueue_Capacity
+// @Description: 测试：剩余容量由容量减去元素数量得出，任何方式取出元素都不会丢失容量；从其他有界队列取出时唤醒其生产者
+// @param t
+func TestBoundedBlockingQueue_Capacity(t *testing.T) {
+	client := redis.NewClient(&redis.Options{Addr: ":6379"})
+	r := redisson.New(context.Background(), client)
+	ctx := context.Background()
+
+	q1 := r.NewBoundedBlockingQueue("boundedCapacityKey1")
+	q2 := r.NewBoundedBlockingQueue("boundedCapacityKey2")
+	_, _ = q1.Delete(ctx)
+	_, _ = q2.Delete(ctx)
+	_, _ = q1.TrySetCapacity(ctx, 1)
+	_, _ = q2.TrySetCapacity(ctx, 1)
+
+	// 模拟取出元素后进程退出：不经过有界队列直接取出，容量仍然可用
+	if ok, err := q1.Offer(ctx, job{ID: 1}); err != nil || !ok {
+		t.Errorf("offer failed: %v", err)
+		return
+	}
+	client.LPop(ctx, "boundedCapacityKey1")
+	if remaining, _ := q1.RemainingCapacity(ctx); remaining != 1 {
+		t.Errorf("unexpected remaining capacity: %d", remaining)
+		return
+	}
+
+	// 测试：PollFromAny 从其他有界队列取出元素时，唤醒该队列上等待添加的生产者
+	if ok, err := q2.Offer(ctx, job{ID: 2}); err != nil || !ok {
+		t.Errorf("offer failed: %v", err)
+		return
+	}
+	go func() {
+		time.Sleep(200 * time.Millisecond)
+		var j job
+		_, _, _ = q1.PollFromAny(ctx, &j, time.Second, "boundedCapacityKey2")
+	}()
+	start := time.Now()
+	if err := q2.Put(ctx, job{ID: 3}); err != nil {
+		t.Error(err)
+		return
+	}
+	if elapsed := time.Since(start); elapsed > 900*time.Millisecond {
+		t.Errorf("put should be woken by poll from any: %v", elapsed)
+	}
+
+	_, _ = q1.Delete(ctx)
+	_, _ = q2.Delete(ctx)
+	runtime.KeepAlive(r)
+}
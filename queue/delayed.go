@@ -0,0 +1,334 @@
+package queue
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/MaricoHan/redisson/mutex"
+	"github.com/MaricoHan/redisson/pkg/backend"
+	"github.com/MaricoHan/redisson/pkg/object"
+	"github.com/MaricoHan/redisson/pkg/utils"
+	"github.com/MaricoHan/redisson/pkg/utils/pubsub"
+)
+
+var delayedQueueScript = struct {
+	offerScript    *backend.Script
+	transferScript *backend.Script
+	sizeScript     *backend.Script
+}{}
+
+const (
+	// delayedQueueActionOffer 新元素的到期时间早于其他所有元素时发布的动作
+	delayedQueueActionOffer = "offer"
+
+	// delayedIDLen zset 成员中元素 id 的长度：成员为 id + 元素，避免相同的元素互相覆盖
+	delayedIDLen = 36
+
+	// transferBatchSize 每次最多转移的元素数量
+	transferBatchSize = 100
+	// transferMaxDelay 两次转移之间的最长间隔，避免错过通知或服务器与本地时钟不一致时等待过久
+	transferMaxDelay = 5 * time.Second
+	// transferRetryDelay 获取转移锁或转移失败后重试的间隔
+	transferRetryDelay = time.Second
+)
+
+// DelayedQueue 是延迟队列：元素按到期时间保存在 zset 中，到期后由后台协程原子地转移到目标 BlockingQueue，
+// 消费者从目标队列中取出。zset 是目标队列的附属 key，与其位于同一个 hash slot，元素使用目标队列的编解码器。
+// 转移协程运行在 Redisson 实例中，同一实例中同名的 DelayedQueue 共用一个转移协程，
+// 通过互斥锁保证集群中同一时间只有一个实例在转移；不再使用时需要调用 Close，
+// 同名的 DelayedQueue 全部关闭后停止转移协程并释放转移锁
+type DelayedQueue struct {
+	*object.Object
+	target    *BlockingQueue
+	scheduler *TransferScheduler
+	closeOnce sync.Once
+}
+
+func NewDelayedQueue(root *mutex.Root, scheduler *TransferScheduler, target *BlockingQueue) *DelayedQueue {
+	root.Logger.Debugf("创建延迟队列实例: %s", target.Name())
+
+	d := &DelayedQueue{
+		Object:    target.Companion("delayed"),
+		target:    target,
+		scheduler: scheduler,
+	}
+	scheduler.start(d)
+	return d
+}
+
+// Offer 添加元素，元素在 delay 之后转移到目标队列；delay 小于等于 0 时在下一次转移时立即转移
+func (d *DelayedQueue) Offer(ctx context.Context, v interface{}, delay time.Duration) error {
+	data, err := d.Encode(v)
+	if err != nil {
+		return err
+	}
+	if delay < 0 {
+		delay = 0
+	}
+
+	member := uuid.New().String() + string(data)
+	keys := []string{d.Key(), d.Root().RedisChannelName}
+	err = d.Root().Eval(ctx, delayedQueueScript.offerScript, keys, member, int64(delay/time.Millisecond), d.Key()+":"+delayedQueueActionOffer).Err()
+	if err != nil {
+		d.Root().Logger.Errorf("添加延迟队列元素失败: %s, 错误: %v", d.Name(), err)
+		return err
+	}
+	return nil
+}
+
+// Size 返回尚未转移到目标队列的元素数量
+func (d *DelayedQueue) Size(ctx context.Context) (int64, error) {
+	res, err := d.Root().Eval(ctx, delayedQueueScript.sizeScript, []string{d.Key()}).Int64()
+	if err != nil {
+		d.Root().Logger.Errorf("查询延迟队列长度失败: %s, 错误: %v", d.Name(), err)
+		return 0, err
+	}
+	return res, nil
+}
+
+// Target 返回元素到期后转移到的目标队列
+func (d *DelayedQueue) Target() *BlockingQueue {
+	return d.target
+}
+
+// Close 关闭当前 DelayedQueue；同名的 DelayedQueue 全部关闭后停止转移协程并等待其退出，
+// 持有转移锁时释放，由其他实例接替转移
+func (d *DelayedQueue) Close() {
+	d.closeOnce.Do(func() {
+		d.scheduler.stop(d)
+	})
+}
+
+// transferLoop 获取转移锁后持续转移到期的元素，直到 ctx 被取消
+func (d *DelayedQueue) transferLoop(ctx context.Context) {
+	lockName := d.Name() + ":transfer"
+	for {
+		// 锁对象释放后不能再次使用，每次获取都重新创建；锁的获取与释放需要在同一个协程中
+		lock := mutex.NewMutex(d.Root(), lockName, mutex.WithWaitTimeout(mutex.WaitForever))
+		if err := lock.Lock(ctx); err != nil {
+			if ctx.Err() != nil {
+				d.Root().Logger.Debugf("延迟队列转移协程收到退出信号: %s", d.Name())
+				return
+			}
+			d.Root().Logger.Errorf("获取延迟队列转移锁失败: %s, 错误: %v", d.Name(), err)
+			if !d.sleep(ctx, transferRetryDelay) {
+				return
+			}
+			continue
+		}
+
+		d.Root().Logger.Infof("开始转移延迟队列元素: %s", d.Name())
+		d.transferUntilDone(ctx)
+		if err := lock.Unlock(context.Background()); err != nil {
+			d.Root().Logger.Warnf("释放延迟队列转移锁失败: %s, 错误: %v", d.Name(), err)
+		}
+		return
+	}
+}
+
+// transferUntilDone 转移到期的元素，并等待到下一个元素到期、收到更早到期的元素的通知或 redis 订阅重连。
+// 转移锁续期失败时其他实例可能同时转移，由于转移是原子的，不会重复或丢失元素
+func (d *DelayedQueue) transferUntilDone(ctx context.Context) {
+	sub := pubsub.Subscribe(utils.ChannelName(d.Key()), pubsub.WithClock(d.Root().Clock))
+	defer sub.Close()
+	// 重连前发布的通知可能已经丢失，重连后立即转移
+	reconnect := pubsub.Subscribe(d.Root().ReconnectChannelName(), pubsub.WithClock(d.Root().Clock))
+	defer reconnect.Close()
+
+	timer := d.Root().NewTimer(transferMaxDelay)
+	defer timer.Stop()
+
+	for {
+		wait, err := d.transfer(ctx)
+		if err != nil {
+			if ctx.Err() != nil {
+				return
+			}
+			d.Root().Logger.Errorf("转移延迟队列元素失败: %s, 错误: %v", d.Name(), err)
+			wait = transferRetryDelay
+		}
+
+		if !timer.Stop() {
+			select {
+			case <-timer.C():
+			default:
+			}
+		}
+		timer.Reset(wait)
+
+		select {
+		case <-ctx.Done():
+			d.Root().Logger.Debugf("延迟队列转移协程收到退出信号: %s", d.Name())
+			return
+		case <-sub.Channel():
+			d.Root().Logger.Debugf("收到更早到期的延迟队列元素: %s", d.Name())
+		case <-reconnect.Channel():
+			d.Root().Logger.Debugf("redis 订阅已重连，立即转移延迟队列元素: %s", d.Name())
+		case <-timer.C():
+		}
+	}
+}
+
+// transfer 转移一批到期的元素，返回距离下一个元素到期的时间
+func (d *DelayedQueue) transfer(ctx context.Context) (time.Duration, error) {
+	res, err := d.Root().Eval(ctx, delayedQueueScript.transferScript, []string{d.Key(), d.target.Key()}, transferBatchSize, delayedIDLen).Int64Slice()
+	if err != nil {
+		return 0, err
+	}
+	if res[0] > 0 {
+		d.Root().Logger.Debugf("转移延迟队列元素: %s, 数量: %d", d.Name(), res[0])
+	}
+
+	wait := time.Duration(res[1]) * time.Millisecond
+	if res[1] < 0 || wait > transferMaxDelay {
+		wait = transferMaxDelay
+	}
+	return wait, nil
+}
+
+// sleep 等待 dur，ctx 被取消时返回 false
+func (d *DelayedQueue) sleep(ctx context.Context, dur time.Duration) bool {
+	timer := d.Root().NewTimer(dur)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return false
+	case <-timer.C():
+		return true
+	}
+}
+
+// TransferScheduler 由 Redisson 实例持有，为每个延迟队列启动一个转移协程，同名的 DelayedQueue 共用，随实例释放而退出
+type TransferScheduler struct {
+	root *mutex.Root
+
+	mu     sync.Mutex
+	ctx    context.Context          // Run 之前为空
+	queues map[string]*transferTask // key 为延迟队列的 key
+}
+
+type transferTask struct {
+	queue  *DelayedQueue // 执行转移的 DelayedQueue，同名的 DelayedQueue 转移的是相同的 key
+	refs   int           // 未关闭的同名 DelayedQueue 数量
+	cancel context.CancelFunc
+	done   chan struct{} // Run 之前为空
+}
+
+func NewTransferScheduler(root *mutex.Root) *TransferScheduler {
+	return &TransferScheduler{
+		root:   root,
+		queues: make(map[string]*transferTask),
+	}
+}
+
+// Run 为已创建的与之后创建的延迟队列启动转移协程，直到 ctx 被取消
+func (s *TransferScheduler) Run(ctx context.Context) {
+	s.root.Logger.Info("启动延迟队列转移调度")
+
+	s.mu.Lock()
+	s.ctx = ctx
+	for _, task := range s.queues {
+		s.run(task)
+	}
+	s.mu.Unlock()
+
+	<-ctx.Done()
+	s.root.Logger.Info("延迟队列转移调度收到退出信号")
+}
+
+func (s *TransferScheduler) start(d *DelayedQueue) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if task, ok := s.queues[d.Key()]; ok {
+		task.refs++
+		return
+	}
+	task := &transferTask{queue: d, refs: 1}
+	s.queues[d.Key()] = task
+	if s.ctx != nil {
+		s.run(task)
+	}
+}
+
+func (s *TransferScheduler) stop(d *DelayedQueue) {
+	s.mu.Lock()
+	task, ok := s.queues[d.Key()]
+	if !ok {
+		s.mu.Unlock()
+		return
+	}
+	task.refs--
+	if task.refs > 0 {
+		s.mu.Unlock()
+		return
+	}
+	delete(s.queues, d.Key())
+	s.mu.Unlock()
+
+	if task.done != nil {
+		task.cancel()
+		<-task.done
+	}
+}
+
+// run 启动转移协程，调用方需持有 mu
+func (s *TransferScheduler) run(task *transferTask) {
+	ctx, cancel := context.WithCancel(s.ctx)
+	task.cancel = cancel
+	task.done = make(chan struct{})
+	go func() {
+		defer close(task.done)
+		task.queue.transferLoop(ctx)
+	}()
+}
+
+func init() {
+	delayedQueueScript.offerScript = backend.NewScript("delayedQueue.offer", backend.NowMillisLua+`
+	-- KEYS[1] 延迟队列（zset，score 为到期时间戳）
+	-- KEYS[2] 发布订阅的channel
+	-- ARGV[1] 成员：元素 id + 元素
+	-- ARGV[2] 延迟时间，单位：ms
+	-- ARGV[3] 新元素最早到期时发布的消息
+	redis.call('zadd',KEYS[1],nowMillis() + tonumber(ARGV[2]),ARGV[1])
+	-- 新元素最早到期时通知转移协程提前转移
+	local first = redis.call('zrange',KEYS[1],0,0)
+	if first[1] == ARGV[1] then
+		redis.call('publish',KEYS[2],ARGV[3])
+	end
+	return 1
+`)
+
+	delayedQueueScript.transferScript = backend.NewScript("delayedQueue.transfer", backend.NowMillisLua+`
+	-- KEYS[1] 延迟队列（zset，score 为到期时间戳）
+	-- KEYS[2] 目标队列（list）
+	-- ARGV[1] 最多转移的数量
+	-- ARGV[2] 成员中元素 id 的长度
+	-- 返回值：{转移的数量, 距离下一个元素到期的时间(ms)，没有元素时为 -1}
+	local now = nowMillis()
+	local members = redis.call('zrangebyscore',KEYS[1],'-inf',now,'limit',0,tonumber(ARGV[1]))
+	local idLen = tonumber(ARGV[2])
+	for _, member in ipairs(members) do
+		redis.call('rpush',KEYS[2],string.sub(member,idLen + 1))
+		redis.call('zrem',KEYS[1],member)
+	end
+	local next = redis.call('zrange',KEYS[1],0,0,'withscores')
+	if next[1] == nil then
+		return {#members, -1}
+	end
+	local wait = tonumber(next[2]) - now
+	if wait < 0 then
+		wait = 0
+	end
+	return {#members, wait}
+`)
+
+	delayedQueueScript.sizeScript = backend.NewScript("delayedQueue.size", `
+	-- KEYS[1] 延迟队列（zset）
+	return redis.call('zcard',KEYS[1])
+`)
+}
@@ -0,0 +1,138 @@
+package queue_test
+
+import (
+	"context"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+
+	"github.com/MaricoHan/redisson"
+)
+
+func TestDelayedQueue(t *testing.T) {
+	client := redis.NewClient(&redis.Options{Addr: ":6379"})
+	r := redisson.New(context.Background(), client)
+	ctx := context.Background()
+
+	target := r.NewBlockingQueue("delayedTargetKey")
+	_, _ = target.Delete(ctx)
+	d := r.NewDelayedQueue(target)
+	defer d.Close()
+	_, _ = d.Delete(ctx)
+
+	if err := d.Offer(ctx, job{ID: 1}, 3*time.Second); err != nil {
+		t.Error(err)
+		return
+	}
+	if size, _ := d.Size(ctx); size != 1 {
+		t.Errorf("unexpected size: %d", size)
+		return
+	}
+
+	// 测试：更早到期的元素提前转移
+	time.Sleep(100 * time.Millisecond)
+	start := time.Now()
+	_ = d.Offer(ctx, job{ID: 2}, 300*time.Millisecond)
+	var j job
+	if ok, err := target.PollTimeout(ctx, &j, 2*time.Second); err != nil || !ok || j.ID != 2 {
+		t.Errorf("unexpected job: %+v, %v", j, err)
+		return
+	}
+	if elapsed := time.Since(start); elapsed < 300*time.Millisecond || elapsed > 2*time.Second {
+		t.Errorf("unexpected delay: %v", elapsed)
+		return
+	}
+	if ok, err := target.Poll(ctx, &j); err != nil || ok {
+		t.Errorf("job should not be due: %+v, %v", j, err)
+		return
+	}
+
+	// 测试：相同的元素不会互相覆盖
+	_ = d.Offer(ctx, job{ID: 3}, 0)
+	_ = d.Offer(ctx, job{ID: 3}, 0)
+	for i := 0; i < 2; i++ {
+		if ok, err := target.PollTimeout(ctx, &j, 2*time.Second); err != nil || !ok || j.ID != 3 {
+			t.Errorf("unexpected job: %+v, %v", j, err)
+			return
+		}
+	}
+	runtime.KeepAlive(r)
+}
+
+func TestDelayedQueue_SingleMover(t *testing.T) {
+	ctx := context.Background()
+	client := redis.NewClient(&redis.Options{Addr: ":6379"})
+	r1 := redisson.New(ctx, client)
+	r2 := redisson.New(ctx, client)
+
+	target := r1.NewBlockingQueue("delayedSingleKey")
+	_, _ = target.Delete(ctx)
+	d1 := r1.NewDelayedQueue(target)
+	d2 := r2.NewDelayedQueue(r2.NewBlockingQueue("delayedSingleKey"))
+	defer d2.Close()
+	_, _ = d1.Delete(ctx)
+
+	for i := 0; i < 10; i++ {
+		_ = d1.Offer(ctx, job{ID: i}, 200*time.Millisecond)
+	}
+	time.Sleep(time.Second)
+	if size, _ := target.Size(ctx); size != 10 {
+		t.Errorf("unexpected size: %d", size)
+		return
+	}
+
+	// 测试：关闭后由其他实例接替转移
+	d1.Close()
+	_ = d1.Offer(ctx, job{ID: 10}, 100*time.Millisecond)
+	time.Sleep(time.Second)
+	if size, _ := target.Size(ctx); size != 11 {
+		t.Errorf("delayed job should be transferred by another instance: %d", size)
+	}
+	runtime.KeepAlive(r1)
+	runtime.KeepAlive(r2)
+}
+
+// TestDelayedQueue_SharedTransfer
+// @Description: 测试：同一实例中同名的 DelayedQueue 共用转移协程，全部关闭后才停止转移
+// @param t
+func TestDelayedQueue_SharedTransfer(t *testing.T) {
+	ctx := context.Background()
+	client := redis.NewClient(&redis.Options{Addr: ":6379"})
+	r := redisson.New(ctx, client)
+
+	target := r.NewBlockingQueue("delayedSharedKey")
+	_, _ = target.Delete(ctx)
+	d1 := r.NewDelayedQueue(target)
+	d2 := r.NewDelayedQueue(r.NewBlockingQueue("delayedSharedKey"))
+	_, _ = d1.Delete(ctx)
+
+	// 关闭其中一个，另一个仍在使用，转移继续进行
+	d1.Close()
+	_ = d2.Offer(ctx, job{ID: 1}, 100*time.Millisecond)
+	var j job
+	if ok, err := target.PollTimeout(ctx, &j, 2*time.Second); err != nil || !ok || j.ID != 1 {
+		t.Errorf("unexpected job: %+v, %v", j, err)
+		return
+	}
+
+	if n := client.Exists(ctx, "delayedSharedKey:delayed:transfer").Val(); n != 1 {
+		t.Error("transfer lock should be held")
+		return
+	}
+
+	// 全部关闭后停止转移，转移锁被释放
+	d2.Close()
+	if n := client.Exists(ctx, "delayedSharedKey:delayed:transfer").Val(); n != 0 {
+		t.Error("transfer lock should be released")
+		return
+	}
+	_ = d2.Offer(ctx, job{ID: 2}, 0)
+	time.Sleep(300 * time.Millisecond)
+	if size, _ := d2.Size(ctx); size != 1 {
+		t.Errorf("closed queue should not transfer: %d", size)
+	}
+	_, _ = d2.Delete(ctx)
+	runtime.KeepAlive(r)
+}
@@ -0,0 +1,47 @@
+package queue
+
+import (
+	"context"
+
+	"github.com/MaricoHan/redisson/mutex"
+	"github.com/MaricoHan/redisson/pkg/object"
+)
+
+// Deque 是双端队列，两端都可以添加与取出元素；作为 Queue 使用时从队尾添加、从队头取出
+type Deque struct {
+	*Queue
+}
+
+func NewDeque(root *mutex.Root, name string, opts ...object.Option) *Deque {
+	return &Deque{Queue: NewQueue(root, name, opts...)}
+}
+
+// OfferFirst 在队头添加元素，多个元素依次添加，最后一个位于队头
+func (d *Deque) OfferFirst(ctx context.Context, v ...interface{}) error {
+	return d.offer(ctx, head, v...)
+}
+
+// OfferLast 在队尾添加元素，与 Offer 相同
+func (d *Deque) OfferLast(ctx context.Context, v ...interface{}) error {
+	return d.offer(ctx, tail, v...)
+}
+
+// PollFirst 取出队头元素，与 Poll 相同
+func (d *Deque) PollFirst(ctx context.Context, v interface{}) (bool, error) {
+	return d.poll(ctx, head, v)
+}
+
+// PollLast 取出队尾元素
+func (d *Deque) PollLast(ctx context.Context, v interface{}) (bool, error) {
+	return d.poll(ctx, tail, v)
+}
+
+// PeekFirst 查看队头元素，与 Peek 相同
+func (d *Deque) PeekFirst(ctx context.Context, v interface{}) (bool, error) {
+	return d.peek(ctx, head, v)
+}
+
+// PeekLast 查看队尾元素
+func (d *Deque) PeekLast(ctx context.Context, v interface{}) (bool, error) {
+	return d.peek(ctx, tail, v)
+}
@@ -0,0 +1,186 @@
+// Package queue 提供基于 redis list 的分布式队列
+package queue
+
+import (
+	"context"
+
+	"github.com/go-redis/redis/v8"
+
+	"github.com/MaricoHan/redisson/mutex"
+	"github.com/MaricoHan/redisson/pkg/backend"
+	"github.com/MaricoHan/redisson/pkg/object"
+)
+
+var queueScript = struct {
+	offerScript *backend.Script
+	pollScript  *backend.Script
+	peekScript  *backend.Script
+	sizeScript  *backend.Script
+	drainScript *backend.Script
+}{}
+
+// 队列的两端
+const (
+	head = "head"
+	tail = "tail"
+)
+
+// Queue 是先进先出的分布式队列：从队尾(list 右侧)入队，从队头(list 左侧)出队，元素通过对象的编解码器编码
+type Queue struct {
+	*object.Object
+}
+
+func NewQueue(root *mutex.Root, name string, opts ...object.Option) *Queue {
+	root.Logger.Debugf("创建队列实例: %s", name)
+	return &Queue{Object: object.New(root, name).With(opts...)}
+}
+
+// Offer 在队尾添加元素
+func (q *Queue) Offer(ctx context.Context, v ...interface{}) error {
+	return q.offer(ctx, tail, v...)
+}
+
+// Poll 取出队头元素并解码到 v 中，v 必须为指针；队列为空时返回 false
+func (q *Queue) Poll(ctx context.Context, v interface{}) (bool, error) {
+	return q.poll(ctx, head, v)
+}
+
+// Peek 查看队头元素但不取出；队列为空时返回 false
+func (q *Queue) Peek(ctx context.Context, v interface{}) (bool, error) {
+	return q.peek(ctx, head, v)
+}
+
+// Size 返回元素数量
+func (q *Queue) Size(ctx context.Context) (int64, error) {
+	res, err := q.Root().Eval(ctx, queueScript.sizeScript, []string{q.Key()}).Int64()
+	if err != nil {
+		q.Root().Logger.Errorf("查询队列长度失败: %s, 错误: %v", q.Name(), err)
+		return 0, err
+	}
+	return res, nil
+}
+
+// DrainTo 原子地从队头取出最多 max 个元素，max 小于等于 0 时取出所有元素
+func (q *Queue) DrainTo(ctx context.Context, max int64) ([]Item, error) {
+	res, err := q.Root().Eval(ctx, queueScript.drainScript, []string{q.Key()}, max).StringSlice()
+	if err != nil {
+		q.Root().Logger.Errorf("批量取出队列元素失败: %s, 错误: %v", q.Name(), err)
+		return nil, err
+	}
+
+	items := make([]Item, len(res))
+	for i := range res {
+		items[i] = q.item(res[i])
+	}
+	return items, nil
+}
+
+func (q *Queue) offer(ctx context.Context, side string, values ...interface{}) error {
+	if len(values) == 0 {
+		return nil
+	}
+
+	args := make([]interface{}, 0, len(values)+1)
+	args = append(args, side)
+	for _, v := range values {
+		data, err := q.Encode(v)
+		if err != nil {
+			return err
+		}
+		args = append(args, data)
+	}
+	if err := q.Root().Eval(ctx, queueScript.offerScript, []string{q.Key()}, args...).Err(); err != nil {
+		q.Root().Logger.Errorf("添加队列元素失败: %s, 错误: %v", q.Name(), err)
+		return err
+	}
+	return nil
+}
+
+func (q *Queue) poll(ctx context.Context, side string, v interface{}) (bool, error) {
+	return q.decode(q.Root().Eval(ctx, queueScript.pollScript, []string{q.Key()}, side), v, "取出")
+}
+
+func (q *Queue) peek(ctx context.Context, side string, v interface{}) (bool, error) {
+	return q.decode(q.Root().Eval(ctx, queueScript.peekScript, []string{q.Key()}, side), v, "查看")
+}
+
+// decode 解码脚本返回的元素，nil 表示队列为空
+func (q *Queue) decode(cmd *redis.Cmd, v interface{}, desc string) (bool, error) {
+	res, err := cmd.Text()
+	if err == redis.Nil {
+		return false, nil
+	}
+	if err != nil {
+		q.Root().Logger.Errorf("%s队列元素失败: %s, 错误: %v", desc, q.Name(), err)
+		return false, err
+	}
+	return true, q.Decode([]byte(res), v)
+}
+
+func (q *Queue) item(data string) Item {
+	return Item{raw: []byte(data), object: q.Object}
+}
+
+// Item 是从队列中取出的元素，在调用 Value 时才解码
+type Item struct {
+	raw    []byte
+	object *object.Object
+}
+
+// Value 将元素解码到 v 中，v 必须为指针
+func (i Item) Value(v interface{}) error {
+	return i.object.Decode(i.raw, v)
+}
+
+func init() {
+	queueScript.offerScript = backend.NewScript("queue.offer", `
+	-- KEYS[1] 队列
+	-- ARGV[1] 添加到哪一端：head、tail
+	-- ARGV[2...] 元素
+	-- 分批展开参数，unpack 的参数个数受 lua 栈大小的限制
+	local command = ARGV[1] == 'head' and 'lpush' or 'rpush'
+	local n = 0
+	for i = 2, #ARGV, 1000 do
+		n = redis.call(command,KEYS[1],unpack(ARGV,i,math.min(i+999,#ARGV)))
+	end
+	return n
+`)
+
+	queueScript.pollScript = backend.NewScript("queue.poll", `
+	-- KEYS[1] 队列
+	-- ARGV[1] 从哪一端取出：head、tail
+	if ARGV[1] == 'head' then
+		return redis.call('lpop',KEYS[1])
+	end
+	return redis.call('rpop',KEYS[1])
+`)
+
+	queueScript.peekScript = backend.NewScript("queue.peek", `
+	-- KEYS[1] 队列
+	-- ARGV[1] 查看哪一端：head、tail
+	if ARGV[1] == 'head' then
+		return redis.call('lindex',KEYS[1],0)
+	end
+	return redis.call('lindex',KEYS[1],-1)
+`)
+
+	queueScript.sizeScript = backend.NewScript("queue.size", `
+	-- KEYS[1] 队列
+	return redis.call('llen',KEYS[1])
+`)
+
+	queueScript.drainScript = backend.NewScript("queue.drain", `
+	-- KEYS[1] 队列
+	-- ARGV[1] 最多取出的数量，小于等于 0 时取出所有元素
+	local max = tonumber(ARGV[1])
+	if max <= 0 then
+		max = redis.call('llen',KEYS[1])
+	end
+	if max == 0 then
+		return {}
+	end
+	local items = redis.call('lrange',KEYS[1],0,max - 1)
+	redis.call('ltrim',KEYS[1],max,-1)
+	return items
+`)
+}
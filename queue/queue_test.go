@@ -0,0 +1,195 @@
+package queue_test
+
+import (
+	"context"
+	"errors"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+
+	"github.com/MaricoHan/redisson"
+	"github.com/MaricoHan/redisson/pkg/clock"
+	"github.com/MaricoHan/redisson/pkg/types"
+)
+
+type job struct {
+	ID int
+}
+
+func TestQueue(t *testing.T) {
+	client := redis.NewClient(&redis.Options{Addr: ":6379"})
+	client.Del(context.Background(), "queueKey")
+	r := redisson.New(context.Background(), client)
+	ctx := context.Background()
+
+	q := r.NewQueue("queueKey")
+	var j job
+	if ok, err := q.Poll(ctx, &j); err != nil || ok {
+		t.Errorf("queue should be empty: %v", err)
+		return
+	}
+	if err := q.Offer(ctx, job{ID: 1}, job{ID: 2}, job{ID: 3}); err != nil {
+		t.Error(err)
+		return
+	}
+	if ok, err := q.Peek(ctx, &j); err != nil || !ok || j.ID != 1 {
+		t.Errorf("unexpected head: %+v, %v", j, err)
+		return
+	}
+	if ok, err := q.Poll(ctx, &j); err != nil || !ok || j.ID != 1 {
+		t.Errorf("unexpected head: %+v, %v", j, err)
+		return
+	}
+	if size, _ := q.Size(ctx); size != 2 {
+		t.Errorf("unexpected size: %d", size)
+		return
+	}
+
+	// 测试：批量取出
+	_ = q.Offer(ctx, job{ID: 4})
+	items, err := q.DrainTo(ctx, 2)
+	if err != nil || len(items) != 2 {
+		t.Errorf("unexpected items: %v, %v", items, err)
+		return
+	}
+	if err := items[1].Value(&j); err != nil || j.ID != 3 {
+		t.Errorf("unexpected item: %+v, %v", j, err)
+		return
+	}
+	if items, _ = q.DrainTo(ctx, 0); len(items) != 1 {
+		t.Errorf("all items should be drained: %d", len(items))
+		return
+	}
+	if items, _ = q.DrainTo(ctx, 0); len(items) != 0 {
+		t.Errorf("queue should be empty: %d", len(items))
+	}
+}
+
+func TestDeque(t *testing.T) {
+	client := redis.NewClient(&redis.Options{Addr: ":6379"})
+	client.Del(context.Background(), "dequeKey")
+	r := redisson.New(context.Background(), client)
+	ctx := context.Background()
+
+	d := r.NewDeque("dequeKey")
+	_ = d.OfferLast(ctx, 2)
+	_ = d.OfferFirst(ctx, 1)
+	_ = d.Offer(ctx, 3)
+
+	var v int
+	if ok, _ := d.PeekLast(ctx, &v); !ok || v != 3 {
+		t.Errorf("unexpected tail: %d", v)
+		return
+	}
+	if ok, _ := d.PollLast(ctx, &v); !ok || v != 3 {
+		t.Errorf("unexpected tail: %d", v)
+		return
+	}
+	if ok, _ := d.PollFirst(ctx, &v); !ok || v != 1 {
+		t.Errorf("unexpected head: %d", v)
+		return
+	}
+	if ok, _ := d.PeekFirst(ctx, &v); !ok || v != 2 {
+		t.Errorf("unexpected head: %d", v)
+	}
+}
+
+func TestBlockingQueue(t *testing.T) {
+	client := redis.NewClient(&redis.Options{Addr: ":6379"})
+	client.Del(context.Background(), "blockingQueueKey", "blockingQueueKey2")
+	r := redisson.New(context.Background(), client)
+	ctx := context.Background()
+
+	q := r.NewBlockingQueue("blockingQueueKey")
+
+	// 测试：阻塞等待直到有元素
+	go func() {
+		time.Sleep(200 * time.Millisecond)
+		_ = r.NewQueue("blockingQueueKey").Offer(ctx, job{ID: 1})
+	}()
+	var j job
+	start := time.Now()
+	if err := q.Take(ctx, &j); err != nil || j.ID != 1 {
+		t.Errorf("unexpected item: %+v, %v", j, err)
+		return
+	}
+	t.Log("take waited:", time.Since(start))
+
+	// 测试：取消等待
+	cctx, cancel := context.WithTimeout(ctx, 100*time.Millisecond)
+	defer cancel()
+	if err := q.Take(cctx, &j); !errors.Is(err, types.ErrWaitCanceled) {
+		t.Errorf("take should be canceled: %v", err)
+		return
+	}
+	if ok, err := q.PollTimeout(ctx, &j, time.Second); err != nil || ok {
+		t.Errorf("poll should time out: %v", err)
+		return
+	}
+
+	// 测试：从多个队列中取出
+	_ = r.NewQueue("blockingQueueKey2").Offer(ctx, job{ID: 2})
+	name, ok, err := q.PollFromAny(ctx, &j, time.Second, "blockingQueueKey2")
+	if err != nil || !ok || name != "blockingQueueKey2" || j.ID != 2 {
+		t.Errorf("unexpected poll from any: %s, %+v, %v", name, j, err)
+		return
+	}
+
+	// 测试：阻塞双端队列
+	d := r.NewBlockingDeque("blockingQueueKey")
+	_ = d.Offer(ctx, job{ID: 3}, job{ID: 4})
+	if err := d.TakeLast(ctx, &j); err != nil || j.ID != 4 {
+		t.Errorf("unexpected tail: %+v, %v", j, err)
+		return
+	}
+	if ok, err := d.PollFirstTimeout(ctx, &j, time.Second); err != nil || !ok || j.ID != 3 {
+		t.Errorf("unexpected head: %+v, %v", j, err)
+	}
+
+	runtime.KeepAlive(r)
+}
+
+// TestBlockingQueue_Clock
+// @Description: 测试：阻塞取出的截止时间基于实例的时钟
+// @param t
+func TestBlockingQueue_Clock(t *testing.T) {
+	client := redis.NewClient(&redis.Options{Addr: ":6379"})
+	client.Del(context.Background(), "blockingQueueClockKey")
+	fake := clock.NewFake(time.Now())
+	r := redisson.NewWithConfig(context.Background(), client, &redisson.Config{Clock: fake})
+	ctx := context.Background()
+
+	q := r.NewBlockingQueue("blockingQueueClockKey")
+
+	// 假时钟未推进时，超过 timeout 的真实时间后仍在等待
+	done := make(chan bool, 1)
+	go func() {
+		var j job
+		ok, err := q.PollTimeout(ctx, &j, time.Second)
+		if err != nil {
+			t.Error(err)
+		}
+		done <- ok
+	}()
+	select {
+	case <-done:
+		t.Error("poll should wait for the fake clock")
+		return
+	case <-time.After(1500 * time.Millisecond):
+	}
+
+	// 推进假时钟后，本轮阻塞命令结束即超时返回
+	fake.Advance(2 * time.Second)
+	select {
+	case ok := <-done:
+		if ok {
+			t.Error("poll should time out")
+		}
+	case <-time.After(2 * time.Second):
+		t.Error("poll should time out after the fake clock advances")
+	}
+
+	runtime.KeepAlive(r)
+}
@@ -0,0 +1,384 @@
+package queue
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/google/uuid"
+
+	"github.com/MaricoHan/redisson/mutex"
+	"github.com/MaricoHan/redisson/pkg/backend"
+	"github.com/MaricoHan/redisson/pkg/object"
+	"github.com/MaricoHan/redisson/pkg/types"
+	"github.com/MaricoHan/redisson/pkg/utils"
+	"github.com/MaricoHan/redisson/pkg/utils/pubsub"
+)
+
+var reliableQueueScript = struct {
+	offerScript *backend.Script
+	pollScript  *backend.Script
+	ackScript   *backend.Script
+	reapScript  *backend.Script
+}{}
+
+const (
+	// reliableQueueActionOffer 有新元素或元素被重新投递时发布的动作
+	reliableQueueActionOffer = "offer"
+
+	// reapBatchSize 每次最多重新投递的元素数量
+	reapBatchSize = 100
+)
+
+// reliableQueueOptions 定义 ReliableQueue 的配置选项
+type reliableQueueOptions struct {
+	visibilityTimeout time.Duration
+	maxAttempts       int64
+	reapInterval      time.Duration
+	objectOptions     []object.Option
+}
+
+// ReliableQueueOption 设置 ReliableQueue 的可选项
+type ReliableQueueOption func(opt *reliableQueueOptions)
+
+// WithVisibilityTimeout 设置元素取出后等待确认的最长时间，超时未确认的元素会被重新投递，默认 30s
+func WithVisibilityTimeout(timeout time.Duration) ReliableQueueOption {
+	return func(opt *reliableQueueOptions) {
+		opt.visibilityTimeout = timeout
+	}
+}
+
+// WithMaxAttempts 设置元素最多被投递的次数，达到后仍未确认的元素转入死信队列，默认 5，小于等于 0 时不限制
+func WithMaxAttempts(n int64) ReliableQueueOption {
+	return func(opt *reliableQueueOptions) {
+		opt.maxAttempts = n
+	}
+}
+
+// WithReapInterval 设置检查超时未确认元素的间隔，默认 1s
+func WithReapInterval(interval time.Duration) ReliableQueueOption {
+	return func(opt *reliableQueueOptions) {
+		opt.reapInterval = interval
+	}
+}
+
+// WithObjectOptions 设置分布式对象的通用可选项，例如 object.WithCodec
+func WithObjectOptions(opts ...object.Option) ReliableQueueOption {
+	return func(opt *reliableQueueOptions) {
+		opt.objectOptions = append(opt.objectOptions, opts...)
+	}
+}
+
+func (o *reliableQueueOptions) checkAndInit() {
+	if o.visibilityTimeout <= 0 {
+		o.visibilityTimeout = 30 * time.Second
+	}
+	if o.maxAttempts == 0 {
+		o.maxAttempts = 5
+	}
+	if o.reapInterval <= 0 {
+		o.reapInterval = time.Second
+	}
+}
+
+// ReliableQueue 是取出后需要确认的队列：Take 在取出元素的同时把它记入处理中的 zset(score 为确认的截止时间)，
+// Ack 后才真正删除；消费者崩溃等原因导致超时未确认的元素，由后台协程重新投递到队头，
+// 投递次数达到上限后转入死信队列。消费者以实例的 Root.UUID 标识。
+// 不再使用时需要调用 Close 停止后台协程
+type ReliableQueue struct {
+	*object.Object
+	options *reliableQueueOptions
+
+	processingKey string // 处理中的元素 id（zset，score 为确认的截止时间戳）
+	messagesKey   string // 元素 id -> 元素（hash）
+	attemptsKey   string // 元素 id -> 已投递次数（hash）
+	ownersKey     string // 元素 id -> 当前持有者（hash）
+	deadLetters   *Queue
+
+	release   chan struct{}
+	closeOnce sync.Once
+}
+
+// Message 是从 ReliableQueue 中取出的元素，处理完成后需要调用 Ack
+type Message struct {
+	ID       string
+	Attempts int64 // 包括本次在内的投递次数
+
+	token string // 持有者标识：消费者 UUID + 投递次数，重新投递后旧的持有者无法确认
+	Item
+}
+
+func NewReliableQueue(root *mutex.Root, name string, opts ...ReliableQueueOption) *ReliableQueue {
+	options := &reliableQueueOptions{}
+	for i := range opts {
+		opts[i](options)
+	}
+	options.checkAndInit()
+
+	root.Logger.Debugf("创建可靠队列实例: %s, 确认超时: %v, 最多投递次数: %d", name, options.visibilityTimeout, options.maxAttempts)
+
+	o := object.New(root, name, "processing", "messages", "attempts", "owners", "dead-letter").With(options.objectOptions...)
+	q := &ReliableQueue{
+		Object:        o,
+		options:       options,
+		processingKey: o.CompanionKey("processing"),
+		messagesKey:   o.CompanionKey("messages"),
+		attemptsKey:   o.CompanionKey("attempts"),
+		ownersKey:     o.CompanionKey("owners"),
+		deadLetters:   &Queue{Object: o.Companion("dead-letter")},
+		release:       make(chan struct{}),
+	}
+
+	go q.reapLoop()
+	return q
+}
+
+// Offer 在队尾添加元素
+func (q *ReliableQueue) Offer(ctx context.Context, v ...interface{}) error {
+	if len(v) == 0 {
+		return nil
+	}
+
+	args := make([]interface{}, 0, 2*len(v)+1)
+	args = append(args, q.Key()+":"+reliableQueueActionOffer)
+	for i := range v {
+		data, err := q.Encode(v[i])
+		if err != nil {
+			return err
+		}
+		args = append(args, uuid.New().String(), data)
+	}
+
+	keys := []string{q.Key(), q.messagesKey, q.Root().RedisChannelName}
+	if err := q.Root().Eval(ctx, reliableQueueScript.offerScript, keys, args...).Err(); err != nil {
+		q.Root().Logger.Errorf("添加可靠队列元素失败: %s, 错误: %v", q.Name(), err)
+		return err
+	}
+	return nil
+}
+
+// Poll 取出队头元素，队列为空时返回 false；元素需要在确认超时之前调用 Ack
+func (q *ReliableQueue) Poll(ctx context.Context) (*Message, bool, error) {
+	keys := []string{q.Key(), q.processingKey, q.messagesKey, q.attemptsKey, q.ownersKey}
+	res, err := q.Root().Eval(ctx, reliableQueueScript.pollScript, keys, q.Root().UUID, int64(q.options.visibilityTimeout/time.Millisecond)).Slice()
+	if err != nil {
+		if err == redis.Nil {
+			return nil, false, nil
+		}
+		q.Root().Logger.Errorf("取出可靠队列元素失败: %s, 错误: %v", q.Name(), err)
+		return nil, false, err
+	}
+
+	return &Message{
+		ID:       res[0].(string),
+		Attempts: res[2].(int64),
+		token:    res[3].(string),
+		Item:     Item{raw: []byte(res[1].(string)), object: q.Object},
+	}, true, nil
+}
+
+// Take 取出队头元素，队列为空时等待，直到取到元素或 ctx 被取消；元素需要在确认超时之前调用 Ack
+func (q *ReliableQueue) Take(ctx context.Context) (*Message, error) {
+	// 先订阅，再取出，避免错过通知
+	sub := pubsub.Subscribe(utils.ChannelName(q.Key()), pubsub.WithClock(q.Root().Clock))
+	defer sub.Close()
+
+	// 除通知外定期检查，通知可能因为重连等原因丢失
+	timer := q.Root().NewTimer(q.options.reapInterval)
+	defer timer.Stop()
+
+	for {
+		msg, ok, err := q.Poll(ctx)
+		if err != nil && ctx.Err() != nil {
+			return nil, q.waitCanceled(ctx)
+		}
+		if err != nil || ok {
+			return msg, err
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, q.waitCanceled(ctx)
+		case <-sub.Channel():
+			if !timer.Stop() {
+				select {
+				case <-timer.C():
+				default:
+				}
+			}
+		case <-timer.C():
+		}
+		timer.Reset(q.options.reapInterval)
+	}
+}
+
+// waitCanceled 返回等待被调用方取消的错误
+func (q *ReliableQueue) waitCanceled(ctx context.Context) error {
+	q.Root().Logger.Debugf("等待可靠队列元素被调用方取消: %s, 原因: %v", q.Name(), ctx.Err())
+	return types.Wrap(types.ErrWaitCanceled, ctx.Err())
+}
+
+// Ack 确认元素已处理完成并删除；元素已超时被重新投递(无论投递给哪个消费者)时返回 types.ErrMismatch
+func (q *ReliableQueue) Ack(ctx context.Context, msg *Message) error {
+	keys := []string{q.processingKey, q.messagesKey, q.attemptsKey, q.ownersKey}
+	res, err := q.Root().Eval(ctx, reliableQueueScript.ackScript, keys, msg.ID, msg.token).Int64()
+	if err != nil {
+		q.Root().Logger.Errorf("确认可靠队列元素失败: %s, 错误: %v", q.Name(), err)
+		return err
+	}
+	if res == 0 {
+		q.Root().Logger.Warnf("确认可靠队列元素失败，元素已超时被重新投递: %s, 元素: %s", q.Name(), msg.ID)
+		return types.ErrMismatch
+	}
+	return nil
+}
+
+// Size 返回等待投递的元素数量，不包括处理中的元素
+func (q *ReliableQueue) Size(ctx context.Context) (int64, error) {
+	res, err := q.Root().Eval(ctx, queueScript.sizeScript, []string{q.Key()}).Int64()
+	if err != nil {
+		q.Root().Logger.Errorf("查询可靠队列长度失败: %s, 错误: %v", q.Name(), err)
+		return 0, err
+	}
+	return res, nil
+}
+
+// DeadLetters 返回死信队列，其中的元素为投递次数达到上限仍未确认的原始元素
+func (q *ReliableQueue) DeadLetters() *Queue {
+	return q.deadLetters
+}
+
+// Close 停止重新投递超时元素的后台协程
+func (q *ReliableQueue) Close() {
+	q.closeOnce.Do(func() {
+		close(q.release)
+	})
+}
+
+// reapLoop 定期把超时未确认的元素重新投递到队头，投递次数达到上限的转入死信队列
+func (q *ReliableQueue) reapLoop() {
+	ticker := q.Root().NewTicker(q.options.reapInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-q.release:
+			q.Root().Logger.Debugf("可靠队列重新投递协程收到退出信号: %s", q.Name())
+			return
+		case <-ticker.C():
+			if err := q.reap(context.Background()); err != nil {
+				q.Root().Logger.Errorf("重新投递可靠队列元素失败: %s, 错误: %v", q.Name(), err)
+			}
+		}
+	}
+}
+
+func (q *ReliableQueue) reap(ctx context.Context) error {
+	keys := []string{q.Key(), q.processingKey, q.messagesKey, q.attemptsKey, q.ownersKey, q.deadLetters.Key(), q.Root().RedisChannelName}
+	res, err := q.Root().Eval(ctx, reliableQueueScript.reapScript, keys, q.options.maxAttempts, reapBatchSize, q.Key()+":"+reliableQueueActionOffer).Int64Slice()
+	if err != nil {
+		return err
+	}
+	if res[0] > 0 || res[1] > 0 {
+		q.Root().Logger.Infof("重新投递可靠队列元素: %s, 数量: %d, 转入死信队列: %d", q.Name(), res[0], res[1])
+	}
+	return nil
+}
+
+func init() {
+	reliableQueueScript.offerScript = backend.NewScript("reliableQueue.offer", `
+	-- KEYS[1] 队列（list，元素 id）
+	-- KEYS[2] 元素 id -> 元素（hash）
+	-- KEYS[3] 发布订阅的channel
+	-- ARGV[1] 添加后发布的消息
+	-- ARGV[2...] 元素 id、元素交替的列表
+	for i = 2, #ARGV, 2 do
+		redis.call('hset',KEYS[2],ARGV[i],ARGV[i + 1])
+		redis.call('rpush',KEYS[1],ARGV[i])
+	end
+	redis.call('publish',KEYS[3],ARGV[1])
+	return 1
+`)
+
+	reliableQueueScript.pollScript = backend.NewScript("reliableQueue.poll", backend.NowMillisLua+`
+	-- KEYS[1] 队列（list，元素 id）
+	-- KEYS[2] 处理中的元素 id（zset，score 为确认的截止时间戳）
+	-- KEYS[3] 元素 id -> 元素（hash）
+	-- KEYS[4] 元素 id -> 已投递次数（hash）
+	-- KEYS[5] 元素 id -> 当前持有者（hash）
+	-- ARGV[1] 消费者标识
+	-- ARGV[2] 确认超时，单位：ms
+	-- 返回值：{元素 id, 元素, 投递次数, 持有者标识}，队列为空时返回 nil
+	while true do
+		local id = redis.call('lpop',KEYS[1])
+		if not id then
+			return nil
+		end
+		local payload = redis.call('hget',KEYS[3],id)
+		-- 元素已被删除时跳过
+		if payload then
+			local attempts = redis.call('hincrby',KEYS[4],id,1)
+			local token = ARGV[1] .. ':' .. attempts
+			redis.call('zadd',KEYS[2],nowMillis() + tonumber(ARGV[2]),id)
+			redis.call('hset',KEYS[5],id,token)
+			return {id, payload, attempts, token}
+		end
+	end
+`)
+
+	reliableQueueScript.ackScript = backend.NewScript("reliableQueue.ack", `
+	-- KEYS[1] 处理中的元素 id（zset）
+	-- KEYS[2] 元素 id -> 元素（hash）
+	-- KEYS[3] 元素 id -> 已投递次数（hash）
+	-- KEYS[4] 元素 id -> 当前持有者（hash）
+	-- ARGV[1] 元素 id
+	-- ARGV[2] 持有者标识
+	if redis.call('hget',KEYS[4],ARGV[1]) ~= ARGV[2] then
+		return 0
+	end
+	redis.call('zrem',KEYS[1],ARGV[1])
+	redis.call('hdel',KEYS[2],ARGV[1])
+	redis.call('hdel',KEYS[3],ARGV[1])
+	redis.call('hdel',KEYS[4],ARGV[1])
+	return 1
+`)
+
+	reliableQueueScript.reapScript = backend.NewScript("reliableQueue.reap", backend.NowMillisLua+`
+	-- KEYS[1] 队列（list，元素 id）
+	-- KEYS[2] 处理中的元素 id（zset，score 为确认的截止时间戳）
+	-- KEYS[3] 元素 id -> 元素（hash）
+	-- KEYS[4] 元素 id -> 已投递次数（hash）
+	-- KEYS[5] 元素 id -> 当前持有者（hash）
+	-- KEYS[6] 死信队列（list，元素）
+	-- KEYS[7] 发布订阅的channel
+	-- ARGV[1] 最多投递次数，小于等于 0 时不限制
+	-- ARGV[2] 最多处理的数量
+	-- ARGV[3] 重新投递后发布的消息
+	-- 返回值：{重新投递的数量, 转入死信队列的数量}
+	local maxAttempts = tonumber(ARGV[1])
+	local ids = redis.call('zrangebyscore',KEYS[2],'-inf',nowMillis(),'limit',0,tonumber(ARGV[2]))
+	local redelivered, dead = 0, 0
+	for _, id in ipairs(ids) do
+		redis.call('zrem',KEYS[2],id)
+		redis.call('hdel',KEYS[5],id)
+		local attempts = tonumber(redis.call('hget',KEYS[4],id) or '0')
+		if maxAttempts > 0 and attempts >= maxAttempts then
+			local payload = redis.call('hget',KEYS[3],id)
+			if payload then
+				redis.call('rpush',KEYS[6],payload)
+			end
+			redis.call('hdel',KEYS[3],id)
+			redis.call('hdel',KEYS[4],id)
+			dead = dead + 1
+		else
+			redis.call('lpush',KEYS[1],id)
+			redelivered = redelivered + 1
+		end
+	end
+	if redelivered > 0 then
+		redis.call('publish',KEYS[7],ARGV[3])
+	end
+	return {redelivered, dead}
+`)
+}
@@ -0,0 +1,116 @@
+package queue_test
+
+import (
+	"context"
+	"errors"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+
+	"github.com/MaricoHan/redisson"
+	"github.com/MaricoHan/redisson/pkg/types"
+	"github.com/MaricoHan/redisson/queue"
+)
+
+func TestReliableQueue(t *testing.T) {
+	client := redis.NewClient(&redis.Options{Addr: ":6379"})
+	r := redisson.New(context.Background(), client)
+	ctx := context.Background()
+
+	q := r.NewReliableQueue("reliableQueueKey", queue.WithVisibilityTimeout(200*time.Millisecond), queue.WithMaxAttempts(2), queue.WithReapInterval(50*time.Millisecond))
+	defer q.Close()
+	_, _ = q.Delete(ctx)
+	_, _ = q.DeadLetters().Delete(ctx)
+
+	if _, ok, err := q.Poll(ctx); err != nil || ok {
+		t.Errorf("queue should be empty: %v", err)
+		return
+	}
+	if err := q.Offer(ctx, job{ID: 1}, job{ID: 2}); err != nil {
+		t.Error(err)
+		return
+	}
+
+	// 测试：确认后不再投递
+	msg, ok, err := q.Poll(ctx)
+	if err != nil || !ok || msg.Attempts != 1 {
+		t.Errorf("unexpected message: %+v, %v", msg, err)
+		return
+	}
+	var j job
+	if err := msg.Value(&j); err != nil || j.ID != 1 {
+		t.Errorf("unexpected job: %+v, %v", j, err)
+		return
+	}
+	if err := q.Ack(ctx, msg); err != nil {
+		t.Error(err)
+		return
+	}
+
+	// 测试：超时未确认的元素被重新投递，旧的持有者无法确认
+	msg, _, _ = q.Poll(ctx)
+	if size, _ := q.Size(ctx); size != 0 {
+		t.Errorf("unexpected size: %d", size)
+		return
+	}
+	takeCtx, cancel := context.WithTimeout(ctx, 2*time.Second)
+	defer cancel()
+	again, err := q.Take(takeCtx)
+	if err != nil || again.ID != msg.ID || again.Attempts != 2 {
+		t.Errorf("message should be redelivered: %+v, %v", again, err)
+		return
+	}
+	if err := q.Ack(ctx, msg); !errors.Is(err, types.ErrMismatch) {
+		t.Errorf("stale message should not be acked: %v", err)
+		return
+	}
+
+	// 测试：投递次数达到上限后转入死信队列
+	time.Sleep(500 * time.Millisecond)
+	if size, _ := q.Size(ctx); size != 0 {
+		t.Errorf("message should not be redelivered: %d", size)
+		return
+	}
+	if ok, err := q.DeadLetters().Poll(ctx, &j); err != nil || !ok || j.ID != 2 {
+		t.Errorf("unexpected dead letter: %+v, %v", j, err)
+		return
+	}
+
+	// 测试：等待被取消
+	cancelCtx, cancel2 := context.WithTimeout(ctx, 100*time.Millisecond)
+	defer cancel2()
+	if _, err := q.Take(cancelCtx); !errors.Is(err, types.ErrWaitCanceled) {
+		t.Errorf("take should be canceled: %v", err)
+	}
+	runtime.KeepAlive(r)
+}
+
+func TestReliableQueue_TakeNotify(t *testing.T) {
+	client := redis.NewClient(&redis.Options{Addr: ":6379"})
+	r := redisson.New(context.Background(), client)
+	ctx := context.Background()
+
+	q := r.NewReliableQueue("reliableQueueNotifyKey", queue.WithReapInterval(10*time.Second))
+	defer q.Close()
+	_, _ = q.Delete(ctx)
+
+	go func() {
+		time.Sleep(100 * time.Millisecond)
+		_ = q.Offer(ctx, job{ID: 1})
+	}()
+
+	// 定期检查的间隔较长，能在其之前取到说明收到了添加通知
+	takeCtx, cancel := context.WithTimeout(ctx, 2*time.Second)
+	defer cancel()
+	msg, err := q.Take(takeCtx)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if err := q.Ack(ctx, msg); err != nil {
+		t.Error(err)
+	}
+	runtime.KeepAlive(r)
+}
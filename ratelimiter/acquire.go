@@ -0,0 +1,48 @@
+package ratelimiter
+
+import (
+	"context"
+	"time"
+
+	"github.com/MaricoHan/redisson/mutex"
+	"github.com/MaricoHan/redisson/pkg/clock"
+	"github.com/MaricoHan/redisson/pkg/types"
+)
+
+// acquire 循环调用 attempt 获取许可，直到获取成功或 ctx 被取消。
+// attempt 返回 0 表示获取成功，否则返回需要等待的时间
+func acquire(ctx context.Context, root *mutex.Root, name string, attempt func(ctx context.Context) (time.Duration, error)) error {
+	var timer clock.Timer
+	defer func() {
+		if timer != nil {
+			timer.Stop()
+		}
+	}()
+
+	for {
+		wait, err := attempt(ctx)
+		if err != nil {
+			if ctx.Err() != nil {
+				return types.Wrap(types.ErrWaitCanceled, ctx.Err())
+			}
+			return err
+		}
+		if wait == 0 {
+			return nil
+		}
+
+		root.Logger.Debugf("限流许可不足: %s, 等待 %v 后重试", name, wait)
+		if timer == nil {
+			timer = root.NewTimer(wait)
+		} else {
+			timer.Reset(wait)
+		}
+
+		select {
+		case <-ctx.Done():
+			root.Logger.Warnf("获取限流许可被调用方取消: %s, 原因: %v", name, ctx.Err())
+			return types.Wrap(types.ErrWaitCanceled, ctx.Err())
+		case <-timer.C():
+		}
+	}
+}
@@ -0,0 +1,215 @@
+package ratelimiter
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/MaricoHan/redisson/mutex"
+	"github.com/MaricoHan/redisson/pkg/backend"
+	"github.com/MaricoHan/redisson/pkg/types"
+)
+
+var rateLimiterScript = struct {
+	trySetRateScript *backend.Script
+	acquireScript    *backend.Script
+	availableScript  *backend.Script
+}{}
+
+// RateType 定义限流的作用范围
+type RateType int
+
+const (
+	// Overall 所有 Redisson 实例共享同一个令牌桶
+	Overall RateType = iota
+	// PerClient 每个 Redisson 实例独立拥有一个令牌桶，速率配置相同
+	PerClient
+)
+
+func (t RateType) String() string {
+	switch t {
+	case Overall:
+		return "overall"
+	case PerClient:
+		return "per_client"
+	}
+	return fmt.Sprintf("RateType(%d)", int(t))
+}
+
+// RateLimiter 是基于令牌桶的分布式限流器：每 interval 生成 rate 个令牌，令牌桶容量为 rate。
+// 速率配置与令牌桶都保存在 redis 中，令牌的计算与扣减在 lua 脚本中原子地完成
+type RateLimiter struct {
+	root *mutex.Root
+	Name string
+
+	key       string // 速率配置
+	valueKey  string // Overall 模式的令牌桶，与 key 位于同一个 hash slot
+	clientKey string // PerClient 模式下当前实例的令牌桶，与 key 位于同一个 hash slot
+}
+
+func NewRateLimiter(root *mutex.Root, name string) *RateLimiter {
+	key := root.Key(name)
+
+	root.Logger.Debugf("创建限流器实例: %s", name)
+
+	return &RateLimiter{
+		root:      root,
+		Name:      name,
+		key:       key,
+		valueKey:  "{" + key + "}:value",
+		clientKey: "{" + key + "}:value:" + root.UUID,
+	}
+}
+
+// TrySetRate 设置速率：每 interval 允许 rate 个许可，rateType 决定许可在所有实例间共享还是每个实例单独计算。
+// 速率已被设置时不会覆盖，返回 false
+func (l *RateLimiter) TrySetRate(ctx context.Context, rate int64, interval time.Duration, rateType RateType) (bool, error) {
+	if rate <= 0 || interval < time.Millisecond {
+		return false, fmt.Errorf("invalid rate: %d per %v", rate, interval)
+	}
+
+	res, err := l.root.Eval(ctx, rateLimiterScript.trySetRateScript, []string{l.key},
+		rate, int64(interval/time.Millisecond), int(rateType)).Int64()
+	if err != nil {
+		l.root.Logger.Errorf("设置限流速率失败: %s, 错误: %v", l.Name, err)
+		return false, err
+	}
+	if res == 0 {
+		l.root.Logger.Debugf("限流速率已存在，不覆盖: %s", l.Name)
+		return false, nil
+	}
+
+	l.root.Logger.Infof("设置限流速率成功: %s, 类型: %s, 速率: %d/%v", l.Name, rateType, rate, interval)
+	return true, nil
+}
+
+// TryAcquire 尝试获取 permits 个许可，许可不足时立即返回 false
+func (l *RateLimiter) TryAcquire(ctx context.Context, permits int64) (bool, error) {
+	wait, err := l.tryAcquire(ctx, permits)
+	if err != nil {
+		return false, err
+	}
+	return wait == 0, nil
+}
+
+// Acquire 获取 permits 个许可，许可不足时等待，直到获取成功或 ctx 被取消
+func (l *RateLimiter) Acquire(ctx context.Context, permits int64) error {
+	return acquire(ctx, l.root, l.Name, func(ctx context.Context) (time.Duration, error) {
+		return l.tryAcquire(ctx, permits)
+	})
+}
+
+// tryAcquire 尝试获取许可，成功时返回 0，否则返回需要等待的时间
+func (l *RateLimiter) tryAcquire(ctx context.Context, permits int64) (time.Duration, error) {
+	res, err := l.root.Eval(ctx, rateLimiterScript.acquireScript, []string{l.key, l.valueKey, l.clientKey}, permits).Int64()
+	if err != nil {
+		l.root.Logger.Errorf("执行限流脚本失败: %s, 错误: %v", l.Name, err)
+		return 0, err
+	}
+
+	switch res {
+	case -1:
+		return 0, types.ErrRateNotSet
+	case -2:
+		return 0, types.ErrPermitsExceedRate
+	}
+	return time.Duration(res) * time.Millisecond, nil
+}
+
+// AvailablePermits 查询当前可用的许可数量
+func (l *RateLimiter) AvailablePermits(ctx context.Context) (int64, error) {
+	res, err := l.root.Eval(ctx, rateLimiterScript.availableScript, []string{l.key, l.valueKey, l.clientKey}).Int64()
+	if err != nil {
+		l.root.Logger.Errorf("查询限流可用许可失败: %s, 错误: %v", l.Name, err)
+		return 0, err
+	}
+	if res < 0 {
+		return 0, types.ErrRateNotSet
+	}
+	return res, nil
+}
+
+// rateLimiterLuaPrelude 限流脚本共用的 lua 函数
+const rateLimiterLuaPrelude = backend.NowMillisLua + `
+	-- 读取速率配置，未设置时返回 nil
+	local function getRate(key)
+		local conf = redis.call('hmget',key,'rate','interval','type')
+		if not conf[1] then
+			return nil
+		end
+		return tonumber(conf[1]), tonumber(conf[2]), tonumber(conf[3])
+	end
+
+	-- 令牌桶 key：PerClient 模式下每个客户端独立
+	local function bucketKey(valueKey, clientKey, rateType)
+		if rateType == 1 then
+			return clientKey
+		end
+		return valueKey
+	end
+
+	-- 按经过的时间补充令牌后的令牌数量
+	local function refill(bucket, rate, interval, now)
+		local state = redis.call('hmget',bucket,'tokens','ts')
+		if not state[1] then
+			return rate
+		end
+		local elapsed = math.max(now - tonumber(state[2]), 0)
+		return math.min(rate, tonumber(state[1]) + elapsed * rate / interval)
+	end
+`
+
+func init() {
+	rateLimiterScript.trySetRateScript = backend.NewScript("ratelimiter.trySetRate", `
+	-- KEYS[1] 速率配置
+	-- ARGV[1] 速率
+	-- ARGV[2] 时间间隔，单位：ms
+	-- ARGV[3] 限流类型：0-Overall 1-PerClient
+	if redis.call('hsetnx',KEYS[1],'rate',ARGV[1]) == 0 then
+		return 0
+	end
+	redis.call('hset',KEYS[1],'interval',ARGV[2])
+	redis.call('hset',KEYS[1],'type',ARGV[3])
+	return 1
+`)
+
+	rateLimiterScript.acquireScript = backend.NewScript("ratelimiter.acquire", rateLimiterLuaPrelude+`
+	-- KEYS[1] 速率配置
+	-- KEYS[2] Overall 模式的令牌桶
+	-- KEYS[3] PerClient 模式下当前客户端的令牌桶
+	-- ARGV[1] 许可数量
+	-- 返回值：0-成功 >0-需要等待的时间(ms) -1-未设置速率 -2-许可数量超过速率
+	local rate, interval, rateType = getRate(KEYS[1])
+	if not rate then
+		return -1
+	end
+	local permits = tonumber(ARGV[1])
+	if permits > rate then
+		return -2
+	end
+
+	local bucket = bucketKey(KEYS[2], KEYS[3], rateType)
+	local now = nowMillis()
+	local tokens = refill(bucket, rate, interval, now)
+	if tokens < permits then
+		return math.ceil((permits - tokens) * interval / rate)
+	end
+
+	redis.call('hset',bucket,'tokens',tostring(tokens - permits))
+	redis.call('hset',bucket,'ts',now)
+	-- 经过一个时间间隔后令牌桶必然已满，无需再保留
+	redis.call('pexpire',bucket,interval)
+	return 0
+`)
+
+	rateLimiterScript.availableScript = backend.NewScript("ratelimiter.available", rateLimiterLuaPrelude+`
+	-- KEYS[1] 速率配置
+	-- KEYS[2] Overall 模式的令牌桶
+	-- KEYS[3] PerClient 模式下当前客户端的令牌桶
+	local rate, interval, rateType = getRate(KEYS[1])
+	if not rate then
+		return -1
+	end
+	return math.floor(refill(bucketKey(KEYS[2], KEYS[3], rateType), rate, interval, nowMillis()))
+`)
+}
@@ -0,0 +1,108 @@
+package ratelimiter_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+
+	"github.com/MaricoHan/redisson"
+	"github.com/MaricoHan/redisson/pkg/types"
+	"github.com/MaricoHan/redisson/ratelimiter"
+)
+
+func newClient() *redis.Client {
+	return redis.NewClient(&redis.Options{Addr: ":6379"})
+}
+
+func TestRateLimiter(t *testing.T) {
+	client := newClient()
+	client.Del(context.Background(), "rateLimiterKey", "{rateLimiterKey}:value")
+	r := redisson.New(context.Background(), client)
+
+	limiter := r.NewRateLimiter("rateLimiterKey")
+	if _, err := limiter.TryAcquire(context.Background(), 1); !errors.Is(err, types.ErrRateNotSet) {
+		t.Errorf("expect rate not set, got: %v", err)
+		return
+	}
+
+	ok, err := limiter.TrySetRate(context.Background(), 5, time.Second, ratelimiter.Overall)
+	if err != nil || !ok {
+		t.Errorf("set rate failed: %v", err)
+		return
+	}
+	// 测试：速率已存在时不覆盖
+	if ok, _ = limiter.TrySetRate(context.Background(), 10, time.Second, ratelimiter.Overall); ok {
+		t.Error("rate should not be overwritten")
+		return
+	}
+	if _, err = limiter.TryAcquire(context.Background(), 6); !errors.Is(err, types.ErrPermitsExceedRate) {
+		t.Errorf("expect permits exceed rate, got: %v", err)
+		return
+	}
+
+	// 测试：令牌桶容量为 rate，耗尽后无法立即获取
+	if ok, err = limiter.TryAcquire(context.Background(), 5); err != nil || !ok {
+		t.Errorf("acquire failed: %v", err)
+		return
+	}
+	if ok, _ = limiter.TryAcquire(context.Background(), 1); ok {
+		t.Error("bucket should be empty")
+		return
+	}
+
+	// 测试：其他实例共享同一个令牌桶
+	other := redisson.New(context.Background(), client).NewRateLimiter("rateLimiterKey")
+	if available, _ := other.AvailablePermits(context.Background()); available != 0 {
+		t.Errorf("bucket should be shared, available: %d", available)
+		return
+	}
+
+	// 测试：等待令牌补充后获取成功
+	start := time.Now()
+	if err = limiter.Acquire(context.Background(), 2); err != nil {
+		t.Error(err)
+		return
+	}
+	if cost := time.Since(start); cost < 300*time.Millisecond {
+		t.Errorf("acquire should wait for refill, cost: %v", cost)
+	}
+
+	// 测试：调用方取消等待
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if err = limiter.Acquire(ctx, 5); !errors.Is(err, types.ErrWaitCanceled) {
+		t.Errorf("expect wait canceled, got: %v", err)
+	}
+
+	client.Del(context.Background(), "rateLimiterKey", "{rateLimiterKey}:value")
+}
+
+func TestRateLimiter_PerClient(t *testing.T) {
+	client := newClient()
+	client.Del(context.Background(), "rateLimiterPerClientKey")
+
+	limiter1 := redisson.New(context.Background(), client).NewRateLimiter("rateLimiterPerClientKey")
+	limiter2 := redisson.New(context.Background(), client).NewRateLimiter("rateLimiterPerClientKey")
+	if _, err := limiter1.TrySetRate(context.Background(), 2, time.Minute, ratelimiter.PerClient); err != nil {
+		t.Error(err)
+		return
+	}
+
+	// 测试：每个实例拥有独立的令牌桶
+	if ok, err := limiter1.TryAcquire(context.Background(), 2); err != nil || !ok {
+		t.Errorf("acquire failed: %v", err)
+		return
+	}
+	if ok, _ := limiter1.TryAcquire(context.Background(), 1); ok {
+		t.Error("bucket of limiter1 should be empty")
+		return
+	}
+	if available, _ := limiter2.AvailablePermits(context.Background()); available != 2 {
+		t.Errorf("limiter2 should have its own bucket, available: %d", available)
+	}
+
+	client.Del(context.Background(), "rateLimiterPerClientKey")
+}
@@ -0,0 +1,142 @@
+package ratelimiter
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/MaricoHan/redisson/mutex"
+	"github.com/MaricoHan/redisson/pkg/backend"
+	"github.com/MaricoHan/redisson/pkg/types"
+)
+
+var slidingWindowScript = struct {
+	acquireScript   *backend.Script
+	remainingScript *backend.Script
+}{}
+
+// SlidingWindow 是基于滑动窗口日志的分布式限流器：任意长度为 window 的时间窗口内，每个 key 最多允许 limit 次调用。
+// 每个 key 对应一个 zset，记录窗口内每次调用的时间戳，过期记录的清理与计数在 lua 脚本中原子地完成；
+// 不同 key 之间互不影响，且 zset 在窗口结束后自动过期，适合按客户等维度对大量 key 限流
+type SlidingWindow struct {
+	root   *mutex.Root
+	Name   string
+	limit  int64
+	window time.Duration
+}
+
+// NewSlidingWindow 创建滑动窗口限流器，limit 需要大于 0，window 不小于 1ms，否则获取与查询许可时返回错误
+func NewSlidingWindow(root *mutex.Root, name string, limit int64, window time.Duration) *SlidingWindow {
+	root.Logger.Debugf("创建滑动窗口限流器实例: %s, 限制: %d/%v", name, limit, window)
+
+	return &SlidingWindow{
+		root:   root,
+		Name:   name,
+		limit:  limit,
+		window: window,
+	}
+}
+
+// TryAcquire 尝试为 key 获取 permits 个许可；许可不足时立即返回 false，以及最早可以获取成功的等待时间
+func (w *SlidingWindow) TryAcquire(ctx context.Context, key string, permits int64) (bool, time.Duration, error) {
+	retryAfter, err := w.tryAcquire(ctx, key, permits)
+	if err != nil {
+		return false, 0, err
+	}
+	return retryAfter == 0, retryAfter, nil
+}
+
+// Acquire 为 key 获取 permits 个许可，许可不足时等待，直到获取成功或 ctx 被取消
+func (w *SlidingWindow) Acquire(ctx context.Context, key string, permits int64) error {
+	return acquire(ctx, w.root, w.Name+":"+key, func(ctx context.Context) (time.Duration, error) {
+		return w.tryAcquire(ctx, key, permits)
+	})
+}
+
+func (w *SlidingWindow) tryAcquire(ctx context.Context, key string, permits int64) (time.Duration, error) {
+	if err := w.validate(); err != nil {
+		return 0, err
+	}
+	if permits <= 0 {
+		return 0, fmt.Errorf("invalid permits: %d", permits)
+	}
+	if permits > w.limit {
+		return 0, types.ErrPermitsExceedRate
+	}
+
+	// 同名的限流器共用调用记录，每次调用的标识需要全局唯一，否则相同的成员只会更新时间戳
+	id := uuid.New().String()
+	res, err := w.root.Eval(ctx, slidingWindowScript.acquireScript, []string{w.windowKey(key)},
+		w.limit, int64(w.window/time.Millisecond), permits, id).Int64()
+	if err != nil {
+		w.root.Logger.Errorf("执行滑动窗口限流脚本失败: %s, key: %s, 错误: %v", w.Name, key, err)
+		return 0, err
+	}
+	return time.Duration(res) * time.Millisecond, nil
+}
+
+// Remaining 查询 key 在当前窗口内剩余的许可数量
+func (w *SlidingWindow) Remaining(ctx context.Context, key string) (int64, error) {
+	if err := w.validate(); err != nil {
+		return 0, err
+	}
+	res, err := w.root.Eval(ctx, slidingWindowScript.remainingScript, []string{w.windowKey(key)},
+		w.limit, int64(w.window/time.Millisecond)).Int64()
+	if err != nil {
+		w.root.Logger.Errorf("查询滑动窗口剩余许可失败: %s, key: %s, 错误: %v", w.Name, key, err)
+		return 0, err
+	}
+	return res, nil
+}
+
+// validate 检查限制与窗口长度
+func (w *SlidingWindow) validate() error {
+	if w.limit <= 0 || w.window < time.Millisecond {
+		return fmt.Errorf("invalid limit: %d per %v", w.limit, w.window)
+	}
+	return nil
+}
+
+// windowKey 返回 key 对应的 zset，不使用 hash tag，使不同的 key 分散到集群的各个节点
+func (w *SlidingWindow) windowKey(key string) string {
+	return fmt.Sprintf("%s:%s", w.root.Key(w.Name), key)
+}
+
+func init() {
+	slidingWindowScript.acquireScript = backend.NewScript("slidingWindow.acquire", backend.NowMillisLua+`
+	-- KEYS[1] 调用记录（zset，score 为调用的时间戳）
+	-- ARGV[1] 窗口内允许的调用次数
+	-- ARGV[2] 窗口长度，单位：ms
+	-- ARGV[3] 许可数量
+	-- ARGV[4] 本次调用的唯一标识
+	-- 返回值：0-成功 >0-最早可以获取成功的等待时间(ms)
+	local limit = tonumber(ARGV[1])
+	local window = tonumber(ARGV[2])
+	local permits = tonumber(ARGV[3])
+	local now = nowMillis()
+
+	redis.call('zremrangebyscore',KEYS[1],'-inf',now - window)
+	local count = redis.call('zcard',KEYS[1])
+	if count + permits <= limit then
+		for i = 1, permits do
+			redis.call('zadd',KEYS[1],now,ARGV[4] .. ':' .. i)
+		end
+		redis.call('pexpire',KEYS[1],window)
+		return 0
+	end
+
+	-- 需要等到最早的 count + permits - limit 条记录移出窗口
+	local oldest = redis.call('zrange',KEYS[1],count + permits - limit - 1,count + permits - limit - 1,'withscores')
+	return math.max(tonumber(oldest[2]) + window - now, 1)
+`)
+
+	slidingWindowScript.remainingScript = backend.NewScript("slidingWindow.remaining", backend.NowMillisLua+`
+	-- KEYS[1] 调用记录（zset，score 为调用的时间戳）
+	-- ARGV[1] 窗口内允许的调用次数
+	-- ARGV[2] 窗口长度，单位：ms
+	local count = redis.call('zcount',KEYS[1],'(' .. (nowMillis() - tonumber(ARGV[2])),'+inf')
+	return math.max(tonumber(ARGV[1]) - count, 0)
+`)
+}
@@ -0,0 +1,101 @@
+package ratelimiter_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/MaricoHan/redisson"
+	"github.com/MaricoHan/redisson/pkg/types"
+)
+
+func TestSlidingWindow(t *testing.T) {
+	client := newClient()
+	client.Del(context.Background(), "slidingWindowKey:customer1", "slidingWindowKey:customer2")
+	r := redisson.New(context.Background(), client)
+
+	limiter := r.NewSlidingWindow("slidingWindowKey", 3, 500*time.Millisecond)
+	if _, _, err := limiter.TryAcquire(context.Background(), "customer1", 4); !errors.Is(err, types.ErrPermitsExceedRate) {
+		t.Errorf("expect permits exceed rate, got: %v", err)
+		return
+	}
+	if _, _, err := limiter.TryAcquire(context.Background(), "customer1", 0); err == nil {
+		t.Error("non-positive permits should be rejected")
+		return
+	}
+	// 测试：非法的限制与窗口长度
+	if _, _, err := r.NewSlidingWindow("slidingWindowKey", 0, time.Second).TryAcquire(context.Background(), "customer1", 1); err == nil {
+		t.Error("non-positive limit should be rejected")
+		return
+	}
+	if _, err := r.NewSlidingWindow("slidingWindowKey", 3, time.Microsecond).Remaining(context.Background(), "customer1"); err == nil {
+		t.Error("window shorter than 1ms should be rejected")
+		return
+	}
+
+	for i := 0; i < 3; i++ {
+		ok, _, err := limiter.TryAcquire(context.Background(), "customer1", 1)
+		if err != nil || !ok {
+			t.Errorf("acquire failed: %v", err)
+			return
+		}
+		<-time.After(50 * time.Millisecond)
+	}
+
+	// 测试：窗口内超过限制时拒绝，并返回最早可以重试的时间
+	ok, retryAfter, err := limiter.TryAcquire(context.Background(), "customer1", 1)
+	if err != nil || ok {
+		t.Errorf("acquire should be denied: %v", err)
+		return
+	}
+	if retryAfter <= 0 || retryAfter > 500*time.Millisecond {
+		t.Errorf("unexpected retry after: %v", retryAfter)
+		return
+	}
+	t.Logf("retry after: %v", retryAfter)
+
+	// 测试：不同 key 互不影响
+	if remaining, _ := limiter.Remaining(context.Background(), "customer2"); remaining != 3 {
+		t.Errorf("customer2 should not be limited, remaining: %d", remaining)
+		return
+	}
+
+	// 测试：最早的调用移出窗口后获取成功
+	start := time.Now()
+	if err = limiter.Acquire(context.Background(), "customer1", 1); err != nil {
+		t.Error(err)
+		return
+	}
+	if cost := time.Since(start); cost > retryAfter+100*time.Millisecond {
+		t.Errorf("acquire should succeed after retry after, cost: %v", cost)
+	}
+
+	client.Del(context.Background(), "slidingWindowKey:customer1", "slidingWindowKey:customer2")
+}
+
+// TestSlidingWindow_SameName
+// @Description: 测试：同名的限流器共用调用记录，合计不会超过限制
+// @param t
+func TestSlidingWindow_SameName(t *testing.T) {
+	client := newClient()
+	client.Del(context.Background(), "slidingWindowSameKey:customer1")
+	r := redisson.New(context.Background(), client)
+
+	granted := 0
+	for i := 0; i < 10; i++ {
+		ok, _, err := r.NewSlidingWindow("slidingWindowSameKey", 2, time.Minute).TryAcquire(context.Background(), "customer1", 1)
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		if ok {
+			granted++
+		}
+	}
+	if granted != 2 {
+		t.Errorf("unexpected granted: %d", granted)
+	}
+
+	client.Del(context.Background(), "slidingWindowSameKey:customer1")
+}
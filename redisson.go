@@ -5,22 +5,70 @@ import (
 	"runtime"
 	"strings"
 	"sync"
+	"time"
 
 	"github.com/go-redis/redis/v8"
 	"github.com/google/uuid"
 
+	"github.com/MaricoHan/redisson/bucket"
+	"github.com/MaricoHan/redisson/counter"
+	"github.com/MaricoHan/redisson/hashmap"
 	"github.com/MaricoHan/redisson/mutex"
+	"github.com/MaricoHan/redisson/pkg/backend"
+	"github.com/MaricoHan/redisson/pkg/clientcache"
+	"github.com/MaricoHan/redisson/pkg/clock"
+	"github.com/MaricoHan/redisson/pkg/codec"
 	"github.com/MaricoHan/redisson/pkg/loggers"
+	"github.com/MaricoHan/redisson/pkg/object"
 	"github.com/MaricoHan/redisson/pkg/utils"
 	"github.com/MaricoHan/redisson/pkg/utils/pubsub"
+	"github.com/MaricoHan/redisson/queue"
+	"github.com/MaricoHan/redisson/ratelimiter"
 )
 
 type Redisson struct {
 	root *mutex.Root
+
+	// evictor 在后台清理 MapCache 中已过期的元素
+	evictor *hashmap.EvictionScheduler
+	// blocking 执行阻塞命令的专用客户端，首次使用时创建
+	blocking *backend.BlockingClient
+	// transfers 在后台把延迟队列中到期的元素转移到目标队列
+	transfers *queue.TransferScheduler
 }
 
 type Config struct {
 	Logger loggers.Advanced
+
+	// KeyPrefix 命名空间前缀，会加在所有锁的 key、脚本 KEYS 以及 pubsub 频道名之前，
+	// 用于多个服务共享同一个 redis 时的隔离与 ACL 划分，例如 "order-service:"
+	KeyPrefix string
+
+	// Clock 锁续期、等待重试以及内部 pubsub 发送超时使用的时钟，默认使用真实时间；
+	// 测试中可以传入 clock.NewFake 返回的假时钟，手动推进时间以触发续期、过期与等待超时
+	Clock clock.Clock
+
+	// PreloadScripts 为 true 时在创建实例时把所有脚本上传到 redis(集群模式下上传到每个主节点)，
+	// 否则在脚本首次执行时上传
+	PreloadScripts bool
+
+	// UseFunctions 为 true 时把所有脚本作为带版本号的 redis 7 函数库安装，并以 FCALL 调用。
+	// 创建实例时会检查函数库版本，不一致时升级；redis 版本不支持函数时回退为脚本执行
+	UseFunctions bool
+
+	// Codec 分布式对象(Bucket、Map 等)中值的编解码器，默认为 JSON，可以在创建对象时单独指定
+	Codec codec.Codec
+
+	// ClientSideCaching 为 true 时开启基于 redis 6 CLIENT TRACKING 的客户端缓存：
+	// Bucket、Map 读取的值缓存在本地，redis 中的 key 被修改时由 redis 通知删除。只支持单机与哨兵客户端
+	ClientSideCaching bool
+
+	// ClientCacheSize 客户端缓存最多保存的值的数量，默认 10000
+	ClientCacheSize int
+
+	// ClientCachePrefixes 客户端缓存跟踪的 key 前缀，redis 只通知以这些前缀开头的 key 的变化；
+	// 默认为 KeyPrefix，KeyPrefix 为空时跟踪所有 key
+	ClientCachePrefixes []string
 }
 
 func DefaultConfig() *Config {
@@ -33,29 +81,75 @@ func (c *Config) CheckAndInit() {
 	if c.Logger == nil {
 		c.Logger = loggers.Logger()
 	}
+	if c.Clock == nil {
+		c.Clock = clock.New()
+	}
+	if c.Codec == nil {
+		c.Codec = codec.JSON()
+	}
+	if c.ClientCacheSize <= 0 {
+		c.ClientCacheSize = 10000
+	}
+	if len(c.ClientCachePrefixes) == 0 && c.KeyPrefix != "" {
+		c.ClientCachePrefixes = []string{c.KeyPrefix}
+	}
 }
 
-func New(ctx context.Context, client *redis.Client) *Redisson {
+// New 使用默认配置创建 Redisson，client 可以是单机、哨兵、集群或 Ring 客户端
+func New(ctx context.Context, client redis.UniversalClient) *Redisson {
 	return NewWithConfig(ctx, client, DefaultConfig())
 }
 
-func NewWithConfig(ctx context.Context, client *redis.Client, config *Config) *Redisson {
+func NewWithConfig(ctx context.Context, client redis.UniversalClient, config *Config) *Redisson {
+	config.CheckAndInit()
+
+	var opts []backend.RedisOption
+	if config.UseFunctions {
+		opts = append(opts, backend.WithFunctions())
+	}
+
+	return newRedisson(ctx, &mutex.Root{
+		Client:  client,
+		Backend: backend.NewRedis(client, config.Logger, opts...),
+	}, config)
+}
+
+// NewWithBackend 基于指定的 Backend 创建 Redisson，例如 redissontest 中基于内存的实现
+func NewWithBackend(ctx context.Context, b backend.Backend, config *Config) *Redisson {
 	config.CheckAndInit()
 
+	return newRedisson(ctx, &mutex.Root{
+		Backend: b,
+	}, config)
+}
+
+func newRedisson(ctx context.Context, root *mutex.Root, config *Config) *Redisson {
+	root.UUID = uuid.New().String()
+	root.Logger = config.Logger
+	root.KeyPrefix = config.KeyPrefix
+	root.Clock = config.Clock
+	root.Codec = config.Codec
+	root.RedisChannelName = root.ChannelName("redisson_pubsub")
+
 	redisson := &Redisson{
-		root: &mutex.Root{
-			Client:           client,
-			UUID:             uuid.New().String(),
-			RedisChannelName: utils.ChannelName("redisson_pubsub"),
-			Logger:           config.Logger,
-		},
+		root:      root,
+		evictor:   hashmap.NewEvictionScheduler(root),
+		blocking:  backend.NewBlockingClient(root.Client, root.Logger),
+		transfers: queue.NewTransferScheduler(root),
+	}
+
+	if p, ok := root.Backend.(backend.Preloader); ok && (config.PreloadScripts || config.UseFunctions) {
+		// 预加载失败不影响使用，脚本或函数库会在首次执行时重新上传
+		if err := p.Preload(ctx); err != nil {
+			config.Logger.Errorf("预加载脚本失败: %v", err)
+		}
 	}
 
 	config.Logger.Infof("初始化 Redisson 实例，UUID: %s, Redis通道: %s", redisson.root.UUID, redisson.root.RedisChannelName)
 
 	// 一个实例只建立一个 pubsub 连接
 	// 额外开协程监听 redis 消息，转发给实例内部基于内存实现的 pubsub，再分配给对应的 subscriber。
-	pubSub := client.Subscribe(ctx, redisson.root.RedisChannelName)
+	pubSub := root.Backend.Subscribe(ctx, redisson.root.RedisChannelName)
 	config.Logger.Debugf("订阅 Redis 通道: %s", redisson.root.RedisChannelName)
 
 	gCtx, cancel := context.WithCancel(ctx)
@@ -72,7 +166,13 @@ func NewWithConfig(ctx context.Context, client *redis.Client, config *Config) *R
 			}
 		}()
 
-		var ss []string
+		// 断线重连期间发布的消息可能已经丢失，重新订阅后通知依赖这些消息的对象，例如本地缓存
+		var reconnected <-chan struct{}
+		if r, ok := pubSub.(backend.Reconnector); ok {
+			reconnected = r.Reconnected()
+		}
+
+		var idx int
 		config.Logger.Info("启动 Redis 消息监听协程")
 		for {
 			select {
@@ -80,15 +180,43 @@ func NewWithConfig(ctx context.Context, client *redis.Client, config *Config) *R
 				config.Logger.Info("Redisson pubsub 监听协程收到退出信号")
 				return
 			case msg := <-pubSub.Channel():
-				ss = strings.Split(msg.Payload, ":")
-				config.Logger.Debugf("收到 Redis 消息: %s, 动作: %s, 通道: %s", ss[0], ss[1], msg.Channel)
-				pubsub.Publish(ss[0], ss[1]) // 0:锁名 1:动作(unlock)
+				// 消息格式为 "锁的key:动作"，key 中可能包含命名空间前缀里的 ':'，因此按最后一个 ':' 切分
+				idx = strings.LastIndex(msg.Payload, ":")
+				if idx < 0 {
+					config.Logger.Warnf("收到无法解析的 Redis 消息: %s, 通道: %s", msg.Payload, msg.Channel)
+					continue
+				}
+				config.Logger.Debugf("收到 Redis 消息: %s, 动作: %s, 通道: %s", msg.Payload[:idx], msg.Payload[idx+1:], msg.Channel)
+				pubsub.Publish(utils.ChannelName(msg.Payload[:idx]), msg.Payload[idx+1:])
+			case <-reconnected:
+				config.Logger.Warnf("Redis 订阅连接已重连: %s", root.RedisChannelName)
+				pubsub.Publish(root.ReconnectChannelName(), mutex.ReconnectedMessage)
 			}
 		}
 	}()
 	wg.Wait() // 等待协程启动成功
 	config.Logger.Debug("Redis 消息监听协程启动成功")
 
+	// 与监听协程一同随实例释放而退出
+	go redisson.evictor.Run(gCtx)
+	go redisson.transfers.Run(gCtx)
+	go func(blocking *backend.BlockingClient) {
+		<-gCtx.Done()
+		if err := blocking.Close(); err != nil {
+			config.Logger.Errorf("关闭阻塞命令专用客户端失败: %v", err)
+		}
+	}(redisson.blocking)
+
+	if config.ClientSideCaching && root.Client != nil {
+		cache := clientcache.New(config.ClientCacheSize)
+		// 跟踪连接是专用的，失效通知不经过实例共享的订阅连接
+		if err := clientcache.Track(gCtx, root.Client, cache, config.ClientCachePrefixes, config.Logger); err != nil {
+			config.Logger.Errorf("开启客户端缓存失败，不使用客户端缓存: %v", err)
+		} else {
+			root.ClientCache = cache
+		}
+	}
+
 	// 释放资源
 	runtime.SetFinalizer(redisson, func(_ *Redisson) {
 		config.Logger.Info("释放 Redisson 资源，UUID: " + redisson.root.UUID)
@@ -98,6 +226,20 @@ func NewWithConfig(ctx context.Context, client *redis.Client, config *Config) *R
 	return redisson
 }
 
+// KeyPrefix 返回当前实例使用的命名空间前缀
+func (r Redisson) KeyPrefix() string {
+	return r.root.KeyPrefix
+}
+
+// Locker 是创建锁的方法集合，*Redisson 与 redissontest 的内存实现均实现了该接口，
+// 只使用锁的业务代码依赖该接口即可在单元测试中替换为内存实现
+type Locker interface {
+	NewMutex(name string, options ...mutex.Option) *mutex.Mutex
+	NewRWMutex(name string, options ...mutex.Option) *mutex.RWMutex
+}
+
+var _ Locker = (*Redisson)(nil)
+
 func (r Redisson) NewMutex(name string, options ...mutex.Option) *mutex.Mutex {
 	r.root.Logger.Debugf("创建互斥锁: %s", name)
 	return mutex.NewMutex(r.root, name, options...)
@@ -107,3 +249,104 @@ func (r Redisson) NewRWMutex(name string, options ...mutex.Option) *mutex.RWMute
 	r.root.Logger.Debugf("创建读写锁: %s", name)
 	return mutex.NewRWMutex(r.root, name, options...)
 }
+
+func (r Redisson) NewRateLimiter(name string) *ratelimiter.RateLimiter {
+	r.root.Logger.Debugf("创建限流器: %s", name)
+	return ratelimiter.NewRateLimiter(r.root, name)
+}
+
+// NewSlidingWindow 创建滑动窗口限流器：任意长度为 window 的时间窗口内，每个 key 最多允许 limit 次调用
+func (r Redisson) NewSlidingWindow(name string, limit int64, window time.Duration) *ratelimiter.SlidingWindow {
+	r.root.Logger.Debugf("创建滑动窗口限流器: %s", name)
+	return ratelimiter.NewSlidingWindow(r.root, name, limit, window)
+}
+
+func (r Redisson) NewAtomicLong(name string) *counter.AtomicLong {
+	r.root.Logger.Debugf("创建 AtomicLong: %s", name)
+	return counter.NewAtomicLong(r.root, name)
+}
+
+func (r Redisson) NewAtomicDouble(name string) *counter.AtomicDouble {
+	r.root.Logger.Debugf("创建 AtomicDouble: %s", name)
+	return counter.NewAtomicDouble(r.root, name)
+}
+
+// NewLongAdder 创建 LongAdder，不再使用时需要调用 Close 停止后台合并协程
+func (r Redisson) NewLongAdder(name string, options ...counter.LongAdderOption) *counter.LongAdder {
+	r.root.Logger.Debugf("创建 LongAdder: %s", name)
+	return counter.NewLongAdder(r.root, name, options...)
+}
+
+func (r Redisson) NewIDGenerator(name string) *counter.IDGenerator {
+	r.root.Logger.Debugf("创建 ID 生成器: %s", name)
+	return counter.NewIDGenerator(r.root, name)
+}
+
+// NewBucket 创建 Bucket，可以通过 object.WithCodec 单独指定编解码器
+func (r Redisson) NewBucket(name string, options ...object.Option) *bucket.Bucket {
+	r.root.Logger.Debugf("创建 Bucket: %s", name)
+	return bucket.NewBucket(r.root, name, options...)
+}
+
+// NewMap 创建基于 redis hash 的 Map，可以通过 object.WithCodec 单独指定编解码器
+func (r Redisson) NewMap(name string, options ...object.Option) *hashmap.Map {
+	r.root.Logger.Debugf("创建 Map: %s", name)
+	return hashmap.NewMap(r.root, name, options...)
+}
+
+// NewMapCache 创建支持为每个元素单独设置过期时间与最大空闲时间的 MapCache，
+// 已过期的元素由实例的后台协程定期清理，不再使用时调用 Close 停止清理
+func (r Redisson) NewMapCache(name string, options ...object.Option) *hashmap.MapCache {
+	r.root.Logger.Debugf("创建 MapCache: %s", name)
+	return hashmap.NewMapCache(r.root, r.evictor, name, options...)
+}
+
+// NewLocalCachedMap 创建带进程内缓存的 Map，写入时通过实例的 pubsub 频道同步其他实例的本地缓存，
+// 不再使用时需要调用 Close 取消订阅
+func (r Redisson) NewLocalCachedMap(name string, options ...hashmap.LocalCachedMapOption) *hashmap.LocalCachedMap {
+	r.root.Logger.Debugf("创建 LocalCachedMap: %s", name)
+	return hashmap.NewLocalCachedMap(r.root, name, options...)
+}
+
+func (r Redisson) NewQueue(name string, options ...object.Option) *queue.Queue {
+	r.root.Logger.Debugf("创建队列: %s", name)
+	return queue.NewQueue(r.root, name, options...)
+}
+
+func (r Redisson) NewDeque(name string, options ...object.Option) *queue.Deque {
+	r.root.Logger.Debugf("创建双端队列: %s", name)
+	return queue.NewDeque(r.root, name, options...)
+}
+
+// NewBlockingQueue 创建阻塞队列，阻塞命令在实例的专用客户端上执行，不占用锁等操作的连接池
+func (r Redisson) NewBlockingQueue(name string, options ...object.Option) *queue.BlockingQueue {
+	r.root.Logger.Debugf("创建阻塞队列: %s", name)
+	return queue.NewBlockingQueue(r.root, r.blocking, name, options...)
+}
+
+// NewBlockingDeque 创建阻塞双端队列，阻塞命令在实例的专用客户端上执行，不占用锁等操作的连接池
+func (r Redisson) NewBlockingDeque(name string, options ...object.Option) *queue.BlockingDeque {
+	r.root.Logger.Debugf("创建阻塞双端队列: %s", name)
+	return queue.NewBlockingDeque(r.root, r.blocking, name, options...)
+}
+
+// NewReliableQueue 创建取出后需要确认的可靠队列，超时未确认的元素会被重新投递，
+// 不再使用时需要调用 Close 停止重新投递的后台协程
+func (r Redisson) NewReliableQueue(name string, options ...queue.ReliableQueueOption) *queue.ReliableQueue {
+	r.root.Logger.Debugf("创建可靠队列: %s", name)
+	return queue.NewReliableQueue(r.root, name, options...)
+}
+
+// NewDelayedQueue 创建以 target 为目标的延迟队列，到期的元素由实例的后台协程转移到 target，
+// 集群中同一时间只有一个实例在转移；不再使用时需要调用 Close 停止转移
+func (r Redisson) NewDelayedQueue(target *queue.BlockingQueue) *queue.DelayedQueue {
+	r.root.Logger.Debugf("创建延迟队列: %s", target.Name())
+	return queue.NewDelayedQueue(r.root, r.transfers, target)
+}
+
+// NewBoundedBlockingQueue 创建有界阻塞队列，使用前需要调用 TrySetCapacity 设置容量；
+// 队列已满时 Put 通过实例共享的 pubsub 频道等待消费者腾出容量
+func (r Redisson) NewBoundedBlockingQueue(name string, options ...object.Option) *queue.BoundedBlockingQueue {
+	r.root.Logger.Debugf("创建有界阻塞队列: %s", name)
+	return queue.NewBoundedBlockingQueue(r.root, r.blocking, name, options...)
+}
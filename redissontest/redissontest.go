@@ -0,0 +1,24 @@
+// Package redissontest 提供基于内存实现的 Redisson，无需 redis 服务即可对使用锁的代码进行单元测试
+package redissontest
+
+import (
+	"context"
+
+	"github.com/MaricoHan/redisson"
+	"github.com/MaricoHan/redisson/pkg/backend/memory"
+)
+
+// New 创建一个基于内存实现的 Redisson，每次调用都拥有独立的数据。
+// 内存实现只支持互斥锁(NewMutex)与读写锁(NewRWMutex)使用的脚本；Bucket、计数器、Map、MapCache、
+// LocalCachedMap、队列、限流器等分布式对象在内存实现上执行操作时返回 unsupported script 错误，
+// 阻塞队列的阻塞取出返回 types.ErrBlockingUnsupported，这些对象需要连接 redis 测试
+func New(ctx context.Context) *redisson.Redisson {
+	return NewWithConfig(ctx, redisson.DefaultConfig())
+}
+
+// NewWithConfig 使用指定配置创建一个基于内存实现的 Redisson，支持的对象与 New 相同；
+// config.Clock 同时作用于内存中 key 的过期，传入 clock.NewFake 即可在测试中手动推进时间
+func NewWithConfig(ctx context.Context, config *redisson.Config) *redisson.Redisson {
+	config.CheckAndInit()
+	return redisson.NewWithBackend(ctx, memory.NewWithClock(config.Clock), config)
+}
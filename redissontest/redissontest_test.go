@@ -0,0 +1,245 @@
+package redissontest_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/MaricoHan/redisson"
+	"github.com/MaricoHan/redisson/mutex"
+	"github.com/MaricoHan/redisson/pkg/clock"
+	"github.com/MaricoHan/redisson/pkg/types"
+	"github.com/MaricoHan/redisson/redissontest"
+)
+
+func TestMutex(t *testing.T) {
+	r := redissontest.New(context.Background())
+
+	mutex1 := r.NewMutex("redissontest_mutex")
+	err := mutex1.Lock(context.Background())
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	t.Log("lock successfully")
+
+	waitGroup := sync.WaitGroup{}
+	waitGroup.Add(1)
+	go func() {
+		defer waitGroup.Done()
+
+		// 测试：其他协程无法解锁
+		mutex2 := r.NewMutex("redissontest_mutex")
+		if err := mutex2.Unlock(context.Background()); !errors.Is(err, types.ErrMismatch) {
+			t.Errorf("expect mismatch, got: %v", err)
+		}
+
+		// 测试：收到解锁通知后立即加锁成功，无需等到锁过期
+		start := time.Now()
+		if err := mutex2.Lock(context.Background()); err != nil {
+			t.Error(err)
+			return
+		}
+		if cost := time.Since(start); cost > 5*time.Second {
+			t.Errorf("waiter should be woken up by unlock event, cost: %v", cost)
+		}
+		if err := mutex2.Unlock(context.Background()); err != nil {
+			t.Error(err)
+		}
+	}()
+
+	<-time.After(500 * time.Millisecond)
+	err = mutex1.Unlock(context.Background())
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	t.Log("unlock successfully")
+
+	waitGroup.Wait()
+}
+
+func TestMutex_Expire(t *testing.T) {
+	r := redissontest.New(context.Background())
+
+	// 测试：未续期的锁会自然过期
+	holder := r.NewMutex("redissontest_expire", mutex.WithExpireDuration(300*time.Millisecond))
+	locked := make(chan struct{})
+	go func() {
+		if err := holder.Lock(context.Background()); err != nil {
+			t.Error(err)
+		}
+		close(locked)
+	}()
+	<-locked
+
+	ttl, err := holder.RemainTTL(context.Background())
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if ttl <= 0 || ttl > 300*time.Millisecond {
+		t.Errorf("unexpected ttl: %v", ttl)
+		return
+	}
+
+	waiter := r.NewMutex("redissontest_expire", mutex.WithWaitTimeout(100*time.Millisecond))
+	if err = waiter.Lock(context.Background()); !errors.Is(err, types.ErrWaitTimeout) {
+		t.Errorf("expect wait timeout, got: %v", err)
+		return
+	}
+
+	// 测试：续期协程会维持锁，直到解锁
+	<-time.After(500 * time.Millisecond)
+	if locked, _ := holder.IsLocked(context.Background()); !locked {
+		t.Error("lock should be renewed")
+		return
+	}
+	if ok, err := holder.ForceUnlock(context.Background()); err != nil || !ok {
+		t.Errorf("force unlock failed: %v", err)
+	}
+}
+
+func TestRWMutex(t *testing.T) {
+	r := redissontest.New(context.Background())
+
+	writer := r.NewRWMutex("redissontest_rwmutex")
+	err := writer.Lock(context.Background())
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	t.Log("lock successfully")
+
+	order := make(chan string, 3)
+	waitGroup := sync.WaitGroup{}
+	for i := 0; i < 2; i++ {
+		waitGroup.Add(1)
+		go func() {
+			defer waitGroup.Done()
+			reader := r.NewRWMutex("redissontest_rwmutex")
+			if err := reader.RLock(context.Background()); err != nil {
+				t.Error(err)
+				return
+			}
+			order <- "reader"
+			<-time.After(100 * time.Millisecond)
+			if err := reader.Unlock(context.Background()); err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+
+	<-time.After(300 * time.Millisecond)
+	order <- "writer unlock"
+	err = writer.Unlock(context.Background())
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	waitGroup.Wait()
+
+	// 测试：读锁与写锁互斥，读锁与读锁共存
+	if first := <-order; first != "writer unlock" {
+		t.Errorf("readers should wait for the writer, got: %s", first)
+	}
+	if locked, _ := writer.IsLocked(context.Background()); locked {
+		t.Error("all readers unlocked, lock should be released")
+	}
+}
+
+func TestRWMutex_Downgrade(t *testing.T) {
+	r := redissontest.New(context.Background())
+
+	m := r.NewRWMutex("redissontest_downgrade")
+	err := m.Lock(context.Background())
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	err = m.Downgrade(context.Background())
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	err = m.TryUpgrade(context.Background())
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	err = m.Unlock(context.Background())
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	t.Log("unlock successfully")
+}
+
+func TestMutex_FakeClock(t *testing.T) {
+	fake := clock.NewFake(time.Now())
+	r := redissontest.NewWithConfig(context.Background(), &redisson.Config{Clock: fake})
+
+	holder := r.NewMutex("redissontest_fake_clock", mutex.WithExpireDuration(3*time.Second))
+	err := holder.Lock(context.Background())
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	fake.BlockUntil(1) // 续期协程开始等待
+
+	// 测试：推进时间即可触发等待超时，无需真实等待
+	errCh := make(chan error, 1)
+	go func() {
+		waiter := r.NewMutex("redissontest_fake_clock", mutex.WithWaitTimeout(10*time.Second))
+		errCh <- waiter.Lock(context.Background())
+	}()
+	fake.BlockUntil(3) // 续期定时器 + 等待超时定时器 + 重试定时器
+
+	// 测试：每推进 expiration/3 触发一次续期，锁不会过期
+	for i := 0; i < 30; i++ {
+		fake.Advance(time.Second)
+		if !waitRenewed(holder, 3*time.Second) {
+			t.Errorf("lock should be renewed, round: %d", i)
+			return
+		}
+	}
+
+	select {
+	case err = <-errCh:
+		if !errors.Is(err, types.ErrWaitTimeout) {
+			t.Errorf("expect wait timeout, got: %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Error("waiter should time out after advancing the clock")
+	}
+
+	if err = holder.Unlock(context.Background()); err != nil {
+		t.Error(err)
+	}
+}
+
+// waitRenewed 等待续期协程把锁的剩余时间刷新到 expiration
+func waitRenewed(m *mutex.Mutex, expiration time.Duration) bool {
+	deadline := time.Now().Add(time.Second)
+	for time.Now().Before(deadline) {
+		ttl, err := m.RemainTTL(context.Background())
+		if err == nil && ttl > expiration-time.Second {
+			return true
+		}
+		<-time.After(time.Millisecond)
+	}
+	return false
+}
+
+// TestUnsupported
+// @Description: 测试：内存实现不支持的分布式对象返回错误，而不是静默成功
+// @param t
+func TestUnsupported(t *testing.T) {
+	r := redissontest.New(context.Background())
+
+	if err := r.NewBucket("redissontest_bucket").Set(context.Background(), 1); err == nil {
+		t.Error("bucket should be unsupported")
+	}
+}